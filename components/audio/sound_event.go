@@ -13,7 +13,7 @@ import (
 // SoundEvent represents an active piece of audio. These are dynamically created when
 // a sound is triggered by a source.
 type SoundEvent struct {
-	ecs.Attached
+	ecs.Attached `ecs:"transient"`
 
 	SourceEntity ecs.Entity
 	Sound        ecs.Entity
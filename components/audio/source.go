@@ -7,6 +7,7 @@ import (
 	"tlyakhov/gofoom/ecs"
 )
 
+//ecs:serialize
 type Source struct {
 	ecs.Attached
 }
@@ -14,17 +15,3 @@ type Source struct {
 func (src *Source) String() string {
 	return "Audio Source"
 }
-
-func (src *Source) Construct(data map[string]any) {
-	src.Attached.Construct(data)
-
-	if data == nil {
-		return
-	}
-}
-
-func (src *Source) Serialize() map[string]any {
-	result := src.Attached.Serialize()
-
-	return result
-}
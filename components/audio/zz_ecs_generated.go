@@ -42,3 +42,12 @@ func GetSource(e ecs.Entity) *Source {
 func (*Source) ComponentID() ecs.ComponentID {
 	return SourceCID
 }
+
+func (s *Source) Construct(data map[string]any) {
+	s.Attached.Construct(data)
+}
+
+func (s *Source) Serialize() map[string]any {
+	result := s.Attached.Serialize()
+	return result
+}
@@ -3,6 +3,7 @@
 
 package behaviors
 
+//ecs:serialize
 type ActionFire struct {
 	ActionTimed `editable:"^"`
 }
@@ -10,17 +11,3 @@ type ActionFire struct {
 func (fire *ActionFire) String() string {
 	return "Fire"
 }
-
-func (fire *ActionFire) Construct(data map[string]any) {
-	fire.ActionTimed.Construct(data)
-
-	if data == nil {
-		return
-	}
-}
-
-func (fire *ActionFire) Serialize() map[string]any {
-	result := fire.ActionTimed.Serialize()
-
-	return result
-}
@@ -3,6 +3,7 @@
 
 package behaviors
 
+//ecs:serialize
 type ActionJump struct {
 	ActionTimed `editable:"^"`
 }
@@ -10,17 +11,3 @@ type ActionJump struct {
 func (jump *ActionJump) String() string {
 	return "Jump"
 }
-
-func (jump *ActionJump) Construct(data map[string]any) {
-	jump.ActionTimed.Construct(data)
-
-	if data == nil {
-		return
-	}
-}
-
-func (jump *ActionJump) Serialize() map[string]any {
-	result := jump.ActionTimed.Serialize()
-
-	return result
-}
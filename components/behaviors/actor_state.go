@@ -5,6 +5,7 @@ package behaviors
 
 import (
 	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/dynamic"
 	"tlyakhov/gofoom/ecs"
 	"tlyakhov/gofoom/pathfinding"
 )
@@ -12,8 +13,9 @@ import (
 type ActorState struct {
 	ecs.Attached
 
-	Action            ecs.Entity `editable:"Current Action" edit_type:"Action"`
-	LastTransition    int64
+	Action ecs.Entity `editable:"Current Action" edit_type:"Action"`
+	// Counts down the ActionTimed.Delay of the current action.
+	Delay             dynamic.Timer
 	Finder            *pathfinding.Finder
 	Path              []concepts.Vector3
 	LastPathGenerated int64
@@ -23,10 +25,22 @@ func (a *ActorState) String() string {
 	return "ActorState"
 }
 
+func (a *ActorState) OnAttach() {
+	a.Attached.OnAttach()
+	a.Delay.Attach(ecs.Simulation)
+}
+
+func (a *ActorState) OnDelete() {
+	defer a.Attached.OnDelete()
+	if a.IsAttached() {
+		a.Delay.Detach(ecs.Simulation)
+	}
+}
+
 func (a *ActorState) Construct(data map[string]any) {
 	a.Attached.Construct(data)
 	a.Action = 0
-	a.LastTransition = 0
+	a.Delay.Cancel()
 	a.Path = nil
 	a.Finder = nil
 
@@ -95,6 +95,43 @@ func (a *Alive) Hurt(source string, amount, cooldown float64) bool {
 	return true
 }
 
+// SerializeRuntime saves any damage that hasn't cooled down yet.
+func (a *Alive) SerializeRuntime() map[string]any {
+	if len(a.Damages) == 0 {
+		return nil
+	}
+	damages := make(map[string]any, len(a.Damages))
+	for source, d := range a.Damages {
+		damages[source] = map[string]any{
+			"Amount":            d.Amount,
+			"Cooldown":          d.Cooldown.Spawn,
+			"_runtime_Cooldown": d.Cooldown.SerializeRuntime(),
+		}
+	}
+	return map[string]any{"Damages": damages}
+}
+
+func (a *Alive) ConstructRuntime(data map[string]any) {
+	damages, ok := data["Damages"].(map[string]any)
+	if !ok {
+		return
+	}
+	for source, v := range damages {
+		dData, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		// See Hurt()
+		d := &Damage{Amount: cast.ToFloat64(dData["Amount"])}
+		d.Cooldown.Attach(ecs.Simulation)
+		d.Cooldown.SetAll(cast.ToFloat64(dData["Cooldown"]))
+		if runtime, ok := dData["_runtime_Cooldown"].(map[string]any); ok {
+			d.Cooldown.ConstructRuntime(runtime)
+		}
+		a.Damages[source] = d
+	}
+}
+
 func (a *Alive) Tint(color, damageTintColor *concepts.Vector4) {
 	allCooldowns := 0.0
 	maxCooldown := 0.0
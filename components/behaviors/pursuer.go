@@ -5,7 +5,6 @@ package behaviors
 
 import (
 	"math"
-	"math/rand/v2"
 	"tlyakhov/gofoom/components/core"
 	"tlyakhov/gofoom/concepts"
 	"tlyakhov/gofoom/ecs"
@@ -60,7 +59,7 @@ func (p *Pursuer) String() string {
 func (p *Pursuer) Construct(data map[string]any) {
 	p.Attached.Construct(data)
 	p.Enemies = make(map[ecs.Entity]*PursuerEnemy)
-	p.ClockwisePreference = rand.UintN(2) == 0
+	p.ClockwisePreference = ecs.Simulation.Rand.UintN(2) == 0
 	p.StrafeDistance = 100
 	p.ChaseSpeed = 1000
 	p.AlwaysFaceTarget = true
@@ -109,6 +108,68 @@ func (p *Pursuer) Serialize() map[string]any {
 	return result
 }
 
+// SerializeRuntime saves the enemies we're tracking and their breadcrumbs.
+// Other fields (e.g. Body) are refreshed every frame by the controller.
+func (p *Pursuer) SerializeRuntime() map[string]any {
+	if len(p.Enemies) == 0 {
+		return nil
+	}
+	enemies := make(map[string]any, len(p.Enemies))
+	for e, enemy := range p.Enemies {
+		// Preserve the order of the heap.
+		breadcrumbs := make([]any, len(enemy.Breadcrumbs))
+		for i, b := range enemy.Breadcrumbs {
+			breadcrumbs[i] = map[string]any{
+				"Key":         b.Key,
+				"Pos":         b.Data.Pos.Serialize(),
+				"TargetTime":  b.Data.TargetTime,
+				"CreatedTime": b.Data.CreatedTime,
+			}
+		}
+		enemies[e.Serialize()] = map[string]any{
+			"Dist":        enemy.Dist,
+			"InView":      enemy.InView,
+			"Visited":     enemy.Visited,
+			"Breadcrumbs": breadcrumbs,
+		}
+	}
+	return map[string]any{"Enemies": enemies}
+}
+
+func (p *Pursuer) ConstructRuntime(data map[string]any) {
+	enemies, ok := data["Enemies"].(map[string]any)
+	if !ok {
+		return
+	}
+	for serialized, v := range enemies {
+		e, err := ecs.ParseEntity(serialized)
+		enemyData, ok := v.(map[string]any)
+		if err != nil || e == 0 || !ok {
+			continue
+		}
+		enemy := &PursuerEnemy{
+			Entity:  e,
+			Dist:    cast.ToFloat64(enemyData["Dist"]),
+			InView:  cast.ToBool(enemyData["InView"]),
+			Visited: cast.ToBool(enemyData["Visited"]),
+		}
+		if breadcrumbs, ok := enemyData["Breadcrumbs"].([]any); ok {
+			for _, bv := range breadcrumbs {
+				bData, ok := bv.(map[string]any)
+				if !ok {
+					continue
+				}
+				b := gheap.HeapElement[int64, Breadcrumb]{Key: cast.ToInt64(bData["Key"])}
+				b.Data.Pos.Deserialize(cast.ToString(bData["Pos"]))
+				b.Data.TargetTime = cast.ToInt64(bData["TargetTime"])
+				b.Data.CreatedTime = cast.ToInt64(bData["CreatedTime"])
+				enemy.Breadcrumbs = append(enemy.Breadcrumbs, b)
+			}
+		}
+		p.Enemies[e] = enemy
+	}
+}
+
 func (p *Pursuer) BestCandidate() *Candidate {
 	var best *Candidate
 	bestWeight := math.Inf(-1)
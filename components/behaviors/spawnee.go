@@ -6,11 +6,11 @@ package behaviors
 import (
 	"fmt"
 	"tlyakhov/gofoom/ecs"
-
-	"github.com/spf13/cast"
 )
 
 // Keeps track of which entity spawned this one.
+//
+//ecs:serialize
 type Spawnee struct {
 	ecs.Attached `editable:"^"`
 
@@ -30,25 +30,3 @@ func (s *Spawnee) OnDetach(e ecs.Entity) {
 		delete(spawner.Spawned, e)
 	}
 }
-
-func (s *Spawnee) Construct(data map[string]any) {
-	s.Attached.Construct(data)
-
-	if data == nil {
-		return
-	}
-
-	if v, ok := data["Spawner"]; ok {
-		s.Spawner, _ = ecs.ParseEntity(cast.ToString(v))
-	}
-}
-
-func (s *Spawnee) Serialize() map[string]any {
-	result := s.Attached.Serialize()
-
-	if s.Spawner != 0 {
-		result["Spawner"] = s.Spawner.Serialize()
-	}
-
-	return result
-}
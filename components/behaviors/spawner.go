@@ -27,6 +27,10 @@ type Spawner struct {
 	Auto                  AutoSpawn       `editable:"Behavior on load"`
 	Targets               ecs.EntityTable `editable:"Targets"`
 
+	// If set, spawns instances of a prefab (see ecs.PrefabInstance) at the
+	// spawner's position instead of cloning the targets.
+	Prefab string `editable:"Prefab" edit_type:"file"`
+
 	// See behaviors.Spawnee for the other side of this relation
 	Spawned map[ecs.Entity]int64
 }
@@ -52,6 +56,7 @@ func (s *Spawner) Construct(data map[string]any) {
 	s.PreserveLinks = false
 	s.Auto = AutoSpawnOnLoad
 	s.Targets = nil
+	s.Prefab = ""
 
 	if data == nil {
 		return
@@ -73,6 +78,9 @@ func (s *Spawner) Construct(data map[string]any) {
 	if v, ok := data["Targets"]; ok {
 		s.Targets = ecs.ParseEntityTable(v, true)
 	}
+	if v, ok := data["Prefab"]; ok {
+		s.Prefab = cast.ToString(v)
+	}
 
 	if v, ok := data["Spawned"]; ok {
 		spawned := ecs.ParseEntityTable(v, false)
@@ -99,6 +107,9 @@ func (s *Spawner) Serialize() map[string]any {
 	if len(s.Targets) != 0 {
 		result["Targets"] = s.Targets.Serialize()
 	}
+	if s.Prefab != "" {
+		result["Prefab"] = s.Prefab
+	}
 
 	if len(s.Spawned) > 0 {
 		spawned := make(ecs.EntityTable, 0)
@@ -110,3 +121,24 @@ func (s *Spawner) Serialize() map[string]any {
 
 	return result
 }
+
+func (s *Spawner) SerializeRuntime() map[string]any {
+	if len(s.Spawned) == 0 {
+		return nil
+	}
+	spawned := make(map[string]any, len(s.Spawned))
+	for e, timestamp := range s.Spawned {
+		spawned[e.Serialize()] = timestamp
+	}
+	return map[string]any{"Spawned": spawned}
+}
+
+func (s *Spawner) ConstructRuntime(data map[string]any) {
+	if spawned, ok := data["Spawned"].(map[string]any); ok {
+		for serialized, timestamp := range spawned {
+			if e, err := ecs.ParseEntity(serialized); err == nil && e != 0 {
+				s.Spawned[e] = cast.ToInt64(timestamp)
+			}
+		}
+	}
+}
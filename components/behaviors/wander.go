@@ -5,50 +5,26 @@ package behaviors
 
 import (
 	"tlyakhov/gofoom/ecs"
-
-	"github.com/spf13/cast"
 )
 
+//ecs:serialize
 type Wander struct {
 	ecs.Attached `editable:"^"`
 
-	Force     float64 `editable:"Force"`
+	Force     float64 `editable:"Force" default:"10"`
 	AsImpulse bool    `editable:"Apply as impulse"`
 
 	// Internal state
-	LastTurn   int64 // nanoseconds
-	LastTarget int64
-	NextSector ecs.Entity
+	LastTurn   int64      `serialize:"-"` // nanoseconds
+	LastTarget int64      `serialize:"-"`
+	NextSector ecs.Entity `serialize:"-"`
 }
 
 func (w *Wander) String() string {
 	return "Wander"
 }
 
-func (w *Wander) Construct(data map[string]any) {
-	w.Attached.Construct(data)
-
-	w.Force = 10
+// construct is called by the generated Construct.
+func (w *Wander) construct(data map[string]any) {
 	w.LastTurn = ecs.Simulation.SimTimestamp
-
-	if data == nil {
-		return
-	}
-
-	if v, ok := data["Force"]; ok {
-		w.Force = cast.ToFloat64(v)
-	}
-	if v, ok := data["AsImpulse"]; ok {
-		w.AsImpulse = cast.ToBool(v)
-	}
-}
-
-func (w *Wander) Serialize() map[string]any {
-	result := w.Attached.Serialize()
-
-	result["Force"] = w.Force
-	if w.AsImpulse {
-		result["AsImpulse"] = true
-	}
-	return result
 }
@@ -1,7 +1,12 @@
 // Code generated by ecs/cmd/gofoom_ecs_generator. DO NOT EDIT.
 package behaviors
 
-import "tlyakhov/gofoom/ecs"
+import (
+	"github.com/spf13/cast"
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/dynamic"
+	"tlyakhov/gofoom/ecs"
+)
 
 var ActionFaceCID ecs.ComponentID
 var ActionFireCID ecs.ComponentID
@@ -246,3 +251,196 @@ func GetWander(e ecs.Entity) *Wander {
 func (*Wander) ComponentID() ecs.ComponentID {
 	return WanderCID
 }
+
+func (a *ActionFace) Construct(data map[string]any) {
+	a.ActionTimed.Construct(data)
+	a.Angle = 0
+	if data != nil {
+		if v, ok := data["Angle"]; ok {
+			a.Angle = cast.ToFloat64(v)
+		}
+	}
+}
+
+func (a *ActionFace) Serialize() map[string]any {
+	result := a.ActionTimed.Serialize()
+	if a.Angle != 0 {
+		result["Angle"] = a.Angle
+	}
+	return result
+}
+
+func (a *ActionFire) Construct(data map[string]any) {
+	a.ActionTimed.Construct(data)
+}
+
+func (a *ActionFire) Serialize() map[string]any {
+	result := a.ActionTimed.Serialize()
+	return result
+}
+
+func (a *ActionJump) Construct(data map[string]any) {
+	a.ActionTimed.Construct(data)
+}
+
+func (a *ActionJump) Serialize() map[string]any {
+	result := a.ActionTimed.Serialize()
+	return result
+}
+
+func (a *ActionWaypoint) Construct(data map[string]any) {
+	a.ActionTimed.Construct(data)
+	a.P = (concepts.Vector3{})
+	a.UsePathFinder = true
+	if data != nil {
+		if v, ok := data["P"]; ok {
+			a.P.Deserialize(cast.ToString(v))
+		}
+		if v, ok := data["UsePathFinder"]; ok {
+			a.UsePathFinder = cast.ToBool(v)
+		}
+	}
+}
+
+func (a *ActionWaypoint) Serialize() map[string]any {
+	result := a.ActionTimed.Serialize()
+	if a.P != (concepts.Vector3{}) {
+		result["P"] = a.P.Serialize()
+	}
+	if !a.UsePathFinder {
+		result["UsePathFinder"] = a.UsePathFinder
+	}
+	return result
+}
+
+func (a *Actor) Construct(data map[string]any) {
+	a.Attached.Construct(data)
+	a.Start = 0
+	a.NoZ = false
+	a.Lifetime = dynamic.AnimationLifetimeLoop
+	a.Speed = 10
+	a.AngularSpeed = 15
+	a.FaceNextWaypoint = true
+	if data != nil {
+		if v, ok := data["Start"]; ok {
+			a.Start, _ = ecs.ParseEntity(cast.ToString(v))
+		}
+		if v, ok := data["NoZ"]; ok {
+			a.NoZ = cast.ToBool(v)
+		}
+		if v, ok := data["Lifetime"]; ok {
+			if parsed, err := dynamic.AnimationLifetimeString(cast.ToString(v)); err == nil {
+				a.Lifetime = parsed
+			}
+		}
+		if v, ok := data["Speed"]; ok {
+			a.Speed = cast.ToFloat64(v)
+		}
+		if v, ok := data["AngularSpeed"]; ok {
+			a.AngularSpeed = cast.ToFloat64(v)
+		}
+		if v, ok := data["FaceNextWaypoint"]; ok {
+			a.FaceNextWaypoint = cast.ToBool(v)
+		}
+	}
+}
+
+func (a *Actor) Serialize() map[string]any {
+	result := a.Attached.Serialize()
+	if a.Start != 0 {
+		result["Start"] = a.Start.Serialize()
+	}
+	if a.NoZ {
+		result["NoZ"] = a.NoZ
+	}
+	if a.Lifetime != dynamic.AnimationLifetimeLoop {
+		result["Lifetime"] = a.Lifetime.String()
+	}
+	if a.Speed != 10 {
+		result["Speed"] = a.Speed
+	}
+	if a.AngularSpeed != 15 {
+		result["AngularSpeed"] = a.AngularSpeed
+	}
+	if !a.FaceNextWaypoint {
+		result["FaceNextWaypoint"] = a.FaceNextWaypoint
+	}
+	return result
+}
+
+func (e *Ephemeral) Construct(data map[string]any) {
+	e.Attached.Construct(data)
+	e.Lifetime = 60000
+	e.FadeTime = 1000
+	e.DeleteEntityOnExpiry = false
+	if data != nil {
+		if v, ok := data["Lifetime"]; ok {
+			e.Lifetime = cast.ToFloat64(v)
+		}
+		if v, ok := data["FadeTime"]; ok {
+			e.FadeTime = cast.ToFloat64(v)
+		}
+		if v, ok := data["DeleteEntityOnExpiry"]; ok {
+			e.DeleteEntityOnExpiry = cast.ToBool(v)
+		}
+	}
+	e.construct(data)
+}
+
+func (e *Ephemeral) Serialize() map[string]any {
+	result := e.Attached.Serialize()
+	if e.Lifetime != 60000 {
+		result["Lifetime"] = e.Lifetime
+	}
+	if e.FadeTime != 1000 {
+		result["FadeTime"] = e.FadeTime
+	}
+	if e.DeleteEntityOnExpiry {
+		result["DeleteEntityOnExpiry"] = e.DeleteEntityOnExpiry
+	}
+	return result
+}
+
+func (s *Spawnee) Construct(data map[string]any) {
+	s.Attached.Construct(data)
+	s.Spawner = 0
+	if data != nil {
+		if v, ok := data["Spawner"]; ok {
+			s.Spawner, _ = ecs.ParseEntity(cast.ToString(v))
+		}
+	}
+}
+
+func (s *Spawnee) Serialize() map[string]any {
+	result := s.Attached.Serialize()
+	if s.Spawner != 0 {
+		result["Spawner"] = s.Spawner.Serialize()
+	}
+	return result
+}
+
+func (w *Wander) Construct(data map[string]any) {
+	w.Attached.Construct(data)
+	w.Force = 10
+	w.AsImpulse = false
+	if data != nil {
+		if v, ok := data["Force"]; ok {
+			w.Force = cast.ToFloat64(v)
+		}
+		if v, ok := data["AsImpulse"]; ok {
+			w.AsImpulse = cast.ToBool(v)
+		}
+	}
+	w.construct(data)
+}
+
+func (w *Wander) Serialize() map[string]any {
+	result := w.Attached.Serialize()
+	if w.Force != 10 {
+		result["Force"] = w.Force
+	}
+	if w.AsImpulse {
+		result["AsImpulse"] = w.AsImpulse
+	}
+	return result
+}
@@ -4,6 +4,7 @@
 package core
 
 import (
+	"maps"
 	"math"
 
 	"tlyakhov/gofoom/dynamic"
@@ -17,12 +18,12 @@ import (
 type Body struct {
 	ecs.Attached `editable:"^"`
 	Pos          dynamic.DynamicValue[concepts.Vector3] `editable:"Position"`
-	// TODO: Separate this out into 3 different fields, potentially in their own component:
-	// 1. Render size
-	// 2. Collision size
-	// 3. Hitbox size for weapon damage
-	Size  dynamic.DynamicValue[concepts.Vector2] `editable:"Size"`
-	Angle dynamic.DynamicValue[float64]          `editable:"Angle"`
+	// Size is used for rendering, and for weapons (see controllers.Cast).
+	// TODO: Weapons should have their own hitbox size.
+	Size dynamic.DynamicValue[concepts.Vector2] `editable:"Size"`
+	// CollisionSize is used for physics, pathfinding and proximity.
+	CollisionSize dynamic.DynamicValue[concepts.Vector2] `editable:"Collision Size"`
+	Angle         dynamic.DynamicValue[float64]          `editable:"Angle"`
 
 	StepSound ecs.Entity `editable:"Step Sound" edit_type:"Sound"`
 
@@ -31,6 +32,22 @@ type Body struct {
 	OnGround     bool
 }
 
+func init() {
+	// Bodies used to have a single size for everything.
+	ecs.RegisterMigration[Body](0, "Split Size into Size and CollisionSize", false, func(data map[string]any) error {
+		if _, ok := data["CollisionSize"]; ok {
+			return nil
+		}
+		if v, ok := data["Size"]; ok {
+			if m, ok := v.(map[string]any); ok {
+				v = maps.Clone(m)
+			}
+			data["CollisionSize"] = v
+		}
+		return nil
+	})
+}
+
 func (b *Body) String() string {
 	return "Body"
 }
@@ -55,6 +72,7 @@ func (b *Body) OnDelete() {
 	if b.IsAttached() {
 		b.Pos.Detach(ecs.Simulation)
 		b.Size.Detach(ecs.Simulation)
+		b.CollisionSize.Detach(ecs.Simulation)
 		b.Angle.Detach(ecs.Simulation)
 	}
 }
@@ -63,10 +81,22 @@ func (b *Body) OnAttach() {
 	b.Attached.OnAttach()
 	b.Pos.Attach(ecs.Simulation)
 	b.Size.Attach(ecs.Simulation)
+	b.CollisionSize.Attach(ecs.Simulation)
 	b.Angle.Attach(ecs.Simulation)
 	QuadTree.Update(b)
 }
 
+// BoundingRadius is the larger of the render and collision radii, for spatial
+// queries that serve both.
+func (b *Body) BoundingRadius() float64 {
+	return max(b.Size.Now[0], b.CollisionSize.Now[0]) * 0.5
+}
+
+// BoundingHeight is the larger of the render and collision heights.
+func (b *Body) BoundingHeight() float64 {
+	return max(b.Size.Now[1], b.CollisionSize.Now[1])
+}
+
 func (b *Body) Sector() *Sector {
 	return GetSector(b.SectorEntity)
 }
@@ -110,6 +140,7 @@ func (b *Body) Construct(data map[string]any) {
 
 	b.Pos.Construct(nil)
 	b.Size.Construct(defaultBodySize)
+	b.CollisionSize.Construct(defaultBodySize)
 	b.Angle.Construct(nil)
 
 	b.Angle.IsAngle = true
@@ -125,6 +156,9 @@ func (b *Body) Construct(data map[string]any) {
 	if v, ok := data["Size"]; ok {
 		b.Size.Construct(v)
 	}
+	if v, ok := data["CollisionSize"]; ok {
+		b.CollisionSize.Construct(v)
+	}
 	if v, ok := data["Angle"]; ok {
 		b.Angle.Construct(v)
 	}
@@ -137,6 +171,7 @@ func (b *Body) Serialize() map[string]any {
 	result := b.Attached.Serialize()
 	result["Pos"] = b.Pos.Serialize()
 	result["Size"] = b.Size.Serialize()
+	result["CollisionSize"] = b.CollisionSize.Serialize()
 	result["Angle"] = b.Angle.Serialize()
 	if b.StepSound != 0 {
 		result["StepSound"] = b.StepSound.Serialize()
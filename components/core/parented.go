@@ -0,0 +1,67 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package core
+
+import (
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/ecs"
+)
+
+// Parented attaches an entity to a parent, which can be another body or a
+// sector. The child's Body (and optionally InternalSegment) follows the
+// parent, see controllers.ParentedController.
+//
+// For body parents, offsets are rotated by the parent's angle. For sector
+// parents, offsets are relative to the sector's TransformOrigin and are
+// transformed by its Transform, just like the sector's own segments.
+//
+// Construct/Serialize are generated, see ecs/cmd/gofoom_ecs_generator.
+//
+//ecs:serialize
+type Parented struct {
+	ecs.Attached `editable:"^"`
+	Parent       ecs.Entity `editable:"Parent"`
+
+	Pos   concepts.Vector3 `editable:"Relative Position" serialize:"always"`
+	Angle float64          `editable:"Relative Angle"`
+	// If false, the child keeps its own angle.
+	InheritAngle bool `editable:"Inherit Angle?" default:"true"`
+
+	// Endpoints of the child's InternalSegment, if FollowSegment is set.
+	FollowSegment bool             `editable:"Move Segment?"`
+	SegmentA      concepts.Vector2 `editable:"Relative Segment A" edit_condition:"IsFollowingSegment"`
+	SegmentB      concepts.Vector2 `editable:"Relative Segment B" edit_condition:"IsFollowingSegment"`
+
+	// Delete this entity when the parent is deleted. Otherwise, it's left
+	// wherever it was.
+	Cascade bool `editable:"Delete with Parent?"`
+
+	// Captured is false until the relative offsets have been loaded or
+	// computed from the current positions of the parent and child.
+	Captured bool `serialize:"-"`
+}
+
+func (p *Parented) String() string {
+	return "Parent: " + p.Parent.String()
+}
+
+func (p *Parented) IsFollowingSegment() bool {
+	return p.FollowSegment
+}
+
+// construct is called by the generated Construct.
+func (p *Parented) construct(data map[string]any) {
+	p.Captured = false
+	if data != nil {
+		_, p.Captured = data["Pos"]
+	}
+}
+
+// serialize is called by the generated Serialize.
+func (p *Parented) serialize(result map[string]any) {
+	// Let the offsets be captured again when loading.
+	if !p.Captured {
+		delete(result, "Pos")
+	}
+}
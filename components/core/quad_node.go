@@ -95,7 +95,7 @@ func (node *QuadNode) Remove(body *Body) {
 			foundIndex = i
 			continue
 		}
-		r := test.Size.Now[0] * 0.5
+		r := test.BoundingRadius()
 		if r > node.MaxRadius {
 			node.MaxRadius = r
 		}
@@ -246,12 +246,12 @@ func (node *QuadNode) expandRoot(pos *concepts.Vector3) {
 
 func (node *QuadNode) addToLeaf(body *Body) {
 	if body.Pos.Now[2] < node.Tree.MinZ {
-		node.Tree.MinZ = body.Pos.Now[2] - body.Size.Now[1]
+		node.Tree.MinZ = body.Pos.Now[2] - body.BoundingHeight()
 	}
 	if body.Pos.Now[2] > node.Tree.MaxZ {
-		node.Tree.MaxZ = body.Pos.Now[2] + body.Size.Now[1]
+		node.Tree.MaxZ = body.Pos.Now[2] + body.BoundingHeight()
 	}
-	node.increaseRadii(body.Size.Now[0] * 0.5)
+	node.increaseRadii(body.BoundingRadius())
 	node.Bodies = append(node.Bodies, body)
 	if light := GetLight(body.Entity); light != nil {
 		node.Lights = append(node.Lights, body)
@@ -338,7 +338,7 @@ func (node *QuadNode) RangeCircle(center *concepts.Vector2, r float64, fn func(b
 		for _, b := range node.Bodies {
 			dx := b.Pos.Now[0] - center[0]
 			dy := b.Pos.Now[1] - center[1]
-			br := b.Size.Now[0] * 0.5
+			br := b.BoundingRadius()
 			if dx*dx+dy*dy > (r+br)*(r+br) {
 				continue
 			}
@@ -447,7 +447,7 @@ func (node *QuadNode) aabbOverlaps(min, max *concepts.Vector2) bool {
 func (node *QuadNode) RangeAABB(min, max *concepts.Vector2, fn func(b *Body) bool) {
 	if node.IsLeaf() {
 		for _, b := range node.Bodies {
-			br := b.Size.Now[0] * 0.5
+			br := b.BoundingRadius()
 			if b.Pos.Now[0]+br < min[0] ||
 				b.Pos.Now[1]+br < min[1] ||
 				b.Pos.Now[0]-br >= max[0] ||
@@ -4,15 +4,18 @@
 package core
 
 import (
-	"bytes"
 	"fmt"
 	"log"
 	"maps"
+	"os"
+	"path/filepath"
+	"strings"
 	"text/template"
+	"time"
+	"tlyakhov/gofoom/dynamic"
 	"tlyakhov/gofoom/ecs"
 
 	"github.com/traefik/yaegi/interp"
-	"github.com/traefik/yaegi/stdlib"
 )
 
 type ScriptParam struct {
@@ -22,6 +25,9 @@ type ScriptParam struct {
 
 type Script struct {
 	Code string `editable:"Code" edit_type:"multi-line-string"`
+	// If set, Code is loaded from this file (relative to the world file) and
+	// reloaded when it changes.
+	File string `editable:"File" edit_type:"file"`
 
 	ErrorMessage string
 	interp       *interp.Interpreter
@@ -29,32 +35,32 @@ type Script struct {
 	Params       []ScriptParam
 	runFunc      any
 	execCode     string
+
+	// Diagnostics, see script_diagnostics.go
+	owner      ecs.Entity
+	component  ecs.Component
+	name       string
+	stats      ScriptStats
+	codeLine   int
+	codeColumn int
+
+	watchedPath string
+	// See script_library.go
+	library    bool
+	generation int
+	funcName   string
 }
 
 var scriptTemplate *template.Template
 
 func init() {
 	var err error
+	// The packages are imported once by scriptPrelude, see
+	// script_library.go
 	scriptTemplate, err = template.New("script").Parse(`
 	package main
 
-	import "tlyakhov/gofoom/components/audio"
-	import "tlyakhov/gofoom/components/behaviors"
-	import "tlyakhov/gofoom/components/character"
-	import "tlyakhov/gofoom/components/core"
-	import "tlyakhov/gofoom/components/inventory"
-	import "tlyakhov/gofoom/components/materials"
-	import "tlyakhov/gofoom/components/selection"
-	import "tlyakhov/gofoom/concepts"
-	import "tlyakhov/gofoom/constants"
-	import "tlyakhov/gofoom/containers"
-	import "tlyakhov/gofoom/controllers"
-	import "tlyakhov/gofoom/ecs"
-	import "tlyakhov/gofoom/pathfinding"
-	import "log"
-	import "fmt"
-
-	func Do(s *core.Script) {
+	func {{.Func}}(s *core.Script) {
 		{{range .Params}}
 			var {{.Name}} {{.TypeName}}
 			if s.Vars["{{.Name}}"] != nil {
@@ -71,60 +77,131 @@ func init() {
 	}
 }
 
+// SetOwner describes where the script lives, for error messages and the
+// script console. component is the one holding the script, if any: the script
+// is dropped from the console once it's detached from owner. Should be called
+// before Compile.
+func (s *Script) SetOwner(owner ecs.Entity, component ecs.Component, name string) {
+	s.owner = owner
+	s.component = component
+	s.name = name
+}
+
+// Describe returns the owner and name of the script, e.g. "Door.Open on 12".
+func (s *Script) Describe() string {
+	name := s.name
+	if name == "" {
+		name = "Script"
+	}
+	if s.owner != 0 {
+		name += " on " + s.owner.String()
+	}
+	return name
+}
+
+func (s *Script) Stats() ScriptStats {
+	return s.stats
+}
+
 func (s *Script) Compile() {
 	s.ErrorMessage = ""
-	s.interp = interp.New(interp.Options{})
-	s.interp.Use(stdlib.Symbols)
-	s.interp.Use(ecs.Types().InterpSymbols)
+	s.stats.Failures = 0
+	s.interp = nil
+	s.runFunc = nil
+	registerScript(s)
 
-	var buf bytes.Buffer
-	err := scriptTemplate.Execute(&buf, s)
-	if err != nil {
-		s.ErrorMessage += fmt.Sprintf("Error building script template %v: %v", s.Code, err)
-		s.interp = nil
-		log.Printf("%v", s.ErrorMessage)
+	if s.library {
+		// Libraries are evaluated when the shared interpreter is built, and
+		// every script has to be compiled again to see the changes.
+		InvalidateScriptLibraries()
+		sharedScriptInterpreter()
 		return
 	}
-	s.execCode = buf.String()
+	if !s.load() {
+		return
+	}
+	s.compileShared()
+}
 
-	// This handles panics inside the interpreter. It's a bit aggressive, but
-	// saves the game from crashing due to bad scripting
-	defer func() {
-		recovered := recover()
-		if recovered == nil {
-			return
-		}
+// load reads the code from the script's file, if it has one.
+func (s *Script) load() bool {
+	path := s.Path()
+	watchScriptFile(s, path)
+	if path == "" {
+		return true
+	}
+	if err := dynamic.CheckSandbox("reading " + s.File); err != nil {
+		se := s.reportError(false, err.Error())
+		log.Printf("%v", se.String())
+		return false
+	}
+	code, err := os.ReadFile(path)
+	if err != nil {
+		se := s.reportError(false, fmt.Sprintf("reading %v: %v", s.File, err))
+		log.Printf("%v", se.String())
+		return false
+	}
+	s.Code = string(code)
+	return true
+}
 
-		log.Printf("Script compilation error: %v", recovered)
-		log.Printf("in code: %v", s.execCode)
-		s.ErrorMessage = fmt.Sprintf("Script error: %v", recovered)
-		s.interp = nil
-		s.runFunc = nil
-	}()
+// Path returns the absolute path of the script's file, or an empty string if
+// the code is inline.
+func (s *Script) Path() string {
+	if s.File == "" {
+		return ""
+	}
+	path := s.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(ecs.WorkingDirForEntity(s.owner), path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return filepath.Clean(path)
+}
 
-	_, err = s.interp.Eval(s.execCode)
-	if err != nil {
-		s.ErrorMessage += fmt.Sprintf("Error compiling script %v: %v", s.execCode, err)
-		log.Printf("%v", s.ErrorMessage)
-		return
+// reload recompiles the script after its file has changed. Vars are kept, and
+// if the new code doesn't compile, the previous version keeps running.
+func (s *Script) reload() bool {
+	if s.File == "" || s.Path() != s.watchedPath {
+		return false
 	}
-	// log.Printf("%v", codeTemplate)
-	f, err := s.interp.Eval("main.Do")
-	if err != nil {
-		s.ErrorMessage += fmt.Sprintf("Error compiling script %v: %v", s.execCode, err)
-		log.Printf("%v", s.ErrorMessage)
-		return
+	if s.library {
+		s.Compile()
+		return s.ErrorMessage == ""
+	}
+	prevCode, prevInterp, prevRunFunc, prevExecCode := s.Code, s.interp, s.runFunc, s.execCode
+	prevLine, prevColumn := s.codeLine, s.codeColumn
+	s.Compile()
+	if s.IsCompiled() {
+		log.Printf("Reloaded %v from %v", s.Describe(), s.File)
+		return true
+	}
+	if prevInterp != nil && prevRunFunc != nil {
+		log.Printf("%v: keeping the previous version", s.Describe())
+		s.Code, s.interp, s.runFunc, s.execCode = prevCode, prevInterp, prevRunFunc, prevExecCode
+		s.codeLine, s.codeColumn = prevLine, prevColumn
 	}
+	return false
+}
 
-	s.runFunc = f.Interface()
+// Recompile compiles the script again, e.g. after fixing an error. Returns
+// true if it was successful.
+func (s *Script) Recompile() bool {
+	if s.IsEmpty() {
+		return false
+	}
+	s.Compile()
+	return s.IsCompiled()
 }
 
 func (s *Script) IsCompiled() bool {
-	return s.interp != nil && s.runFunc != nil
+	return s.interp != nil && (s.library || s.runFunc != nil)
 }
 
 func (s *Script) IsEmpty() bool {
-	return len(s.Code) == 0
+	return len(s.Code) == 0 && len(s.File) == 0
 }
 
 func (s *Script) Construct(data map[string]any) {
@@ -142,14 +219,31 @@ func (s *Script) Construct(data map[string]any) {
 	if v, ok := data["Code"]; ok {
 		s.Code = v.(string)
 	}
+	if v, ok := data["File"]; ok {
+		s.File = v.(string)
+	}
 }
 
 func (s *Script) Serialize() map[string]any {
 	data := make(map[string]any)
-	data["Code"] = s.Code
+	// The file is the source of truth, no need to save a copy.
+	if s.File != "" {
+		data["File"] = s.File
+	} else {
+		data["Code"] = s.Code
+	}
 	return data
 }
 
+// Scripts are compiled by controllers during precompute, so there's nothing
+// to save.
+func (s *Script) SerializeRuntime() map[string]any {
+	return nil
+}
+
+func (s *Script) ConstructRuntime(data map[string]any) {
+}
+
 func (s *Script) Entity(name string) ecs.Entity {
 	if s.Vars[name] == nil {
 		return 0
@@ -160,30 +254,80 @@ func (s *Script) Entity(name string) ecs.Entity {
 	return 0
 }
 
+// State returns the persistent state for the entity the script is running on
+// (or its owner), e.g. s.State().Set("count", s.State().Int("count")+1)
+func (s *Script) State() *ScriptState {
+	if e := s.Entity("onEntity"); e != 0 {
+		return ScriptStateFor(e)
+	}
+	return ScriptStateFor(s.owner)
+}
+
 func (s *Script) Act() bool {
+	if s.library {
+		return false
+	}
+	if s.generation != scriptLibraries.generation && !s.IsEmpty() {
+		// A library changed since we compiled, which may also fix a broken
+		// script.
+		s.Compile()
+	}
 	if !s.IsCompiled() {
 		return false
 	}
+	scriptLibraries.stderr.Reset()
+	if beginScriptBudget() {
+		defer endScriptBudget()
+	}
+	start := time.Now()
 	// This handles panics inside the interpreter. It's a bit aggressive, but
 	// saves the game from crashing due to bad scripting
 	defer func() {
+		elapsed := time.Since(start)
+		s.stats.Runs++
+		s.stats.Total += elapsed
+		s.stats.Max = max(s.stats.Max, elapsed)
+
 		recovered := recover()
 		if recovered == nil {
+			if s.stats.Failures > 0 {
+				// Recovered after retrying
+				s.stats.Failures = 0
+				s.ErrorMessage = ""
+			}
 			return
 		}
-
-		log.Printf("Script error: %v", recovered)
-		log.Printf("in code: %v", s.execCode)
-		s.ErrorMessage = fmt.Sprintf("Script error: %v", recovered)
-		s.interp = nil
-		s.runFunc = nil
+		s.runtimeError(recovered)
 	}()
 
 	if f, ok := s.runFunc.(func(*Script)); ok {
 		f(s)
 		return true
 	} else {
-		s.ErrorMessage = "Error running script, 'Run' function has the wrong signature."
+		s.reportError(false, "'Do' function has the wrong signature.")
 		return false
 	}
 }
+
+func (s *Script) runtimeError(recovered any) {
+	message := fmt.Sprintf("%v", recovered)
+	// yaegi logs a line for each function the panic passes through, the
+	// first is the innermost. The position is where that function's current
+	// block started rather than the exact statement, but it's close enough.
+	if first, _, _ := strings.Cut(scriptLibraries.stderr.String(), "\n"); first != "" {
+		if m := scriptPositionRegexp.FindStringSubmatch(first); m != nil {
+			message = m[1] + ":" + m[2] + ": " + message
+		}
+	}
+	scriptLibraries.stderr.Reset()
+
+	se := s.reportError(true, message)
+	s.stats.Failures++
+	if s.stats.Failures > ScriptRetries {
+		log.Printf("%v (disabled)", se.String())
+		s.interp = nil
+		s.runFunc = nil
+		return
+	}
+	log.Printf("%v (%v/%v retries)", se.String(), s.stats.Failures, ScriptRetries)
+}
@@ -0,0 +1,229 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package core
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tlyakhov/gofoom/ecs"
+)
+
+// ScriptStats tracks how often a script runs and how long it takes.
+type ScriptStats struct {
+	Runs   uint64
+	Errors uint64
+	Total  time.Duration
+	Max    time.Duration
+	// Runtime errors in a row, see ScriptRetries.
+	Failures int
+}
+
+func (s *ScriptStats) Average() time.Duration {
+	if s.Runs == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Runs)
+}
+
+func (s *ScriptStats) String() string {
+	return fmt.Sprintf("%v runs, %v errors, avg %v, max %v", s.Runs, s.Errors, s.Average(), s.Max)
+}
+
+// ScriptError is a compilation or runtime error, with the line and column in
+// the user's code rather than the generated code that's actually compiled.
+type ScriptError struct {
+	Time    time.Time
+	Script  *Script
+	Entity  ecs.Entity
+	Name    string
+	Runtime bool
+	// 0 if unknown, or if the error is in the generated code around the
+	// user's code.
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *ScriptError) String() string {
+	var sb strings.Builder
+	if e.Name != "" {
+		sb.WriteString(e.Name)
+	} else {
+		sb.WriteString("Script")
+	}
+	if e.Entity != 0 {
+		sb.WriteString(" on " + e.Entity.String())
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&sb, " (line %v:%v)", e.Line, e.Column)
+	}
+	if e.Runtime {
+		sb.WriteString(": runtime error: ")
+	} else {
+		sb.WriteString(": compile error: ")
+	}
+	sb.WriteString(e.Message)
+	return sb.String()
+}
+
+// ScriptRetries is how many runtime errors in a row a script can have before
+// it's disabled. The default of 0 disables scripts on the first error. A
+// successful run resets the count.
+var ScriptRetries = 0
+
+const ScriptErrorHistoryLength = 64
+
+var scriptDiagnostics struct {
+	sync.Mutex
+	errors     [ScriptErrorHistoryLength]ScriptError
+	errorIndex int
+	errorCount int
+	scripts    map[*Script]struct{}
+}
+
+// yaegi prefixes errors with the position in the source, e.g. "5:2: ..."
+var scriptPositionRegexp = regexp.MustCompile(`(?m)^(?:[^\s:]*:)?(\d+):(\d+): (.*)$`)
+
+func init() {
+	ecs.OnInitialize(resetScriptRegistry)
+}
+
+// registerScript adds a script to the console, see Scripts. Scripts without
+// an owner aren't listed.
+func registerScript(s *Script) {
+	scriptDiagnostics.Lock()
+	defer scriptDiagnostics.Unlock()
+	if s.owner == 0 {
+		delete(scriptDiagnostics.scripts, s)
+		return
+	}
+	if scriptDiagnostics.scripts == nil {
+		scriptDiagnostics.scripts = make(map[*Script]struct{})
+	}
+	scriptDiagnostics.scripts[s] = struct{}{}
+}
+
+// resetScriptRegistry forgets every script and error when the world is
+// reset, since they belong to the old one.
+func resetScriptRegistry() {
+	scriptDiagnostics.Lock()
+	defer scriptDiagnostics.Unlock()
+	clear(scriptDiagnostics.scripts)
+	clear(scriptDiagnostics.errors[:])
+	scriptDiagnostics.errorCount = 0
+	scriptDiagnostics.errorIndex = 0
+}
+
+// isAttached checks whether the script still belongs to the world: its owner
+// exists and the component holding it hasn't been detached.
+func (s *Script) isAttached() bool {
+	if s.owner == 0 || !ecs.Entities.Contains(uint32(s.owner)) {
+		return false
+	}
+	return s.component == nil || s.component.Base().Entities.Contains(s.owner)
+}
+
+// userPosition maps a position in the generated code to the user's code.
+func (s *Script) userPosition(line, column int) (int, int) {
+	line -= s.codeLine - 1
+	if line < 1 || line > strings.Count(s.Code, "\n")+1 {
+		return 0, 0
+	}
+	if line == 1 {
+		// The first line of code is indented in the template.
+		column -= s.codeColumn - 1
+	}
+	return line, column
+}
+
+func (s *Script) reportError(runtime bool, message string) *ScriptError {
+	se := ScriptError{
+		Time:    time.Now(),
+		Script:  s,
+		Entity:  s.owner,
+		Name:    s.name,
+		Runtime: runtime,
+		Message: message,
+	}
+	if e := s.Entity("onEntity"); e != 0 {
+		se.Entity = e
+	}
+	if m := scriptPositionRegexp.FindStringSubmatch(message); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		column, _ := strconv.Atoi(m[2])
+		se.Line, se.Column = s.userPosition(line, column)
+		if se.Line > 0 {
+			se.Message = m[3]
+		}
+	}
+	s.stats.Errors++
+	s.ErrorMessage = se.String()
+
+	scriptDiagnostics.Lock()
+	scriptDiagnostics.errors[scriptDiagnostics.errorIndex] = se
+	scriptDiagnostics.errorIndex = (scriptDiagnostics.errorIndex + 1) % ScriptErrorHistoryLength
+	scriptDiagnostics.errorCount = min(scriptDiagnostics.errorCount+1, ScriptErrorHistoryLength)
+	scriptDiagnostics.Unlock()
+	return &se
+}
+
+// RecentScriptErrors returns the last errors from any script, oldest first.
+func RecentScriptErrors() []ScriptError {
+	scriptDiagnostics.Lock()
+	defer scriptDiagnostics.Unlock()
+	result := make([]ScriptError, 0, scriptDiagnostics.errorCount)
+	start := scriptDiagnostics.errorIndex - scriptDiagnostics.errorCount
+	if start < 0 {
+		start += ScriptErrorHistoryLength
+	}
+	for i := range scriptDiagnostics.errorCount {
+		result = append(result, scriptDiagnostics.errors[(start+i)%ScriptErrorHistoryLength])
+	}
+	return result
+}
+
+// Scripts returns every compiled script that's still attached to its owner,
+// sorted by total execution time (slowest first).
+func Scripts() []*Script {
+	scriptDiagnostics.Lock()
+	defer scriptDiagnostics.Unlock()
+	result := make([]*Script, 0, len(scriptDiagnostics.scripts))
+	for s := range scriptDiagnostics.scripts {
+		if !s.isAttached() {
+			delete(scriptDiagnostics.scripts, s)
+			continue
+		}
+		result = append(result, s)
+	}
+	slices.SortFunc(result, func(a, b *Script) int {
+		if c := cmp.Compare(b.stats.Total, a.stats.Total); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Describe(), b.Describe())
+	})
+	return result
+}
+
+// FailingScripts returns the scripts that have errors, see Scripts.
+func FailingScripts() []*Script {
+	return slices.DeleteFunc(Scripts(), func(s *Script) bool { return s.ErrorMessage == "" })
+}
+
+// ResetScriptDiagnostics forgets all errors and statistics.
+func ResetScriptDiagnostics() {
+	scriptDiagnostics.Lock()
+	defer scriptDiagnostics.Unlock()
+	for s := range scriptDiagnostics.scripts {
+		s.stats = ScriptStats{}
+	}
+	scriptDiagnostics.errorCount = 0
+	scriptDiagnostics.errorIndex = 0
+}
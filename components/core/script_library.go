@@ -0,0 +1,268 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package core
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"tlyakhov/gofoom/ecs"
+
+	"github.com/traefik/yaegi/interp"
+	"github.com/traefik/yaegi/stdlib"
+)
+
+// ScriptLibrary holds Go declarations (functions, types, variables) that every
+// script in the world can use, instead of copy-pasting helpers between
+// scripts. The code is top-level, e.g.:
+//
+//	func IsPlayer(e ecs.Entity) bool {
+//		return character.GetPlayer(e) != nil
+//	}
+//
+// Package-level variables are shared by all scripts, but aren't saved. Use
+// ScriptState for anything that should persist.
+type ScriptLibrary struct {
+	ecs.Attached `editable:"^"`
+
+	Library Script `editable:"Library"`
+}
+
+func (sl *ScriptLibrary) String() string {
+	return "ScriptLibrary"
+}
+
+// Functions from a removed library shouldn't be callable anymore.
+func (sl *ScriptLibrary) OnDetach(e ecs.Entity) {
+	sl.Attached.OnDetach(e)
+	InvalidateScriptLibraries()
+}
+
+func (sl *ScriptLibrary) OnDelete() {
+	sl.Attached.OnDelete()
+	InvalidateScriptLibraries()
+}
+
+func (sl *ScriptLibrary) Construct(data map[string]any) {
+	sl.Attached.Construct(data)
+	sl.Library.library = true
+
+	if data == nil {
+		sl.Library.Construct(nil)
+		return
+	}
+
+	if v, ok := data["Library"]; ok {
+		sl.Library.Construct(v.(map[string]any))
+	} else {
+		sl.Library.Construct(nil)
+	}
+}
+
+func (sl *ScriptLibrary) Serialize() map[string]any {
+	result := sl.Attached.Serialize()
+	if !sl.Library.IsEmpty() {
+		result["Library"] = sl.Library.Serialize()
+	}
+	return result
+}
+
+// All scripts are compiled into one interpreter, which is much faster than
+// creating an interpreter for each one. The interpreter is rebuilt when a
+// library changes, and scripts compiled against an older one recompile the
+// next time they run.
+var scriptLibraries struct {
+	interp     *interp.Interpreter
+	generation int
+	// Each script gets its own function name in the shared interpreter, which
+	// it keeps when compiled again, so recompiling replaces the function
+	// rather than adding another one.
+	nextFunc int
+	// yaegi writes the positions of runtime panics to stderr, we use that to
+	// find the line in the user's code.
+	stderr bytes.Buffer
+}
+
+// Every package that scripts can use. Imports are shared by all the code in
+// an interpreter, so they're only evaluated once.
+const scriptPrelude = `
+	package main
+
+	import "tlyakhov/gofoom/components/audio"
+	import "tlyakhov/gofoom/components/behaviors"
+	import "tlyakhov/gofoom/components/character"
+	import "tlyakhov/gofoom/components/core"
+	import "tlyakhov/gofoom/components/inventory"
+	import "tlyakhov/gofoom/components/materials"
+	import "tlyakhov/gofoom/components/selection"
+	import "tlyakhov/gofoom/concepts"
+	import "tlyakhov/gofoom/constants"
+	import "tlyakhov/gofoom/containers"
+	import "tlyakhov/gofoom/controllers"
+	import "tlyakhov/gofoom/ecs"
+	import "tlyakhov/gofoom/pathfinding"
+	import "log"
+	import "fmt"
+
+	// Sandboxed code calls ScriptStep through this, see scriptStepImport.
+	import gofoom_sandbox "tlyakhov/gofoom/components/core"
+`
+
+func init() {
+	// Libraries and scripts from the previous world shouldn't stick around.
+	ecs.OnInitialize(InvalidateScriptLibraries)
+}
+
+// InvalidateScriptLibraries discards the shared interpreter, e.g. after a
+// library changed. Scripts recompile the next time they run.
+func InvalidateScriptLibraries() {
+	scriptLibraries.interp = nil
+	scriptLibraries.generation++
+}
+
+// sharedScriptInterpreter returns the interpreter for scripts, building it
+// and evaluating every library if needed.
+func sharedScriptInterpreter() *interp.Interpreter {
+	if scriptLibraries.interp != nil {
+		return scriptLibraries.interp
+	}
+	i := interp.New(interp.Options{Stderr: &scriptLibraries.stderr})
+	if ScriptSandbox {
+		i.Use(sandboxSymbols(stdlib.Symbols))
+		i.Use(sandboxSymbols(ecs.Types().InterpSymbols))
+	} else {
+		i.Use(stdlib.Symbols)
+		i.Use(ecs.Types().InterpSymbols)
+	}
+	if _, err := i.Eval(scriptPrelude); err != nil {
+		log.Printf("core.sharedScriptInterpreter: error importing packages: %v", err)
+	}
+	scriptLibraries.interp = i
+	scriptLibraries.generation++
+
+	var libraries []*ScriptLibrary
+	arena := ecs.ArenaFor[ScriptLibrary](ScriptLibraryCID)
+	for index := range arena.Cap() {
+		if sl := arena.Value(index); sl != nil && sl.IsActive() {
+			libraries = append(libraries, sl)
+		}
+	}
+	// Libraries can use each other, evaluate them in a stable order.
+	slices.SortFunc(libraries, func(a, b *ScriptLibrary) int { return cmp.Compare(a.Entity, b.Entity) })
+	for _, sl := range libraries {
+		sl.Library.SetOwner(sl.Entity, sl, "ScriptLibrary")
+		sl.Library.evalLibrary(i)
+	}
+	return i
+}
+
+// NeedsCompile is true if the library has changed since it was last evaluated
+// (or never was).
+func (sl *ScriptLibrary) NeedsCompile() bool {
+	if sl.Library.generation != scriptLibraries.generation || scriptLibraries.interp == nil {
+		return true
+	}
+	return sl.Library.File == "" && sl.Library.execCode != "package main\n\n"+sl.Library.Code
+}
+
+func (s *Script) evalLibrary(i *interp.Interpreter) {
+	s.ErrorMessage = ""
+	s.interp = nil
+	registerScript(s)
+	if !s.load() {
+		return
+	}
+	s.execCode = "package main\n\n" + s.Code
+	s.codeLine, s.codeColumn = 3, 1
+	s.generation = scriptLibraries.generation
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			se := s.reportError(false, fmt.Sprintf("%v", recovered))
+			log.Printf("%v", se.String())
+		}
+	}()
+	if beginScriptBudget() {
+		defer endScriptBudget()
+	}
+	if err := s.eval(i, s.execCode); err != nil {
+		se := s.reportError(false, err.Error())
+		log.Printf("%v", se.String())
+		return
+	}
+	s.interp = i
+}
+
+// eval evaluates code in the shared interpreter, checking it first if the
+// sandbox is enabled.
+func (s *Script) eval(i *interp.Interpreter, code string) error {
+	if ScriptSandbox {
+		var err error
+		if code, err = sandboxCode(code); err != nil {
+			return err
+		}
+	}
+	_, err := i.Eval(code)
+	return err
+}
+
+// compileShared compiles a script into its function in the shared
+// interpreter.
+func (s *Script) compileShared() {
+	i := sharedScriptInterpreter()
+	if s.funcName == "" {
+		scriptLibraries.nextFunc++
+		s.funcName = fmt.Sprintf("Do%v", scriptLibraries.nextFunc)
+	}
+	data := struct {
+		*Script
+		Func string
+	}{s, s.funcName}
+
+	var buf bytes.Buffer
+	if err := scriptTemplate.Execute(&buf, data); err != nil {
+		se := s.reportError(false, fmt.Sprintf("building script template: %v", err))
+		log.Printf("%v", se.String())
+		return
+	}
+	s.execCode = buf.String()
+	s.codeLine, s.codeColumn = 0, 0
+	if index := strings.Index(s.execCode, s.Code); index >= 0 && s.Code != "" {
+		s.codeLine = strings.Count(s.execCode[:index], "\n") + 1
+		s.codeColumn = index - strings.LastIndex(s.execCode[:index], "\n")
+	}
+	s.generation = scriptLibraries.generation
+
+	// This handles panics inside the interpreter. It's a bit aggressive, but
+	// saves the game from crashing due to bad scripting
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		se := s.reportError(false, fmt.Sprintf("%v", recovered))
+		log.Printf("%v", se.String())
+		s.interp = nil
+		s.runFunc = nil
+	}()
+
+	if err := s.eval(i, s.execCode); err != nil {
+		se := s.reportError(false, err.Error())
+		log.Printf("%v", se.String())
+		return
+	}
+	f, err := i.Eval("main." + data.Func)
+	if err != nil {
+		se := s.reportError(false, err.Error())
+		log.Printf("%v", se.String())
+		return
+	}
+
+	s.interp = i
+	s.runFunc = f.Interface()
+}
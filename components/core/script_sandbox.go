@@ -0,0 +1,327 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package core
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"tlyakhov/gofoom/dynamic"
+
+	"github.com/traefik/yaegi/interp"
+)
+
+// ScriptSandbox restricts what scripts can do, for running worlds we didn't
+// make. When it's enabled:
+//   - Only the packages in scriptSandboxPackages can be used. ecs and dynamic,
+//     which manage the world and files, only export an allowlist. Other
+//     packages export everything but a few symbols.
+//   - Goroutines, channels, goto and recover aren't allowed, nor are the
+//     fields and methods in scriptSandboxSelectors (called or not).
+//   - Each run of a script is limited to ScriptMaxSteps (loop iterations and
+//     function calls) and ScriptMaxTime. A script that goes over is aborted,
+//     which counts as a runtime error.
+//   - The engine refuses to read or write files (saves, demos, prefabs,
+//     worlds and scripts) while a sandboxed script runs, see
+//     dynamic.CheckSandbox. This covers methods of game types, which the
+//     static checks can only match by name.
+//
+// Use SetScriptSandbox to change it, since scripts have to be compiled again.
+var ScriptSandbox = false
+
+var ScriptMaxSteps = 1_000_000
+var ScriptMaxTime = 50 * time.Millisecond
+
+// SetScriptSandbox turns the sandbox on or off. Scripts are compiled again the
+// next time they run.
+func SetScriptSandbox(enabled bool) {
+	if ScriptSandbox == enabled {
+		return
+	}
+	ScriptSandbox = enabled
+	InvalidateScriptLibraries()
+}
+
+// scriptSandboxRules are the symbols a sandboxed script can use from a
+// package. If Allow is set, only those symbols are exported, otherwise
+// everything but Deny is. Interface wrappers (names starting with "_") are
+// always exported, the interpreter needs them.
+type scriptSandboxRules struct {
+	Allow []string
+	Deny  []string
+}
+
+func (r scriptSandboxRules) allowed(name string) bool {
+	if strings.HasPrefix(name, "_") {
+		return true
+	}
+	if r.Allow != nil {
+		return slices.Contains(r.Allow, name)
+	}
+	return !slices.Contains(r.Deny, name)
+}
+
+// Packages that sandboxed scripts can use.
+var scriptSandboxPackages = map[string]scriptSandboxRules{
+	"errors":       {},
+	"fmt":          {Deny: []string{"Fscan", "Fscanf", "Fscanln", "Scan", "Scanf", "Scanln"}},
+	"log":          {Deny: []string{"Fatal", "Fatalf", "Fatalln", "SetOutput", "New", "Default", "Writer"}},
+	"maps":         {},
+	"math":         {},
+	"math/bits":    {},
+	"math/rand":    {},
+	"slices":       {},
+	"sort":         {},
+	"strconv":      {},
+	"strings":      {},
+	"time":         {Deny: []string{"Sleep", "After", "AfterFunc", "Tick", "NewTicker", "NewTimer"}},
+	"unicode":      {},
+	"unicode/utf8": {},
+
+	"tlyakhov/gofoom/components/audio":     {},
+	"tlyakhov/gofoom/components/behaviors": {},
+	"tlyakhov/gofoom/components/character": {},
+	"tlyakhov/gofoom/components/core": {Deny: []string{"ScriptSandbox", "ScriptMaxSteps", "ScriptMaxTime",
+		"SetScriptSandbox", "ScriptRetries", "ReloadChangedScripts", "InvalidateScriptLibraries",
+		"ResetScriptDiagnostics"}},
+	"tlyakhov/gofoom/components/inventory": {},
+	"tlyakhov/gofoom/components/materials": {},
+	"tlyakhov/gofoom/components/selection": {},
+	"tlyakhov/gofoom/concepts":             {},
+	"tlyakhov/gofoom/constants":            {},
+	"tlyakhov/gofoom/containers":           {},
+	"tlyakhov/gofoom/controllers":          {Deny: []string{"ImportWorld", "CreateFont"}},
+	"tlyakhov/gofoom/dynamic": {Allow: []string{
+		"Animated", "AnimationCoordinates", "AnimationCoordinatesAbsolute", "AnimationCoordinatesRelative",
+		"AnimationLifetime", "AnimationLifetimeBounce", "AnimationLifetimeBounceOnce", "AnimationLifetimeLoop",
+		"AnimationLifetimeOnce", "DefaultEventPriority", "Dynamic", "DynamicState", "EaseIn2", "EaseIn3",
+		"EaseIn4", "EaseInOut2", "EaseInOut3", "EaseInOut4", "EaseOut2", "EaseOut3", "EaseOut4", "ElasticIn",
+		"ElasticInOut", "ElasticOut", "Event", "EventID", "EventRecord", "EventSubscription", "Lerp", "Now",
+		"Prev", "Random", "Render", "Simulation", "Spawn", "Spawnable", "Spike", "Spike2", "Spike3", "Spike4",
+		"SubscribeToEvent", "SubscribeToEventWithPriority", "SubscribeToTarget", "Timer", "TimerHandler",
+		"TweenAngles", "TweeningFunc", "TweeningFuncs", "Unsubscribe"}},
+	"tlyakhov/gofoom/ecs": {Allow: []string{
+		"ActAllControllersOneEntity", "Attach", "Attachable", "Attached", "AttachedWithIndirects",
+		"BaseController", "Component", "ComponentActive", "ComponentFlags", "ComponentHideEntityInEditor",
+		"ComponentHideInEditor", "ComponentID", "ComponentInternal", "ComponentLockedEntityInEditor",
+		"ComponentLockedInEditor", "ComponentNoSave", "Controller", "ControllerFrame", "ControllerMethod",
+		"ControllerPrecompute", "Delete", "DetachComponent", "EntitiesByComponent", "EntitiesByName",
+		"EntitiesByTag", "Entity", "EntityTable", "FindEntities", "First", "FuncMap", "GetComponent",
+		"GetEntityByName", "GetLinked", "GetNamed", "GetTagged", "IsChanged", "Linked", "LinkedCID",
+		"MarkModified", "Named", "NamedCID", "NewAttachedComponent", "NewAttachedComponentTyped", "NewEntity",
+		"NewQuery", "ParseEntity", "ParseEntityHumanOrCanonical", "ParseEntitySlice", "ParseEntityTable",
+		"Query", "SerializeEntity", "Simulation", "Singleton", "Tagged", "TaggedCID"}},
+	"tlyakhov/gofoom/pathfinding": {},
+}
+
+// Fields and methods that sandboxed scripts can't use, on any type, whether
+// they're called or not (e.g. f := sim.Record). These touch files or compile
+// code outside the sandbox checks. The engine checks for file access too, see
+// dynamic.CheckSandbox.
+var scriptSandboxSelectors = []string{
+	"Record", "Play", // dynamic.Simulation demos
+	"Save", "ApplyOverrides", // ecs.Prefab/PrefabInstance
+	"File", "Compile", "Recompile", // core.Script
+	// ecs.Types(), these reach every symbol through reflect
+	"InterpSymbols", "ExprEnv",
+}
+
+// scriptStepImport is the name sandboxed code uses to call ScriptStep (see
+// scriptPrelude). Scripts can't use it, so they can't shadow it with their
+// own ScriptStep.
+const scriptStepImport = "gofoom_sandbox"
+
+// sandboxSymbols returns only the symbols sandboxed scripts can use. The keys
+// of interp.Exports are "import/path/name".
+func sandboxSymbols(symbols interp.Exports) interp.Exports {
+	result := make(interp.Exports)
+	for key, values := range symbols {
+		path := key
+		if i := strings.LastIndex(key, "/"); i >= 0 {
+			path = key[:i]
+		}
+		rules, ok := scriptSandboxPackages[path]
+		if !ok {
+			continue
+		}
+		allowed := make(map[string]reflect.Value, len(values))
+		for name, v := range values {
+			if rules.allowed(name) {
+				allowed[name] = v
+			}
+		}
+		result[key] = allowed
+	}
+	return result
+}
+
+// scriptBudget tracks the steps and time of the script being run. Scripts run
+// on the simulation goroutine, and nested runs (a script triggering another)
+// share the budget of the outermost one. Once it's exceeded, every step
+// panics until the outermost run is done, so a script can't keep going by
+// catching the panic.
+var scriptBudget struct {
+	active    bool
+	sandboxed bool
+	exceeded  *ScriptBudgetError
+	steps     int
+	start     time.Time
+}
+
+// ScriptBudgetError is the panic raised when a sandboxed script goes over
+// its budget.
+type ScriptBudgetError struct {
+	Steps   int
+	Elapsed time.Duration
+}
+
+func (e *ScriptBudgetError) Error() string {
+	return fmt.Sprintf("sandbox: script exceeded its budget (%v steps, %v)", e.Steps, e.Elapsed)
+}
+
+// beginScriptBudget starts counting steps, returning false if a budget was
+// already running.
+func beginScriptBudget() bool {
+	if scriptBudget.active {
+		return false
+	}
+	scriptBudget.active = true
+	scriptBudget.sandboxed = ScriptSandbox
+	scriptBudget.exceeded = nil
+	scriptBudget.steps = 0
+	scriptBudget.start = time.Now()
+	if scriptBudget.sandboxed {
+		dynamic.EnterSandbox()
+	}
+	return true
+}
+
+func endScriptBudget() {
+	if scriptBudget.sandboxed {
+		dynamic.ExitSandbox()
+	}
+	scriptBudget.active = false
+	scriptBudget.sandboxed = false
+	scriptBudget.exceeded = nil
+}
+
+// ScriptStep is called by sandboxed scripts at the start of every loop
+// iteration and function, and panics if the script is over its budget.
+func ScriptStep() {
+	if !scriptBudget.active {
+		return
+	}
+	if scriptBudget.exceeded != nil {
+		panic(scriptBudget.exceeded)
+	}
+	scriptBudget.steps++
+	if scriptBudget.steps > ScriptMaxSteps ||
+		(scriptBudget.steps%256 == 0 && time.Since(scriptBudget.start) > ScriptMaxTime) {
+		scriptBudget.exceeded = &ScriptBudgetError{Steps: scriptBudget.steps, Elapsed: time.Since(scriptBudget.start)}
+		panic(scriptBudget.exceeded)
+	}
+}
+
+// sandboxCode checks code against the sandbox rules, and adds budget checks to
+// the start of every loop body and function. Errors are prefixed with the
+// position in the code, like the interpreter's.
+func sandboxCode(code string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", code, parser.SkipObjectResolution)
+	if err != nil {
+		// Let the interpreter report syntax errors.
+		return code, nil
+	}
+
+	var violation error
+	var insertions []int
+	fail := func(pos token.Pos, format string, args ...any) {
+		if violation == nil {
+			p := fset.Position(pos)
+			violation = fmt.Errorf("%v:%v: sandbox: %v", p.Line, p.Column, fmt.Sprintf(format, args...))
+		}
+	}
+	step := func(body *ast.BlockStmt) {
+		if body != nil {
+			insertions = append(insertions, fset.Position(body.Lbrace).Offset+1)
+		}
+	}
+
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if _, ok := scriptSandboxPackages[path]; !ok {
+			fail(spec.Pos(), "package %v isn't allowed", path)
+		}
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.GoStmt:
+			fail(n.Pos(), "go statements aren't allowed")
+		case *ast.SelectStmt, *ast.SendStmt, *ast.ChanType:
+			fail(n.Pos(), "channels aren't allowed")
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				fail(n.Pos(), "channels aren't allowed")
+			}
+		case *ast.BranchStmt:
+			if n.Tok == token.GOTO {
+				fail(n.Pos(), "goto isn't allowed")
+			}
+		case *ast.Ident:
+			if n.Name == "recover" {
+				fail(n.Pos(), "recover isn't allowed")
+			} else if n.Name == scriptStepImport {
+				fail(n.Pos(), "%v is reserved", scriptStepImport)
+			}
+		case *ast.SelectorExpr:
+			if pkg, ok := n.X.(*ast.Ident); ok && sandboxDenied(pkg.Name, n.Sel.Name) {
+				fail(n.Pos(), "%v.%v isn't allowed", pkg.Name, n.Sel.Name)
+			} else if slices.Contains(scriptSandboxSelectors, n.Sel.Name) {
+				fail(n.Sel.Pos(), "%v isn't allowed", n.Sel.Name)
+			}
+		case *ast.ForStmt:
+			step(n.Body)
+		case *ast.RangeStmt:
+			step(n.Body)
+		case *ast.FuncDecl:
+			step(n.Body)
+		case *ast.FuncLit:
+			step(n.Body)
+		}
+		return true
+	})
+	if violation != nil {
+		return "", violation
+	}
+
+	// Everything goes on the same line as the brace, to keep line numbers the
+	// same.
+	slices.Sort(insertions)
+	var sb strings.Builder
+	prev := 0
+	for _, offset := range insertions {
+		sb.WriteString(code[prev:offset])
+		sb.WriteString(" " + scriptStepImport + ".ScriptStep();")
+		prev = offset
+	}
+	sb.WriteString(code[prev:])
+	return sb.String(), nil
+}
+
+// sandboxDenied checks whether a package-qualified symbol is denied, by the
+// package's name (e.g. "ecs" rather than "tlyakhov/gofoom/ecs").
+func sandboxDenied(pkg, name string) bool {
+	for path, rules := range scriptSandboxPackages {
+		if (path == pkg || strings.HasSuffix(path, "/"+pkg)) && !rules.allowed(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package core
+
+import (
+	"fmt"
+	"maps"
+	"tlyakhov/gofoom/ecs"
+
+	"github.com/spf13/cast"
+)
+
+// ScriptState is persistent per-entity storage for scripts (e.g. how many
+// times a switch has been flipped). It's saved with the world and in save
+// games. Values should be simple types like numbers, strings and bools, since
+// they go through YAML (numbers come back as float64, use the typed getters).
+type ScriptState struct {
+	ecs.Attached `editable:"^"`
+
+	Values map[string]any
+}
+
+// ScriptStateFor returns the state for an entity, attaching it if needed.
+func ScriptStateFor(e ecs.Entity) *ScriptState {
+	if e == 0 {
+		return nil
+	}
+	if ss := GetScriptState(e); ss != nil {
+		return ss
+	}
+	return ecs.NewAttachedComponent(e, ScriptStateCID).(*ScriptState)
+}
+
+func (ss *ScriptState) String() string {
+	return fmt.Sprintf("ScriptState (%v values)", len(ss.Values))
+}
+
+func (ss *ScriptState) Get(key string) any {
+	return ss.Values[key]
+}
+
+func (ss *ScriptState) Set(key string, value any) {
+	ss.Values[key] = value
+}
+
+func (ss *ScriptState) Delete(key string) {
+	delete(ss.Values, key)
+}
+
+func (ss *ScriptState) Has(key string) bool {
+	_, ok := ss.Values[key]
+	return ok
+}
+
+func (ss *ScriptState) Float(key string) float64 {
+	return cast.ToFloat64(ss.Values[key])
+}
+
+func (ss *ScriptState) Int(key string) int {
+	return cast.ToInt(ss.Values[key])
+}
+
+func (ss *ScriptState) Text(key string) string {
+	return cast.ToString(ss.Values[key])
+}
+
+func (ss *ScriptState) Bool(key string) bool {
+	return cast.ToBool(ss.Values[key])
+}
+
+func (ss *ScriptState) Construct(data map[string]any) {
+	ss.Attached.Construct(data)
+	ss.Values = make(map[string]any)
+
+	if data == nil {
+		return
+	}
+
+	if v, ok := data["Values"]; ok {
+		maps.Copy(ss.Values, cast.ToStringMap(v))
+	}
+}
+
+func (ss *ScriptState) Serialize() map[string]any {
+	result := ss.Attached.Serialize()
+	if len(ss.Values) > 0 {
+		result["Values"] = maps.Clone(ss.Values)
+	}
+	return result
+}
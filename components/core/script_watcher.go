@@ -0,0 +1,179 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package core
+
+import (
+	"log"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"tlyakhov/gofoom/ecs"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Scripts can live in external files (see Script.File), which we watch for
+// changes. The watcher runs in its own goroutine, so it only marks files as
+// changed. ReloadChangedScripts does the actual recompiling, and should be
+// called from the same goroutine as the simulation.
+var scriptWatcher struct {
+	sync.Mutex
+	watcher *fsnotify.Watcher
+	failed  bool
+	dirs    map[string]struct{}
+	scripts map[string][]*Script
+	changed map[string]struct{}
+}
+
+func init() {
+	ecs.OnInitialize(resetScriptWatcher)
+}
+
+// resetScriptWatcher forgets every watched script when the world is reset,
+// since they belong to the old one.
+func resetScriptWatcher() {
+	scriptWatcher.Lock()
+	defer scriptWatcher.Unlock()
+	for dir := range scriptWatcher.dirs {
+		if err := scriptWatcher.watcher.Remove(dir); err != nil {
+			log.Printf("core.resetScriptWatcher: error unwatching %v: %v", dir, err)
+		}
+	}
+	clear(scriptWatcher.dirs)
+	clear(scriptWatcher.scripts)
+	clear(scriptWatcher.changed)
+}
+
+// pruneScriptWatcher forgets scripts whose components have been detached or
+// deleted. Scripts without an owner are kept until the world is reset. It
+// expects the lock to be held.
+func pruneScriptWatcher() {
+	for path, scripts := range scriptWatcher.scripts {
+		scripts = slices.DeleteFunc(scripts, func(s *Script) bool {
+			if s.owner == 0 || s.isAttached() {
+				return false
+			}
+			s.watchedPath = ""
+			return true
+		})
+		if len(scripts) == 0 {
+			delete(scriptWatcher.scripts, path)
+			delete(scriptWatcher.changed, path)
+		} else {
+			scriptWatcher.scripts[path] = scripts
+		}
+	}
+}
+
+// watchScriptFile starts watching the file at path (absolute) for changes,
+// replacing any previous file the script was watching.
+func watchScriptFile(s *Script, path string) {
+	scriptWatcher.Lock()
+	defer scriptWatcher.Unlock()
+
+	pruneScriptWatcher()
+	if s.watchedPath == path {
+		return
+	}
+	unwatchScriptFile(s)
+	if path == "" {
+		return
+	}
+
+	if scriptWatcher.watcher == nil && !scriptWatcher.failed {
+		w, err := fsnotify.NewWatcher()
+		if err != nil {
+			log.Printf("core.watchScriptFile: can't watch script files, hot reload is disabled: %v", err)
+			scriptWatcher.failed = true
+			return
+		}
+		scriptWatcher.watcher = w
+		scriptWatcher.dirs = make(map[string]struct{})
+		scriptWatcher.scripts = make(map[string][]*Script)
+		scriptWatcher.changed = make(map[string]struct{})
+		go watchScriptEvents(w)
+	}
+	if scriptWatcher.watcher == nil {
+		return
+	}
+
+	// Watch directories rather than files, many editors save by writing a new
+	// file and renaming it over the old one.
+	dir := filepath.Dir(path)
+	if _, ok := scriptWatcher.dirs[dir]; !ok {
+		if err := scriptWatcher.watcher.Add(dir); err != nil {
+			log.Printf("core.watchScriptFile: error watching %v: %v", dir, err)
+			return
+		}
+		scriptWatcher.dirs[dir] = struct{}{}
+	}
+	scriptWatcher.scripts[path] = append(scriptWatcher.scripts[path], s)
+	s.watchedPath = path
+}
+
+// unwatchScriptFile expects the lock to be held.
+func unwatchScriptFile(s *Script) {
+	if s.watchedPath == "" {
+		return
+	}
+	path := s.watchedPath
+	s.watchedPath = ""
+	scriptWatcher.scripts[path] = slices.DeleteFunc(scriptWatcher.scripts[path], func(other *Script) bool {
+		return other == s
+	})
+	if len(scriptWatcher.scripts[path]) == 0 {
+		delete(scriptWatcher.scripts, path)
+	}
+}
+
+func watchScriptEvents(w *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+			path := filepath.Clean(event.Name)
+			scriptWatcher.Lock()
+			if _, ok := scriptWatcher.scripts[path]; ok {
+				scriptWatcher.changed[path] = struct{}{}
+			}
+			scriptWatcher.Unlock()
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("core.watchScriptEvents: %v", err)
+		}
+	}
+}
+
+// ReloadChangedScripts recompiles scripts whose files have changed since the
+// last call. Returns the number of scripts that were reloaded.
+func ReloadChangedScripts() int {
+	scriptWatcher.Lock()
+	if len(scriptWatcher.changed) == 0 {
+		scriptWatcher.Unlock()
+		return 0
+	}
+	pruneScriptWatcher()
+	var reload []*Script
+	for path := range scriptWatcher.changed {
+		reload = append(reload, scriptWatcher.scripts[path]...)
+		delete(scriptWatcher.changed, path)
+	}
+	scriptWatcher.Unlock()
+
+	count := 0
+	for _, s := range reload {
+		if s.reload() {
+			count++
+		}
+	}
+	return count
+}
@@ -4,6 +4,7 @@
 package core
 
 import (
+	"tlyakhov/gofoom/dynamic"
 	"tlyakhov/gofoom/ecs"
 
 	"github.com/spf13/cast"
@@ -16,16 +17,30 @@ type Scripted struct {
 	Args    []string `editable:"Arguments"`
 	Timer   float64  `editable:"ETA (timer)"` // ms
 
-	TimerStart int64 // ns
+	// If Timer is set, this runs OnFrame instead of every frame. Scripts can
+	// use it to reschedule themselves.
+	Ticker dynamic.Timer
 }
 
 func (s *Scripted) Shareable() bool { return true }
 
+func (s *Scripted) OnAttach() {
+	s.AttachedWithIndirects.OnAttach()
+	s.Ticker.Attach(ecs.Simulation)
+}
+
+func (s *Scripted) OnDelete() {
+	defer s.AttachedWithIndirects.OnDelete()
+	if s.IsAttached() {
+		s.Ticker.Detach(ecs.Simulation)
+	}
+}
+
 func (s *Scripted) Construct(data map[string]any) {
 	s.AttachedWithIndirects.Construct(data)
 	s.Args = nil
 	s.Timer = 0
-	s.TimerStart = ecs.Simulation.SimTimestamp
+	s.Ticker.Cancel()
 
 	if data == nil {
 		s.OnFrame.Construct(nil)
@@ -415,18 +415,22 @@ func (s *Sector) Precompute() {
 
 	for _, script := range s.EnterScripts {
 		script.Params = contactScriptParams
+		script.SetOwner(s.Entity, s, "Sector.Enter")
 		script.Compile()
 	}
 	for _, script := range s.ExitScripts {
 		script.Params = contactScriptParams
+		script.SetOwner(s.Entity, s, "Sector.Exit")
 		script.Compile()
 	}
 	for _, script := range s.Top.Scripts {
 		script.Params = contactScriptParams
+		script.SetOwner(s.Entity, s, "Sector.Top")
 		script.Compile()
 	}
 	for _, script := range s.Bottom.Scripts {
 		script.Params = contactScriptParams
+		script.SetOwner(s.Entity, s, "Sector.Bottom")
 		script.Compile()
 	}
 }
@@ -14,75 +14,49 @@ import (
 	"github.com/spf13/cast"
 )
 
+//ecs:serialize
 type SectorPlane struct {
 	*Sector
 
-	Z       dynamic.DynamicValue[float64] `editable:"Z"`
-	Normal  concepts.Vector3              `editable:"Normal" edit_type:"Normal"`
-	Target  ecs.Entity                    `editable:"Target" edit_type:"Sector"`
-	Surface materials.Surface             `editable:"Surf"`
-	Scripts []*Script                     `editable:"Scripts"`
+	Z dynamic.DynamicValue[float64] `editable:"Z"`
+	// The sector sets this up depending on whether it's the floor or ceiling,
+	// see the hooks below.
+	Normal  concepts.Vector3  `editable:"Normal" edit_type:"Normal" serialize:"-"`
+	Target  ecs.Entity        `editable:"Target" edit_type:"Sector"`
+	Surface materials.Surface `editable:"Surf"`
+	Scripts []*Script         `editable:"Scripts"`
 
 	// This is only valid for inner sectors
 	Ignore bool `editable:"Ignore"`
 
-	PlaneDet dynamic.DynamicValue[float64]
+	PlaneDet dynamic.DynamicValue[float64] `serialize:"-"`
 }
 
 func (s *SectorPlane) String() string {
 	return fmt.Sprintf("Plane (Z: %v, Normal: %v)", s.Z.Now, s.Normal)
 }
 
-func (s *SectorPlane) Construct(data map[string]any) {
-	s.Surface.Construct(data)
-	s.Z.Construct(nil)
+// construct is called by the generated Construct.
+func (s *SectorPlane) construct(data map[string]any) {
 	s.PlaneDet.Construct(nil)
 
 	if data == nil {
 		return
 	}
-
-	if v, ok := data["Z"]; ok {
-		s.Z.Construct(v)
+	// Older worlds have the surface properties inline.
+	if _, ok := data["Surface"]; !ok {
+		s.Surface.Construct(data)
 	}
 	if v, ok := data["Normal"]; ok {
-		s.Normal.Deserialize(v.(string))
-	}
-	if v, ok := data["Surface"]; ok {
-		s.Surface.Construct(v.(map[string]any))
-	}
-	if v, ok := data["Target"]; ok {
-		s.Target, _ = ecs.ParseEntity(v.(string))
-	}
-	if v, ok := data["Scripts"]; ok {
-		s.Scripts = ecs.ConstructSlice[*Script](v, nil)
+		s.Normal.Deserialize(cast.ToString(v))
 	}
-	if v, ok := data["Ignore"]; ok {
-		s.Ignore = cast.ToBool(v)
-	}
-
 }
 
-func (s *SectorPlane) Serialize() map[string]any {
-	result := make(map[string]any)
-	result["Z"] = s.Z.Serialize()
-	result["Surface"] = s.Surface.Serialize()
-
+// serialize is called by the generated Serialize.
+func (s *SectorPlane) serialize(result map[string]any) {
 	if s.Normal[0] != 0 || s.Normal[1] != 0 || math.Abs(s.Normal[2]) != 1 {
 		result["Normal"] = s.Normal.Serialize()
 	}
-	if s.Target != 0 {
-		result["Target"] = s.Target.Serialize()
-	}
-	if len(s.Scripts) > 0 {
-		result["Scripts"] = ecs.SerializeSlice(s.Scripts)
-	}
-
-	if s.Ignore {
-		result["Ignore"] = true
-	}
-
-	return result
 }
 
 func (s *SectorPlane) Precompute() {
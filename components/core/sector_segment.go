@@ -50,6 +50,11 @@ func (s *SectorSegment) Precompute() {
 	if s.Next != nil {
 		s.Segment.B = &s.Next.P.Render
 	}
+	if s.Sector != nil {
+		for _, script := range s.ContactScripts {
+			script.SetOwner(s.Sector.Entity, s.Sector, "SectorSegment.Contact")
+		}
+	}
 	s.Segment.Precompute()
 
 	if s.Sector != nil && s.Sector.Winding < 0 {
@@ -24,15 +24,21 @@ type Segment struct {
 }
 
 func (s *Segment) Precompute() {
-	s.Length = s.B.Dist(s.A)
-	s.Normal[0] = -(s.B[1] - s.A[1]) / s.Length
-	s.Normal[1] = (s.B[0] - s.A[0]) / s.Length
+	s.PrecomputeNormal()
 	for _, script := range s.ContactScripts {
 		script.Params = contactScriptParams
 		script.Compile()
 	}
 }
 
+// PrecomputeNormal updates the length and normal only. Cheaper than
+// Precompute for segments that move every frame.
+func (s *Segment) PrecomputeNormal() {
+	s.Length = s.B.Dist(s.A)
+	s.Normal[0] = -(s.B[1] - s.A[1]) / s.Length
+	s.Normal[1] = (s.B[0] - s.A[0]) / s.Length
+}
+
 func (s *Segment) Matches(s2 *Segment) bool {
 	d1 := math.Abs(s.A[0]-s2.A[0]) < matchEpsilon && math.Abs(s.B[0]-s2.B[0]) < matchEpsilon &&
 		math.Abs(s.A[1]-s2.A[1]) < matchEpsilon && math.Abs(s.B[1]-s2.B[1]) < matchEpsilon
@@ -1,12 +1,19 @@
 // Code generated by ecs/cmd/gofoom_ecs_generator. DO NOT EDIT.
 package core
 
-import "tlyakhov/gofoom/ecs"
+import (
+	"github.com/spf13/cast"
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/ecs"
+)
 
 var BodyCID ecs.ComponentID
 var InternalSegmentCID ecs.ComponentID
 var LightCID ecs.ComponentID
 var MobileCID ecs.ComponentID
+var ParentedCID ecs.ComponentID
+var ScriptLibraryCID ecs.ComponentID
+var ScriptStateCID ecs.ComponentID
 var ScriptedCID ecs.ComponentID
 var SectorCID ecs.ComponentID
 
@@ -15,6 +22,9 @@ func init() {
 	InternalSegmentCID = ecs.RegisterComponent(&ecs.Arena[InternalSegment, *InternalSegment]{})
 	LightCID = ecs.RegisterComponent(&ecs.Arena[Light, *Light]{})
 	MobileCID = ecs.RegisterComponent(&ecs.Arena[Mobile, *Mobile]{})
+	ParentedCID = ecs.RegisterComponent(&ecs.Arena[Parented, *Parented]{})
+	ScriptLibraryCID = ecs.RegisterComponent(&ecs.Arena[ScriptLibrary, *ScriptLibrary]{})
+	ScriptStateCID = ecs.RegisterComponent(&ecs.Arena[ScriptState, *ScriptState]{})
 	ScriptedCID = ecs.RegisterComponent(&ecs.Arena[Scripted, *Scripted]{})
 	SectorCID = ecs.RegisterComponent(&ecs.Arena[Sector, *Sector]{})
 }
@@ -58,6 +68,36 @@ func GetMobile(e ecs.Entity) *Mobile {
 func (*Mobile) ComponentID() ecs.ComponentID {
 	return MobileCID
 }
+func GetParented(e ecs.Entity) *Parented {
+	if asserted, ok := ecs.GetComponent(e, ParentedCID).(*Parented); ok {
+		return asserted
+	}
+	return nil
+}
+
+func (*Parented) ComponentID() ecs.ComponentID {
+	return ParentedCID
+}
+func GetScriptLibrary(e ecs.Entity) *ScriptLibrary {
+	if asserted, ok := ecs.GetComponent(e, ScriptLibraryCID).(*ScriptLibrary); ok {
+		return asserted
+	}
+	return nil
+}
+
+func (*ScriptLibrary) ComponentID() ecs.ComponentID {
+	return ScriptLibraryCID
+}
+func GetScriptState(e ecs.Entity) *ScriptState {
+	if asserted, ok := ecs.GetComponent(e, ScriptStateCID).(*ScriptState); ok {
+		return asserted
+	}
+	return nil
+}
+
+func (*ScriptState) ComponentID() ecs.ComponentID {
+	return ScriptStateCID
+}
 func GetScripted(e ecs.Entity) *Scripted {
 	if asserted, ok := ecs.GetComponent(e, ScriptedCID).(*Scripted); ok {
 		return asserted
@@ -78,3 +118,115 @@ func GetSector(e ecs.Entity) *Sector {
 func (*Sector) ComponentID() ecs.ComponentID {
 	return SectorCID
 }
+
+func (p *Parented) Construct(data map[string]any) {
+	p.Attached.Construct(data)
+	p.Parent = 0
+	p.Pos = (concepts.Vector3{})
+	p.Angle = 0
+	p.InheritAngle = true
+	p.FollowSegment = false
+	p.SegmentA = (concepts.Vector2{})
+	p.SegmentB = (concepts.Vector2{})
+	p.Cascade = false
+	if data != nil {
+		if v, ok := data["Parent"]; ok {
+			p.Parent, _ = ecs.ParseEntity(cast.ToString(v))
+		}
+		if v, ok := data["Pos"]; ok {
+			p.Pos.Deserialize(cast.ToString(v))
+		}
+		if v, ok := data["Angle"]; ok {
+			p.Angle = cast.ToFloat64(v)
+		}
+		if v, ok := data["InheritAngle"]; ok {
+			p.InheritAngle = cast.ToBool(v)
+		}
+		if v, ok := data["FollowSegment"]; ok {
+			p.FollowSegment = cast.ToBool(v)
+		}
+		if v, ok := data["SegmentA"]; ok {
+			p.SegmentA.Deserialize(cast.ToString(v))
+		}
+		if v, ok := data["SegmentB"]; ok {
+			p.SegmentB.Deserialize(cast.ToString(v))
+		}
+		if v, ok := data["Cascade"]; ok {
+			p.Cascade = cast.ToBool(v)
+		}
+	}
+	p.construct(data)
+}
+
+func (p *Parented) Serialize() map[string]any {
+	result := p.Attached.Serialize()
+	if p.Parent != 0 {
+		result["Parent"] = p.Parent.Serialize()
+	}
+	result["Pos"] = p.Pos.Serialize()
+	if p.Angle != 0 {
+		result["Angle"] = p.Angle
+	}
+	if !p.InheritAngle {
+		result["InheritAngle"] = p.InheritAngle
+	}
+	if p.FollowSegment {
+		result["FollowSegment"] = p.FollowSegment
+	}
+	if p.SegmentA != (concepts.Vector2{}) {
+		result["SegmentA"] = p.SegmentA.Serialize()
+	}
+	if p.SegmentB != (concepts.Vector2{}) {
+		result["SegmentB"] = p.SegmentB.Serialize()
+	}
+	if p.Cascade {
+		result["Cascade"] = p.Cascade
+	}
+	p.serialize(result)
+	return result
+}
+
+func (s *SectorPlane) Construct(data map[string]any) {
+	s.Z.Construct(nil)
+	s.Target = 0
+	s.Surface.Construct(nil)
+	s.Scripts = nil
+	s.Ignore = false
+	if data != nil {
+		if v, ok := data["Z"]; ok {
+			s.Z.Construct(v)
+		}
+		if v, ok := data["Target"]; ok {
+			s.Target, _ = ecs.ParseEntity(cast.ToString(v))
+		}
+		if v, ok := data["Surface"]; ok {
+			if m, ok := v.(map[string]any); ok {
+				s.Surface.Construct(m)
+			}
+		}
+		if v, ok := data["Scripts"]; ok {
+			s.Scripts = ecs.ConstructSlice[*Script](v, nil)
+		}
+		if v, ok := data["Ignore"]; ok {
+			s.Ignore = cast.ToBool(v)
+		}
+	}
+	s.construct(data)
+}
+
+func (s *SectorPlane) Serialize() map[string]any {
+	result := make(map[string]any)
+	result["Z"] = s.Z.Serialize()
+	if s.Target != 0 {
+		result["Target"] = s.Target.Serialize()
+	}
+	result["Surface"] = s.Surface.Serialize()
+	if len(s.Scripts) != 0 {
+		result["Scripts"] = ecs.SerializeSlice(s.Scripts)
+	}
+	if s.Ignore {
+		result["Ignore"] = s.Ignore
+	}
+	s.serialize(result)
+	return result
+}
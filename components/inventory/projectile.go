@@ -0,0 +1,24 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package inventory
+
+import (
+	"tlyakhov/gofoom/ecs"
+)
+
+// Projectile is attached to projectiles fired by a weapon with a
+// WeaponClassProjectile. When one hits something, the Damage and Hit scripts
+// of the weapon's class are run, like for instant weapons.
+//
+//ecs:serialize
+type Projectile struct {
+	ecs.Attached `editable:"^"`
+
+	Weapon ecs.Entity `editable:"Weapon"`
+	Damage float64    `editable:"Damage"`
+}
+
+func (p *Projectile) String() string {
+	return "Projectile from " + p.Weapon.ShortString()
+}
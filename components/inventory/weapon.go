@@ -14,6 +14,8 @@ const (
 	WeaponHeld WeaponIntent = iota
 	WeaponFire
 	WeaponHolstered
+	WeaponFireAlt
+	WeaponReload
 )
 
 // Weapon represents the state for a weapon actually held by a player or NPC.
@@ -25,6 +27,8 @@ type Weapon struct {
 	Intent             WeaponIntent `editable:"Intent"`
 	LastStateTimestamp int64        // in ns
 	Fired              bool
+	// True if the current shot is an alt-fire (see WeaponClass.Fire)
+	Alt bool
 }
 
 func (w *Weapon) String() string {
@@ -4,12 +4,23 @@
 package inventory
 
 import (
+	"tlyakhov/gofoom/components/core"
 	"tlyakhov/gofoom/ecs"
 
 	"github.com/spf13/cast"
 )
 
-// TODO: Implement scripting
+// WeaponClass is shared by all weapons of the same kind. What the weapon
+// shoots is defined by WeaponClassInstant/WeaponClassProjectile, and the
+// scripts below can change how it behaves (see controllers/weapon_script.go
+// for the variables they get):
+//   - Fire runs instead of the default shot. Calling shoot(angle, pitch) fires
+//     one shot offset by the angles, so a shotgun is a loop.
+//   - Damage runs when an instant shot hits something, and can change
+//     *damage before it's applied.
+//   - Hit runs after an instant shot has hit something.
+//   - Reload runs when a reload finishes.
+//   - StateChange runs whenever the weapon changes state.
 type WeaponClass struct {
 	ecs.Attached `editable:"^"`
 
@@ -17,6 +28,12 @@ type WeaponClass struct {
 	Params [WeaponStateCount]WeaponStateParams `editable:"Params"`
 
 	FlashMaterial ecs.Entity `editable:"Flash Material" edit_type:"Material"`
+
+	Fire        core.Script `editable:"Fire"`
+	Damage      core.Script `editable:"Damage"`
+	Hit         core.Script `editable:"Hit"`
+	Reload      core.Script `editable:"Reload"`
+	StateChange core.Script `editable:"State Change"`
 }
 
 func (w *WeaponClass) Shareable() bool { return true }
@@ -32,6 +49,9 @@ func (w *WeaponClass) Construct(data map[string]any) {
 	for i := range WeaponStateCount {
 		w.Params[i].Construct(nil)
 	}
+	for _, script := range w.Scripts() {
+		script.Construct(nil)
+	}
 
 	if data == nil {
 		return
@@ -52,6 +72,11 @@ func (w *WeaponClass) Construct(data map[string]any) {
 		w.FlashMaterial, _ = ecs.ParseEntity(v.(string))
 	}
 
+	for name, script := range w.Scripts() {
+		if v, ok := data[name]; ok {
+			script.Construct(v.(map[string]any))
+		}
+	}
 }
 
 func (w *WeaponClass) Serialize() map[string]any {
@@ -69,5 +94,22 @@ func (w *WeaponClass) Serialize() map[string]any {
 		result["FlashMaterial"] = w.FlashMaterial.Serialize()
 	}
 
+	for name, script := range w.Scripts() {
+		if !script.IsEmpty() {
+			result[name] = script.Serialize()
+		}
+	}
+
 	return result
 }
+
+// Scripts returns the weapon's scripts by field name.
+func (w *WeaponClass) Scripts() map[string]*core.Script {
+	return map[string]*core.Script{
+		"Fire":        &w.Fire,
+		"Damage":      &w.Damage,
+		"Hit":         &w.Hit,
+		"Reload":      &w.Reload,
+		"StateChange": &w.StateChange,
+	}
+}
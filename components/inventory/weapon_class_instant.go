@@ -5,14 +5,13 @@ package inventory
 
 import (
 	"tlyakhov/gofoom/ecs"
-
-	"github.com/spf13/cast"
 )
 
+//ecs:serialize
 type WeaponClassInstant struct {
 	ecs.Attached `editable:"^"`
 
-	Damage float64 `editable:"Damage"`
+	Damage float64 `editable:"Damage" default:"10"`
 }
 
 func (w *WeaponClassInstant) Shareable() bool { return true }
@@ -20,24 +19,3 @@ func (w *WeaponClassInstant) Shareable() bool { return true }
 func (w *WeaponClassInstant) String() string {
 	return "WeaponClassInstant"
 }
-
-func (w *WeaponClassInstant) Construct(data map[string]any) {
-	w.Attached.Construct(data)
-	w.Damage = 10
-
-	if data == nil {
-		return
-	}
-
-	if v, ok := data["Damage"]; ok {
-		w.Damage = cast.ToFloat64(v)
-	}
-}
-
-func (w *WeaponClassInstant) Serialize() map[string]any {
-	result := w.Attached.Serialize()
-
-	result["Damage"] = w.Damage
-
-	return result
-}
@@ -14,6 +14,8 @@ type WeaponClassProjectile struct {
 
 	Projectile ecs.Entity `editable:"Projectile" edit_type:"Spawner"`
 	Speed      float64    `editable:"Speed"`
+	// Damage dealt when a projectile hits something, see Projectile.
+	Damage float64 `editable:"Damage"`
 }
 
 func (w *WeaponClassProjectile) Shareable() bool { return true }
@@ -26,6 +28,7 @@ func (w *WeaponClassProjectile) Construct(data map[string]any) {
 	w.Attached.Construct(data)
 	w.Projectile = 0
 	w.Speed = 25
+	w.Damage = 0
 
 	if data == nil {
 		return
@@ -37,11 +40,17 @@ func (w *WeaponClassProjectile) Construct(data map[string]any) {
 	if v, ok := data["Speed"]; ok {
 		w.Speed = cast.ToFloat64(v)
 	}
+	if v, ok := data["Damage"]; ok {
+		w.Damage = cast.ToFloat64(v)
+	}
 }
 
 func (w *WeaponClassProjectile) Serialize() map[string]any {
 	result := w.Attached.Serialize()
 	result["Speed"] = w.Speed
+	if w.Damage != 0 {
+		result["Damage"] = w.Damage
+	}
 
 	if w.Projectile != 0 {
 		result["Projectile"] = w.Projectile.Serialize()
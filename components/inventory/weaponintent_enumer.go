@@ -8,11 +8,11 @@ import (
 	"strings"
 )
 
-const _WeaponIntentName = "WeaponHeldWeaponFireWeaponHolstered"
+const _WeaponIntentName = "WeaponHeldWeaponFireWeaponHolsteredWeaponFireAltWeaponReload"
 
-var _WeaponIntentIndex = [...]uint8{0, 10, 20, 35}
+var _WeaponIntentIndex = [...]uint8{0, 10, 20, 35, 48, 60}
 
-const _WeaponIntentLowerName = "weaponheldweaponfireweaponholstered"
+const _WeaponIntentLowerName = "weaponheldweaponfireweaponholsteredweaponfirealtweaponreload"
 
 func (i WeaponIntent) String() string {
 	if i < 0 || i >= WeaponIntent(len(_WeaponIntentIndex)-1) {
@@ -28,9 +28,11 @@ func _WeaponIntentNoOp() {
 	_ = x[WeaponHeld-(0)]
 	_ = x[WeaponFire-(1)]
 	_ = x[WeaponHolstered-(2)]
+	_ = x[WeaponFireAlt-(3)]
+	_ = x[WeaponReload-(4)]
 }
 
-var _WeaponIntentValues = []WeaponIntent{WeaponHeld, WeaponFire, WeaponHolstered}
+var _WeaponIntentValues = []WeaponIntent{WeaponHeld, WeaponFire, WeaponHolstered, WeaponFireAlt, WeaponReload}
 
 var _WeaponIntentNameToValueMap = map[string]WeaponIntent{
 	_WeaponIntentName[0:10]:       WeaponHeld,
@@ -39,12 +41,18 @@ var _WeaponIntentNameToValueMap = map[string]WeaponIntent{
 	_WeaponIntentLowerName[10:20]: WeaponFire,
 	_WeaponIntentName[20:35]:      WeaponHolstered,
 	_WeaponIntentLowerName[20:35]: WeaponHolstered,
+	_WeaponIntentName[35:48]:      WeaponFireAlt,
+	_WeaponIntentLowerName[35:48]: WeaponFireAlt,
+	_WeaponIntentName[48:60]:      WeaponReload,
+	_WeaponIntentLowerName[48:60]: WeaponReload,
 }
 
 var _WeaponIntentNames = []string{
 	_WeaponIntentName[0:10],
 	_WeaponIntentName[10:20],
 	_WeaponIntentName[20:35],
+	_WeaponIntentName[35:48],
+	_WeaponIntentName[48:60],
 }
 
 // WeaponIntentString retrieves an enum value from the enum constants string name.
@@ -1,10 +1,14 @@
 // Code generated by ecs/cmd/gofoom_ecs_generator. DO NOT EDIT.
 package inventory
 
-import "tlyakhov/gofoom/ecs"
+import (
+	"github.com/spf13/cast"
+	"tlyakhov/gofoom/ecs"
+)
 
 var CarrierCID ecs.ComponentID
 var ItemCID ecs.ComponentID
+var ProjectileCID ecs.ComponentID
 var SlotCID ecs.ComponentID
 var WeaponCID ecs.ComponentID
 var WeaponClassCID ecs.ComponentID
@@ -14,6 +18,7 @@ var WeaponClassProjectileCID ecs.ComponentID
 func init() {
 	CarrierCID = ecs.RegisterComponent(&ecs.Arena[Carrier, *Carrier]{})
 	ItemCID = ecs.RegisterComponent(&ecs.Arena[Item, *Item]{})
+	ProjectileCID = ecs.RegisterComponent(&ecs.Arena[Projectile, *Projectile]{})
 	SlotCID = ecs.RegisterComponent(&ecs.Arena[Slot, *Slot]{})
 	WeaponCID = ecs.RegisterComponent(&ecs.Arena[Weapon, *Weapon]{})
 	WeaponClassCID = ecs.RegisterComponent(&ecs.Arena[WeaponClass, *WeaponClass]{})
@@ -40,6 +45,16 @@ func GetItem(e ecs.Entity) *Item {
 func (*Item) ComponentID() ecs.ComponentID {
 	return ItemCID
 }
+func GetProjectile(e ecs.Entity) *Projectile {
+	if asserted, ok := ecs.GetComponent(e, ProjectileCID).(*Projectile); ok {
+		return asserted
+	}
+	return nil
+}
+
+func (*Projectile) ComponentID() ecs.ComponentID {
+	return ProjectileCID
+}
 func GetSlot(e ecs.Entity) *Slot {
 	if asserted, ok := ecs.GetComponent(e, SlotCID).(*Slot); ok {
 		return asserted
@@ -90,3 +105,46 @@ func GetWeaponClassProjectile(e ecs.Entity) *WeaponClassProjectile {
 func (*WeaponClassProjectile) ComponentID() ecs.ComponentID {
 	return WeaponClassProjectileCID
 }
+
+func (p *Projectile) Construct(data map[string]any) {
+	p.Attached.Construct(data)
+	p.Weapon = 0
+	p.Damage = 0
+	if data != nil {
+		if v, ok := data["Weapon"]; ok {
+			p.Weapon, _ = ecs.ParseEntity(cast.ToString(v))
+		}
+		if v, ok := data["Damage"]; ok {
+			p.Damage = cast.ToFloat64(v)
+		}
+	}
+}
+
+func (p *Projectile) Serialize() map[string]any {
+	result := p.Attached.Serialize()
+	if p.Weapon != 0 {
+		result["Weapon"] = p.Weapon.Serialize()
+	}
+	if p.Damage != 0 {
+		result["Damage"] = p.Damage
+	}
+	return result
+}
+
+func (w *WeaponClassInstant) Construct(data map[string]any) {
+	w.Attached.Construct(data)
+	w.Damage = 10
+	if data != nil {
+		if v, ok := data["Damage"]; ok {
+			w.Damage = cast.ToFloat64(v)
+		}
+	}
+}
+
+func (w *WeaponClassInstant) Serialize() map[string]any {
+	result := w.Attached.Serialize()
+	if w.Damage != 10 {
+		result["Damage"] = w.Damage
+	}
+	return result
+}
@@ -7,6 +7,7 @@ import (
 	"tlyakhov/gofoom/ecs"
 )
 
+//ecs:serialize
 type Shader struct {
 	ecs.Attached `editable:"^"`
 
@@ -14,24 +15,3 @@ type Shader struct {
 }
 
 func (s *Shader) Shareable() bool { return true }
-
-func (s *Shader) Construct(data map[string]any) {
-	s.Attached.Construct(data)
-
-	s.Stages = make([]*ShaderStage, 0)
-
-	if data == nil {
-		return
-	}
-
-	if v, ok := data["Stages"]; ok {
-		s.Stages = ecs.ConstructSlice[*ShaderStage](v, nil)
-	}
-}
-
-func (s *Shader) Serialize() map[string]any {
-	result := s.Attached.Serialize()
-
-	result["Stages"] = ecs.SerializeSlice(s.Stages)
-	return result
-}
@@ -9,6 +9,7 @@ import (
 	"tlyakhov/gofoom/ecs"
 )
 
+//ecs:serialize
 type Solid struct {
 	ecs.Attached `editable:"^"`
 	Diffuse      dynamic.DynamicValue[concepts.Vector4] `editable:"Color"`
@@ -26,22 +27,3 @@ func (s *Solid) OnAttach() {
 	s.Attached.OnAttach()
 	s.Diffuse.Attach(ecs.Simulation)
 }
-
-func (s *Solid) Construct(data map[string]any) {
-	s.Attached.Construct(data)
-	s.Diffuse.Construct(nil)
-
-	if data == nil {
-		return
-	}
-
-	if v, ok := data["Diffuse"]; ok {
-		s.Diffuse.Construct(v)
-	}
-}
-
-func (s *Solid) Serialize() map[string]any {
-	result := s.Attached.Serialize()
-	result["Diffuse"] = s.Diffuse.Serialize()
-	return result
-}
@@ -28,6 +28,17 @@ type Visible struct {
 	PixelOnly bool           `editable:"Pixel only?"`
 }
 
+func init() {
+	// Opacity used to be saved under the wrong name.
+	ecs.RegisterMigration[Visible](0, "Rename Ambient to Opacity", false, func(data map[string]any) error {
+		if v, ok := data["Ambient"]; ok {
+			data["Opacity"] = v
+			delete(data, "Ambient")
+		}
+		return nil
+	})
+}
+
 func (v *Visible) Shareable() bool { return true }
 
 func (v *Visible) String() string {
@@ -63,7 +74,9 @@ func (m *Visible) Construct(data map[string]any) {
 
 func (v *Visible) Serialize() map[string]any {
 	result := v.Attached.Serialize()
-	result["Ambient"] = v.Opacity
+	if v.Opacity != 1 {
+		result["Opacity"] = v.Opacity
+	}
 	if v.Shadow != ShadowNone {
 		result["Shadow"] = v.Shadow.String()
 	}
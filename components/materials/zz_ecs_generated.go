@@ -1,7 +1,10 @@
 // Code generated by ecs/cmd/gofoom_ecs_generator. DO NOT EDIT.
 package materials
 
-import "tlyakhov/gofoom/ecs"
+import (
+	"github.com/spf13/cast"
+	"tlyakhov/gofoom/ecs"
+)
 
 var ImageCID ecs.ComponentID
 var LitCID ecs.ComponentID
@@ -123,3 +126,63 @@ func GetVisible(e ecs.Entity) *Visible {
 func (*Visible) ComponentID() ecs.ComponentID {
 	return VisibleCID
 }
+
+func (ma *MarkMaker) Construct(data map[string]any) {
+	ma.Attached.Construct(data)
+	ma.Material = 0
+	ma.Size = 5
+	if data != nil {
+		if v, ok := data["Material"]; ok {
+			ma.Material, _ = ecs.ParseEntity(cast.ToString(v))
+		}
+		if v, ok := data["Size"]; ok {
+			ma.Size = cast.ToFloat64(v)
+		}
+	}
+	ma.construct(data)
+}
+
+func (ma *MarkMaker) Serialize() map[string]any {
+	result := ma.Attached.Serialize()
+	if ma.Material != 0 {
+		result["Material"] = ma.Material.Serialize()
+	}
+	if ma.Size != 5 {
+		result["Size"] = ma.Size
+	}
+	return result
+}
+
+func (s *Shader) Construct(data map[string]any) {
+	s.Attached.Construct(data)
+	s.Stages = nil
+	if data != nil {
+		if v, ok := data["Stages"]; ok {
+			s.Stages = ecs.ConstructSlice[*ShaderStage](v, nil)
+		}
+	}
+}
+
+func (s *Shader) Serialize() map[string]any {
+	result := s.Attached.Serialize()
+	if len(s.Stages) != 0 {
+		result["Stages"] = ecs.SerializeSlice(s.Stages)
+	}
+	return result
+}
+
+func (s *Solid) Construct(data map[string]any) {
+	s.Attached.Construct(data)
+	s.Diffuse.Construct(nil)
+	if data != nil {
+		if v, ok := data["Diffuse"]; ok {
+			s.Diffuse.Construct(v)
+		}
+	}
+}
+
+func (s *Solid) Serialize() map[string]any {
+	result := s.Attached.Serialize()
+	result["Diffuse"] = s.Diffuse.Serialize()
+	return result
+}
@@ -68,6 +68,7 @@ const (
 
 	// Game constants
 	UserSettings    = "settings.json"
+	QuickSavePath   = "quicksave.yaml"
 	DefaultFontPath = "../gofoom-data/fonts/vga-font-8x8.png"
 	TestWorldPath   = "../gofoom-data/worlds/pursuer-test.yaml"
 
@@ -0,0 +1,61 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"testing"
+
+	"tlyakhov/gofoom/components/behaviors"
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/components/materials"
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/ecs"
+)
+
+// createTestCrowd adds static bodies and long-lived particles to a world, to
+// measure per-frame controller overhead on large levels.
+func createTestCrowd(bodies, particles int) {
+	for i := range bodies + particles {
+		e := ecs.NewEntity()
+		body := ecs.NewAttachedComponent(e, core.BodyCID).(*core.Body)
+		body.Pos.Spawn = concepts.Vector3{float64(i%180) - 90, float64(i/180%180) - 90, 10}
+		body.Pos.ResetToSpawn()
+		if i < bodies {
+			continue
+		}
+		ecs.NewAttachedComponent(e, materials.VisibleCID)
+		eph := ecs.NewAttachedComponent(e, behaviors.EphemeralCID).(*behaviors.Ephemeral)
+		eph.Lifetime = 1e12
+	}
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+}
+
+// Controllers that declare their access shouldn't touch anything else. Run
+// with -race to also check the parallel schedule.
+func TestControllerAccess(t *testing.T) {
+	ecs.Initialize()
+	ecs.Simulation.SetSeed(1)
+	CreateTestWorld2()
+	createTestCrowd(100, 100)
+	RespawnAll()
+	RunHeadless(10)
+
+	ecs.DebugControllerAccess = true
+	defer func() { ecs.DebugControllerAccess = false }()
+	RunHeadless(10)
+	if accesses := ecs.UndeclaredControllerAccesses(); len(accesses) > 0 {
+		t.Errorf("Found undeclared component accesses: %v", accesses)
+	}
+}
+
+func BenchmarkActAllControllers(b *testing.B) {
+	ecs.Initialize()
+	ecs.Simulation.SetSeed(1)
+	CreateTestWorld2()
+	createTestCrowd(5000, 5000)
+	b.ResetTimer()
+	for b.Loop() {
+		ecs.ActAllControllers(ecs.ControllerFrame)
+	}
+}
@@ -5,7 +5,6 @@ package controllers
 
 import (
 	"math"
-	"math/rand"
 	"tlyakhov/gofoom/components/behaviors"
 	"tlyakhov/gofoom/components/core"
 	"tlyakhov/gofoom/components/inventory"
@@ -120,15 +119,44 @@ func (ac *ActionController) Precompute() {
 
 	if closest != nil {
 		ac.State.Action = closest.Entity
-		ac.State.LastTransition = ecs.Simulation.SimTimestamp
+		ac.scheduleDelay()
 	}
 }
 
+// actionTimed returns the timing of the behavior that's used for an action,
+// if any. Some actions combine several behaviors, in which case the last one
+// Frame acts on wins.
+func (ac *ActionController) actionTimed(action ecs.Entity) *behaviors.ActionTimed {
+	if fire := behaviors.GetActionFire(action); fire != nil {
+		return &fire.ActionTimed
+	}
+	if jump := behaviors.GetActionJump(action); jump != nil {
+		return &jump.ActionTimed
+	}
+	waypoint := behaviors.GetActionWaypoint(action)
+	if face := behaviors.GetActionFace(action); face != nil && (waypoint == nil || !ac.FaceNextWaypoint) {
+		return &face.ActionTimed
+	}
+	if waypoint != nil {
+		return &waypoint.ActionTimed
+	}
+	return nil
+}
+
+// scheduleDelay starts counting down the delay of the current action.
+func (ac *ActionController) scheduleDelay() {
+	var delay float64
+	if timed := ac.actionTimed(ac.State.Action); timed != nil {
+		delay = timed.Delay.Now
+	}
+	ac.State.Delay.Schedule(ecs.Simulation, concepts.MillisToNanos(delay), 0)
+}
+
 func (ac *ActionController) timedAction(timed *behaviors.ActionTimed) timedState {
 	if timed.Fired.Contains(ac.Entity) {
 		return timedFired
 	}
-	if ecs.Simulation.SimTimestamp-ac.State.LastTransition < concepts.MillisToNanos(timed.Delay.Now) {
+	if !ac.State.Delay.Expired() {
 		return timedDelayed
 	}
 
@@ -230,50 +258,44 @@ func (ac *ActionController) Frame() {
 
 	if ac.State.Action == 0 {
 		ac.State.Action = ac.Start
+		ac.scheduleDelay()
 	}
 
 	doTransition := true
 	ignoreFace := false
 
-	var timed *behaviors.ActionTimed
-
 	if waypoint := behaviors.GetActionWaypoint(ac.State.Action); waypoint != nil {
 		// Order of ops matters - the && short circuits on false
 		doTransition = ac.Waypoint(waypoint) && doTransition
-		timed = &waypoint.ActionTimed
 		ignoreFace = ac.FaceNextWaypoint
 	}
 
 	if face := behaviors.GetActionFace(ac.State.Action); face != nil && !ignoreFace {
 		// Order of ops matters - the && short circuits on false
 		doTransition = ac.Face(face) && doTransition
-		timed = &face.ActionTimed
 	}
 
 	if jump := behaviors.GetActionJump(ac.State.Action); jump != nil {
 		// Order of ops matters - the && short circuits on false
 		doTransition = ac.Jump(jump) && doTransition
-		timed = &jump.ActionTimed
 	}
 
 	if fire := behaviors.GetActionFire(ac.State.Action); fire != nil {
 		// Order of ops matters - the && short circuits on false
 		doTransition = ac.Fire(fire) && doTransition
-		timed = &fire.ActionTimed
 	}
 
 	if !doTransition {
 		return
 	}
 
-	if timed != nil {
+	if timed := ac.actionTimed(ac.State.Action); timed != nil {
 		timed.Fired.Delete(ac.Entity)
 	}
 
 	if t := behaviors.GetActionTransition(ac.State.Action); t != nil {
-		ac.State.LastTransition = ecs.Simulation.SimTimestamp
 		if len(t.Next) > 0 {
-			i := rand.Intn(len(t.Next))
+			i := ecs.Simulation.Rand.IntN(len(t.Next))
 			for _, next := range t.Next {
 				ac.State.Action = next
 				if i <= 0 {
@@ -291,5 +313,6 @@ func (ac *ActionController) Frame() {
 			case dynamic.AnimationLifetimeBounceOnce:
 			}
 		}
+		ac.scheduleDelay()
 	}
 }
@@ -46,7 +46,7 @@ func (ac *ActionController) repath(start, end concepts.Vector3) {
 		ac.State.Finder.MountHeight = ac.Mobile.MountHeight
 	}
 	if ac.Body != nil {
-		ac.State.Finder.Radius = ac.Body.Size.Now[0] * 0.5
+		ac.State.Finder.Radius = ac.Body.CollisionSize.Now[0] * 0.5
 	} else if ac.Sector != nil {
 		ac.State.Finder.Radius = max(ac.Sector.Max[0]-ac.Sector.Min[0], ac.Sector.Max[1]-ac.Sector.Min[1]) * 0.5
 	}
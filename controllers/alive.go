@@ -40,10 +40,12 @@ var aliveScriptParams = []core.ScriptParam{
 func (a *AliveController) Precompute() {
 	if !a.Die.IsEmpty() {
 		a.Die.Params = aliveScriptParams
+		a.Die.SetOwner(a.Entity, a.Alive, "Alive.Die")
 		a.Die.Compile()
 	}
 	if !a.Live.IsEmpty() {
 		a.Live.Params = aliveScriptParams
+		a.Live.SetOwner(a.Entity, a.Alive, "Alive.Live")
 		a.Live.Compile()
 	}
 }
@@ -59,6 +61,12 @@ func (a *AliveController) Frame() {
 		if d.Amount > 0 {
 			if a.Health.Now > 0 {
 				a.Health.Now -= d.Amount
+				EventDamaged.Publish(a.Entity, &DamageEventParams{
+					Entity: a.Entity,
+					Source: source,
+					Amount: d.Amount,
+					Health: a.Health.Now,
+				})
 			}
 			d.Amount = 0
 		}
@@ -84,6 +92,7 @@ func (a *AliveController) Frame() {
 			}
 			a.Base().Flags &= ^ecs.ComponentActive
 		}
+		EventDied.Publish(a.Entity, &EntityEventParams{Entity: a.Entity})
 		if a.Die.IsCompiled() {
 			a.Die.Vars["onEntity"] = a.Entity
 			a.Die.Vars["alive"] = a.Alive
@@ -103,6 +112,7 @@ func (a *AliveController) Frame() {
 			}
 			a.Base().Flags |= ecs.ComponentActive
 		}
+		EventRevived.Publish(a.Entity, &EntityEventParams{Entity: a.Entity})
 		if a.Live.IsCompiled() {
 			a.Live.Vars["onEntity"] = a.Entity
 			a.Live.Vars["alive"] = a.Alive
@@ -10,6 +10,7 @@ import (
 	"tlyakhov/gofoom/components/core"
 	"tlyakhov/gofoom/components/inventory"
 	"tlyakhov/gofoom/components/materials"
+	"tlyakhov/gofoom/concepts"
 	"tlyakhov/gofoom/ecs"
 )
 
@@ -17,9 +18,8 @@ func CreateLightBody() ecs.Entity {
 	e := ecs.NewEntity()
 	body := ecs.NewAttachedComponent(e, core.BodyCID).(*core.Body)
 	ecs.NewAttachedComponent(e, core.LightCID)
-	body.Size.Spawn[0] = 2
-	body.Size.Spawn[1] = 2
-	body.Size.ResetToSpawn()
+	body.Size.SetAll(concepts.Vector2{2, 2})
+	body.CollisionSize.SetAll(concepts.Vector2{2, 2})
 
 	return e
 }
@@ -45,6 +45,8 @@ func CreateFont(filename string, name string) ecs.Entity {
 	return e
 }
 
+// EntitiesByClass returns the entities in a broad class (e.g. "Material"), or
+// matching a query (see ecs.FindEntities).
 func EntitiesByClass(c string) ecs.EntityTable {
 	entitySet := make(ecs.EntityTable, 0)
 	cids := make([]ecs.ComponentID, 0)
@@ -64,6 +66,11 @@ func EntitiesByClass(c string) ecs.EntityTable {
 		cids = append(cids, audio.SoundCID)
 	case "Spawner":
 		cids = append(cids, behaviors.SpawnerCID)
+	default:
+		// Anything else is a query, e.g. "tag:reactor component:Light"
+		if found, err := ecs.FindEntities(c); err == nil {
+			return found
+		}
 	}
 	for _, cid := range cids {
 		arena := ecs.ArenaByID(cid)
@@ -40,6 +40,14 @@ func (bc *BodyController) EditorPausedMethods() ecs.ControllerMethod {
 	return ecs.ControllerPrecompute
 }
 
+var bodyAccess = ecs.ControllerAccess{
+	Reads: []ecs.ComponentID{character.PlayerCID, core.SectorCID},
+}
+
+func (bc *BodyController) Access() *ecs.ControllerAccess {
+	return &bodyAccess
+}
+
 func (bc *BodyController) Target(target ecs.Component, e ecs.Entity) bool {
 	bc.Entity = e
 	bc.Body = target.(*core.Body)
@@ -50,7 +58,7 @@ func (bc *BodyController) Target(target ecs.Component, e ecs.Entity) bool {
 	bc.Sector = bc.Body.Sector()
 	bc.pos = &bc.Pos.Now
 	bc.pos2d = bc.pos.To2D()
-	bc.halfHeight = bc.Size.Now[1] * 0.5
+	bc.halfHeight = bc.CollisionSize.Now[1] * 0.5
 	return true
 }
 
@@ -147,7 +155,7 @@ func (bc *BodyController) Enter(sector *core.Sector) {
 	if bc.Body.OnGround {
 		floorZ := bc.Sector.Bottom.ZAt(bc.Pos.Now.To2D())
 		p := &bc.Pos.Now
-		h := bc.Size.Now[1] * 0.5
+		h := bc.CollisionSize.Now[1] * 0.5
 		if bc.Sector.Bottom.Target == 0 && p[2]-h < floorZ {
 			p[2] = floorZ + h
 		}
@@ -0,0 +1,70 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	_ "tlyakhov/gofoom/components/behaviors"
+	"tlyakhov/gofoom/controllers"
+	"tlyakhov/gofoom/ecs"
+	_ "tlyakhov/gofoom/scripting_symbols"
+
+	"sigs.k8s.io/yaml"
+)
+
+/***
+
+Runs the simulation without a renderer or audio device for a fixed number of
+steps and dumps Body/Alive/Door state as YAML, for comparing against golden
+files. Examples:
+
+go run ./controllers/cmd/gofoom_headless -world ../gofoom-data/worlds/pursuer-test.yaml -steps 1280
+go run ./controllers/cmd/gofoom_headless -test-world 2 -steps 256 -out world2.yaml
+
+***/
+
+var worldPath = flag.String("world", "", "World to load")
+var testWorld = flag.Int("test-world", 0, "Generate a test world instead of loading one (1, 2, or 3, see controllers.CreateTestWorld*)")
+var steps = flag.Int("steps", 128, "Number of simulation steps to run")
+var seed = flag.Uint64("seed", 1, "Random seed for the simulation")
+var outPath = flag.String("out", "", "File to write the state dump to (defaults to stdout)")
+
+func main() {
+	flag.Parse()
+
+	ecs.Initialize()
+	ecs.Simulation.SetSeed(*seed)
+
+	switch {
+	case *worldPath != "":
+		if err := ecs.Load(*worldPath); err != nil {
+			log.Fatalf("Error loading world %v: %v", *worldPath, err)
+		}
+	case *testWorld == 1:
+		controllers.CreateTestWorld()
+	case *testWorld == 2:
+		controllers.CreateTestWorld2()
+	case *testWorld == 3:
+		controllers.CreateTestWorld3()
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	controllers.RespawnAll()
+	controllers.RunHeadless(*steps)
+
+	bytes, err := yaml.Marshal(controllers.DumpState())
+	if err != nil {
+		log.Fatalf("Error serializing state: %v", err)
+	}
+	if *outPath == "" {
+		os.Stdout.Write(bytes)
+		return
+	}
+	if err = os.WriteFile(*outPath, bytes, 0644); err != nil {
+		log.Fatalf("Error writing %v: %v", *outPath, err)
+	}
+}
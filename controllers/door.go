@@ -63,6 +63,7 @@ func (d *DoorController) close() {
 }
 
 func (d *DoorController) Frame() {
+	prevState := d.State
 	switch d.Type {
 	case behaviors.DoorTypeVertical:
 		d.setupVerticalDoorAnimation(false)
@@ -85,6 +86,10 @@ func (d *DoorController) Frame() {
 	case behaviors.DoorTypeSwing:
 		d.checkSwingDoorState()
 	}
+
+	if d.State != prevState {
+		EventDoorState.Publish(d.Entity, &DoorEventParams{Entity: d.Entity, State: d.State})
+	}
 }
 
 func (d *DoorController) Precompute() {
@@ -98,10 +103,12 @@ func (d *DoorController) Precompute() {
 	}
 	if !d.Open.IsEmpty() {
 		d.Open.Params = []core.ScriptParam{{Name: "door", TypeName: "*behaviors.Door"}}
+		d.Open.SetOwner(d.Entity, d.Door, "Door.Open")
 		d.Open.Compile()
 	}
 	if !d.Close.IsEmpty() {
 		d.Close.Params = []core.ScriptParam{{Name: "door", TypeName: "*behaviors.Door"}}
+		d.Close.SetOwner(d.Entity, d.Door, "Door.Close")
 		d.Close.Compile()
 	}
 
@@ -23,6 +23,24 @@ type EntityAxisEventParams struct {
 	AxisValue float64
 }
 
+type DamageEventParams struct {
+	Entity ecs.Entity
+	Source string
+	Amount float64
+	// Health after the damage was applied
+	Health float64
+}
+
+type DoorEventParams struct {
+	Entity ecs.Entity
+	State  behaviors.DoorState
+}
+
+type ProximityEventParams struct {
+	Source ecs.Entity
+	Target ecs.Entity
+}
+
 var (
 	EventIdForward         = dynamic.RegisterEventClass(&dynamic.EventClass{Name: "Forward"})
 	EventIdBack            = dynamic.RegisterEventClass(&dynamic.EventClass{Name: "Back"})
@@ -36,9 +54,26 @@ var (
 	EventIdDown            = dynamic.RegisterEventClass(&dynamic.EventClass{Name: "Down"})
 	EventIdPrimaryAction   = dynamic.RegisterEventClass(&dynamic.EventClass{Name: "PrimaryAction"})
 	EventIdSecondaryAction = dynamic.RegisterEventClass(&dynamic.EventClass{Name: "SecondaryAction"})
+	EventIdAltFire         = dynamic.RegisterEventClass(&dynamic.EventClass{Name: "AltFire"})
+	EventIdReload          = dynamic.RegisterEventClass(&dynamic.EventClass{Name: "Reload"})
+)
+
+// Gameplay events. These target the entity they happened to, so scripts can
+// subscribe to a specific door or enemy (see ecs.EventType.SubscribeEntity).
+var (
+	EventDamaged        = ecs.NewEventType[DamageEventParams]("Damaged")
+	EventDied           = ecs.NewEventType[EntityEventParams]("Died")
+	EventRevived        = ecs.NewEventType[EntityEventParams]("Revived")
+	EventDoorState      = ecs.NewEventType[DoorEventParams]("DoorState")
+	EventProximityEnter = ecs.NewEventType[ProximityEventParams]("ProximityEnter")
+	EventProximityExit  = ecs.NewEventType[ProximityEventParams]("ProximityExit")
 )
 
 func init() {
+	// So that demos can save & load these
+	dynamic.RegisterEventData(&EntityEventParams{})
+	dynamic.RegisterEventData(&EntityAxisEventParams{})
+
 	dynamic.SubscribeToEvent(EventIdForward, eventMove)
 	dynamic.SubscribeToEvent(EventIdBack, eventMove)
 	dynamic.SubscribeToEvent(EventIdLeft, eventMove)
@@ -49,6 +84,8 @@ func init() {
 	dynamic.SubscribeToEvent(EventIdDown, eventDown)
 	dynamic.SubscribeToEvent(EventIdPrimaryAction, eventPrimaryAction)
 	dynamic.SubscribeToEvent(EventIdSecondaryAction, eventSecondaryAction)
+	dynamic.SubscribeToEvent(EventIdAltFire, eventAltFire)
+	dynamic.SubscribeToEvent(EventIdReload, eventReload)
 	dynamic.SubscribeToEvent(EventIdYaw, eventYaw)
 	dynamic.SubscribeToEvent(EventIdPitch, eventPitch)
 }
@@ -121,17 +158,31 @@ func eventDown(evt *dynamic.Event) bool {
 	return false
 }
 
-func eventPrimaryAction(evt *dynamic.Event) bool {
-	p := evt.Data.(*EntityEventParams)
-	carrier := inventory.GetCarrier(p.Entity)
+// setWeaponIntent sets the intent of the weapon an entity has selected.
+func setWeaponIntent(e ecs.Entity, intent inventory.WeaponIntent) {
+	carrier := inventory.GetCarrier(e)
 	if carrier == nil {
-		return false
+		return
 	}
 	if carrier.SelectedWeapon != 0 {
 		if w := inventory.GetWeapon(carrier.SelectedWeapon); w != nil {
-			w.Intent = inventory.WeaponFire
+			w.Intent = intent
 		}
 	}
+}
+
+func eventPrimaryAction(evt *dynamic.Event) bool {
+	setWeaponIntent(evt.Data.(*EntityEventParams).Entity, inventory.WeaponFire)
+	return false
+}
+
+func eventAltFire(evt *dynamic.Event) bool {
+	setWeaponIntent(evt.Data.(*EntityEventParams).Entity, inventory.WeaponFireAlt)
+	return false
+}
+
+func eventReload(evt *dynamic.Event) bool {
+	setWeaponIntent(evt.Data.(*EntityEventParams).Entity, inventory.WeaponReload)
 	return false
 }
 
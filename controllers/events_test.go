@@ -0,0 +1,86 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"testing"
+
+	"tlyakhov/gofoom/components/behaviors"
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/dynamic"
+	"tlyakhov/gofoom/ecs"
+)
+
+func TestGameplayEvents(t *testing.T) {
+	ecs.Initialize()
+	CreateTestWorld2()
+	e, _ := newTestBody(concepts.Vector3{50, 50, 10}, 0)
+	alive := ecs.NewAttachedComponent(e, behaviors.AliveCID).(*behaviors.Alive)
+	alive.Health.SetAll(10)
+	other, _ := newTestBody(concepts.Vector3{60, 50, 10}, 0)
+	ecs.NewAttachedComponent(other, behaviors.AliveCID)
+
+	var damage []DamageEventParams
+	died := 0
+	EventDamaged.SubscribeEntity(e, dynamic.DefaultEventPriority, func(evt *dynamic.Event, p *DamageEventParams) bool {
+		damage = append(damage, *p)
+		return false
+	})
+	sub := EventDied.Subscribe(dynamic.DefaultEventPriority, func(evt *dynamic.Event, p *EntityEventParams) bool {
+		if p.Entity == e {
+			died++
+		}
+		return false
+	})
+	defer dynamic.Unsubscribe(sub)
+
+	behaviors.GetAlive(other).Hurt("test", 5, 2)
+	alive.Hurt("test", 15, 2)
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+	RunHeadless(2)
+
+	if len(damage) != 1 || damage[0].Amount != 15 || damage[0].Health != -5 || damage[0].Source != "test" {
+		t.Errorf("Expected one damage event for the target entity, got %+v", damage)
+	}
+	if died != 1 {
+		t.Errorf("Expected one death event, got %v", died)
+	}
+
+	// Deleting the entity should remove its subscriptions.
+	ecs.Delete(e)
+	e2, _ := newTestBody(concepts.Vector3{50, 50, 10}, 0)
+	if e2 != e {
+		t.Skipf("Entity %v wasn't reused", e)
+	}
+	alive = ecs.NewAttachedComponent(e2, behaviors.AliveCID).(*behaviors.Alive)
+	alive.Hurt("test", 1, 2)
+	RunHeadless(1)
+	if len(damage) != 1 {
+		t.Errorf("Subscription should have been removed with the entity, got %+v", damage)
+	}
+}
+
+func TestDoorEvents(t *testing.T) {
+	ecs.Initialize()
+	CreateTestWorld2()
+	sector := core.GetSector(ecs.GetEntityByName("sector1"))
+	door := ecs.NewAttachedComponent(sector.Entity, behaviors.DoorCID).(*behaviors.Door)
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+
+	var states []behaviors.DoorState
+	EventDoorState.SubscribeEntity(sector.Entity, dynamic.DefaultEventPriority, func(evt *dynamic.Event, p *DoorEventParams) bool {
+		states = append(states, p.State)
+		return false
+	})
+
+	door.Intent = behaviors.DoorIntentClosed
+	RunHeadless(200)
+	if len(states) < 2 || states[0] != behaviors.DoorStateClosing || states[len(states)-1] != behaviors.DoorStateClosed {
+		t.Errorf("Expected the door to close, got %v", states)
+	}
+	if door.State != behaviors.DoorStateClosed {
+		t.Errorf("Expected the door state to be closed, got %v", door.State)
+	}
+}
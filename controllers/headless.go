@@ -0,0 +1,77 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"strconv"
+	"tlyakhov/gofoom/components/behaviors"
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/ecs"
+)
+
+// Precision used when dumping floating point state. Golden files need to be
+// stable across platforms, so we don't want every last bit.
+const headlessPrecision = 3
+
+// RunHeadless runs all frame controllers for a fixed number of simulation
+// steps, without a renderer or audio device.
+func RunHeadless(steps int) {
+	integrate := ecs.Simulation.Integrate
+	background := ecs.StreamInBackground
+	defer func() {
+		ecs.Simulation.Integrate = integrate
+		ecs.StreamInBackground = background
+	}()
+	// Streamed files have to load on the same frame every time.
+	ecs.StreamInBackground = false
+	ecs.Simulation.Integrate = func() {
+		ecs.ActAllControllers(ecs.ControllerFrame)
+	}
+	for range steps {
+		ecs.Simulation.Tick()
+	}
+}
+
+func headlessFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', headlessPrecision, 64)
+}
+
+// DumpState returns a YAML-friendly snapshot of a few key components (Bodies,
+// Alive, and Doors) for every entity. Unlike ecs.SaveSnapshot, it only
+// includes runtime state that's interesting for regression tests.
+func DumpState() []map[string]any {
+	result := make([]map[string]any, 0)
+	ecs.Entities.Range(func(entity uint32) {
+		e := ecs.Entity(entity)
+		if e == 0 {
+			return
+		}
+		state := make(map[string]any)
+		if b := core.GetBody(e); b != nil {
+			state["Body"] = map[string]any{
+				"Pos":      b.Pos.Now.StringHuman(headlessPrecision),
+				"Angle":    headlessFloat(b.Angle.Now),
+				"Sector":   b.SectorEntity.Serialize(),
+				"OnGround": b.OnGround,
+			}
+		}
+		if a := behaviors.GetAlive(e); a != nil {
+			state["Alive"] = map[string]any{
+				"Health": headlessFloat(a.Health.Now),
+			}
+		}
+		if d := behaviors.GetDoor(e); d != nil {
+			state["Door"] = map[string]any{
+				"State":  d.State.String(),
+				"Intent": d.Intent.String(),
+			}
+		}
+		if len(state) == 0 {
+			return
+		}
+		state["Entity"] = e.Serialize()
+		result = append(result, state)
+	})
+	return result
+}
@@ -0,0 +1,143 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tlyakhov/gofoom/components/behaviors"
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/components/inventory"
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/ecs"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Run `go test ./controllers -run TestHeadlessGolden -update` to regenerate
+// the golden files after an intentional behavior change.
+var updateGolden = flag.Bool("update", false, "Update golden files")
+
+func TestHeadlessGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps int
+		setup func()
+	}{
+		{
+			// The player spawns at z=240 and falls onto the floor, so both
+			// the spawner and the spawned player end up at z=20 (standing on
+			// the floor at z=0). The body left at z=60 is the light, which
+			// isn't mobile.
+			name:  "world2",
+			steps: 256,
+			setup: CreateTestWorld2,
+		},
+		{
+			// A vertical door that starts open and closes.
+			name:  "door",
+			steps: 512,
+			setup: func() {
+				CreateTestWorld2()
+				eSector := ecs.GetEntityByName("sector3")
+				door := ecs.NewAttachedComponent(eSector, behaviors.DoorCID).(*behaviors.Door)
+				door.Type = behaviors.DoorTypeVertical
+				door.Intent = behaviors.DoorIntentClosed
+				ecs.ActAllControllersOneEntity(eSector, ecs.ControllerPrecompute)
+				if core.GetSector(eSector) == nil {
+					t.Fatal("sector3 not found")
+				}
+			},
+		},
+		{
+			// A ball rolls from sector1 into sector2, while a turret in
+			// sector3 fires a slow projectile back, which drops short in sector2.
+			name:  "projectiles",
+			steps: 256,
+			setup: func() {
+				CreateTestWorld2()
+				createTestBall()
+				createTestTurret()
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ecs.Initialize()
+			ecs.Simulation.SetSeed(1)
+			tc.setup()
+			RespawnAll()
+			RunHeadless(tc.steps)
+
+			actual, err := yaml.Marshal(DumpState())
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "headless_"+tc.name+".golden.yaml")
+			if *updateGolden {
+				if err := os.MkdirAll("testdata", 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, actual, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("Error reading golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(expected, actual) {
+				t.Errorf("State after %v steps doesn't match %v.\nExpected:\n%s\nActual:\n%s", tc.steps, golden, expected, actual)
+			}
+		})
+	}
+}
+
+// createTestBall creates a mobile body that rolls in +X.
+func createTestBall() {
+	e := ecs.NewEntity()
+	body := ecs.NewAttachedComponent(e, core.BodyCID).(*core.Body)
+	body.Pos.SetAll(concepts.Vector3{-50, 0, 20})
+	mobile := ecs.NewAttachedComponent(e, core.MobileCID).(*core.Mobile)
+	mobile.Mass = 5
+	mobile.Vel.SetAll(concepts.Vector3{6, 0, 0})
+	ecs.ActAllControllersOneEntity(e, ecs.ControllerPrecompute)
+}
+
+// createTestTurret creates a body carrying a projectile weapon that fires
+// once, in -X.
+func createTestTurret() {
+	// The template that projectiles are cloned from.
+	eTemplate := ecs.NewEntity()
+	spawner := ecs.NewAttachedComponent(eTemplate, behaviors.SpawnerCID).(*behaviors.Spawner)
+	spawner.Auto = behaviors.AutoSpawnNone
+	templateBody := ecs.NewAttachedComponent(eTemplate, core.BodyCID).(*core.Body)
+	templateBody.Size.SetAll(concepts.Vector2{2, 2})
+	templateBody.CollisionSize.SetAll(concepts.Vector2{2, 2})
+
+	eSlot := ecs.NewEntity()
+	slot := ecs.NewAttachedComponent(eSlot, inventory.SlotCID).(*inventory.Slot)
+	slot.Count.SetAll(1)
+	ecs.NewAttachedComponent(eSlot, inventory.WeaponClassCID)
+	wcp := ecs.NewAttachedComponent(eSlot, inventory.WeaponClassProjectileCID).(*inventory.WeaponClassProjectile)
+	wcp.Projectile = eTemplate
+	wcp.Speed = 5
+	ecs.ActAllControllersOneEntity(eSlot, ecs.ControllerPrecompute)
+	weapon := inventory.GetWeapon(eSlot)
+	weapon.Intent = inventory.WeaponFire
+
+	eTurret := ecs.NewEntity()
+	body := ecs.NewAttachedComponent(eTurret, core.BodyCID).(*core.Body)
+	body.Pos.SetAll(concepts.Vector3{400, 0, 20})
+	body.Angle.SetAll(180)
+	carrier := ecs.NewAttachedComponent(eTurret, inventory.CarrierCID).(*inventory.Carrier)
+	carrier.Slots.Set(eSlot)
+	ecs.ActAllControllersOneEntity(eTurret, ecs.ControllerPrecompute)
+}
@@ -20,6 +20,10 @@ type ImageController struct {
 
 func init() {
 	ecs.Types().RegisterController(func() ecs.Controller { return &ImageController{} }, 100)
+	// Any change to an image's properties means it needs to be reloaded.
+	ecs.Types().Subscribe(materials.ImageCID, ecs.ChangeModified, func(kind ecs.ChangeKind, c ecs.Component, e ecs.Entity) {
+		c.(*materials.Image).MarkDirty()
+	})
 }
 
 func (ic *ImageController) ComponentID() ecs.ComponentID {
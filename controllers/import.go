@@ -0,0 +1,60 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"slices"
+	"tlyakhov/gofoom/components/behaviors"
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/ecs"
+)
+
+// OffsetEntity moves any geometry an entity has (sectors, bodies, internal
+// segments, waypoints) by delta.
+func OffsetEntity(e ecs.Entity, delta *concepts.Vector3) {
+	if sector := core.GetSector(e); sector != nil {
+		for _, seg := range sector.Segments {
+			seg.P.Spawn.AddSelf(delta.To2D())
+			seg.P.ResetToSpawn()
+		}
+		sector.Bottom.Z.Spawn += delta[2]
+		sector.Bottom.Z.ResetToSpawn()
+		sector.Top.Z.Spawn += delta[2]
+		sector.Top.Z.ResetToSpawn()
+	}
+	if body := core.GetBody(e); body != nil {
+		body.Pos.Spawn.AddSelf(delta)
+		body.Pos.ResetToSpawn()
+	}
+	if seg := core.GetInternalSegment(e); seg != nil {
+		seg.A.AddSelf(delta.To2D())
+		seg.B.AddSelf(delta.To2D())
+		seg.Bottom += delta[2]
+		seg.Top += delta[2]
+	}
+	if waypoint := behaviors.GetActionWaypoint(e); waypoint != nil {
+		waypoint.P.AddSelf(delta)
+	}
+}
+
+// ImportWorld merges another world file into the current one (see
+// ecs.Import), optionally moving everything by offset. Returns the new
+// entities.
+func ImportWorld(filename string, offset *concepts.Vector3) ([]ecs.Entity, error) {
+	imported, err := ecs.Import(filename)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ecs.Entity, 0, len(imported))
+	for _, e := range imported {
+		if offset != nil && !offset.Zero() {
+			OffsetEntity(e, offset)
+		}
+		result = append(result, e)
+	}
+	slices.Sort(result)
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+	return result, nil
+}
@@ -0,0 +1,103 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/ecs"
+)
+
+func TestImportWorld(t *testing.T) {
+	worldPath := filepath.Join(t.TempDir(), "world.yaml")
+	ecs.Initialize()
+	CreateTestWorld2()
+	ecs.Save(worldPath)
+	ecs.Initialize()
+	if err := ecs.Load(worldPath); err != nil {
+		t.Fatal(err)
+	}
+	original := ecs.GetEntityByName("sector1")
+	originalSector := core.GetSector(original)
+	sectors := ecs.ArenaFor[core.Sector](core.SectorCID).Len()
+
+	offset := &concepts.Vector3{1000, 500, 10}
+	imported, err := ImportWorld(worldPath, offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) == 0 {
+		t.Fatal("Expected entities to be imported")
+	}
+	if n := ecs.ArenaFor[core.Sector](core.SectorCID).Len(); n != sectors*2 {
+		t.Errorf("Expected %v sectors after import, got %v", sectors*2, n)
+	}
+	for _, e := range imported {
+		sector := core.GetSector(e)
+		if sector == nil || e == original || ecs.GetNamed(e).Name != "sector1" {
+			continue
+		}
+		p := sector.Segments[0].P.Spawn
+		expected := originalSector.Segments[0].P.Spawn
+		if p[0] != expected[0]+offset[0] || p[1] != expected[1]+offset[1] {
+			t.Errorf("Expected imported sector to be offset, got %v (original %v)", p, expected)
+		}
+		if sector.Bottom.Z.Spawn != originalSector.Bottom.Z.Spawn+offset[2] {
+			t.Errorf("Expected imported floor to be offset, got %v", sector.Bottom.Z.Spawn)
+		}
+		portals := 0
+		for _, seg := range sector.Segments {
+			if seg.AdjacentSector == 0 {
+				continue
+			}
+			portals++
+			if !slices.Contains(imported, seg.AdjacentSector) {
+				t.Errorf("Expected portal to be remapped to an imported sector, got %v", seg.AdjacentSector)
+			}
+		}
+		if portals == 0 {
+			t.Errorf("Expected imported sector1 to have portals")
+		}
+		return
+	}
+	t.Errorf("Couldn't find imported copy of sector1")
+}
+
+// The editor undoes an import like any other action, by loading the
+// snapshot it took beforehand.
+func TestImportWorldUndo(t *testing.T) {
+	worldPath := filepath.Join(t.TempDir(), "world.yaml")
+	ecs.Initialize()
+	CreateTestWorld2()
+	ecs.Save(worldPath)
+	// Loading a snapshot isn't lossless (e.g. precomputed positions), so
+	// compare two loads of the same one.
+	undo := ecs.SaveSnapshot(true)
+	if err := ecs.LoadSnapshot(undo); err != nil {
+		t.Fatal(err)
+	}
+	before, err := ecs.StateHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := ecs.Entities.Count()
+
+	if _, err := ImportWorld(worldPath, &concepts.Vector3{1000, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ecs.LoadSnapshot(undo); err != nil {
+		t.Fatal(err)
+	}
+	after, err := ecs.StateHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != before || ecs.Entities.Count() != count {
+		t.Errorf("Expected undoing the import to restore the world, got %v entities (was %v)", ecs.Entities.Count(), count)
+	}
+}
@@ -29,6 +29,15 @@ func (icc *InventoryCarrierController) EditorPausedMethods() ecs.ControllerMetho
 	return ecs.ControllerFrame
 }
 
+var carrierAccess = ecs.ControllerAccess{
+	Reads:  []ecs.ComponentID{inventory.WeaponCID},
+	Writes: []ecs.ComponentID{inventory.SlotCID},
+}
+
+func (icc *InventoryCarrierController) Access() *ecs.ControllerAccess {
+	return &carrierAccess
+}
+
 func (icc *InventoryCarrierController) Target(target ecs.Component, e ecs.Entity) bool {
 	icc.Entity = e
 	icc.Carrier = target.(*inventory.Carrier)
@@ -4,7 +4,6 @@
 package controllers
 
 import (
-	"math/rand/v2"
 	"tlyakhov/gofoom/components/behaviors"
 	"tlyakhov/gofoom/components/core"
 	"tlyakhov/gofoom/components/inventory"
@@ -76,7 +75,7 @@ func (iic *InventoryItemController) Precompute() {
 		a.End[2] = 5
 		a.Duration = 1000
 		// Make inventory items bounce differently for variety
-		a.Percent = rand.Float64()
+		a.Percent = ecs.Simulation.Rand.Float64()
 	}
 
 	if iic.Flags&inventory.ItemAutoProximity != 0 {
@@ -5,7 +5,6 @@ package controllers
 
 import (
 	"math"
-	"math/rand"
 
 	"tlyakhov/gofoom/components/audio"
 	"tlyakhov/gofoom/components/core"
@@ -27,6 +26,12 @@ func init() {
 	ecs.Types().RegisterController(func() ecs.Controller { return &MobileController{} }, 80)
 }
 
+// Access overrides the embedded BodyController. Collisions can touch almost
+// anything, so this controller always runs serially.
+func (mc *MobileController) Access() *ecs.ControllerAccess {
+	return nil
+}
+
 func (mc *MobileController) ComponentID() ecs.ComponentID {
 	return core.MobileCID
 }
@@ -75,7 +80,7 @@ func (mc *MobileController) Forces() {
 
 	if mc.AirDrag {
 		// Air drag
-		r := mc.Body.Size.Now[0] * 0.5 * constants.MetersPerUnit
+		r := mc.Body.CollisionSize.Now[0] * 0.5 * constants.MetersPerUnit
 		crossSectionArea := math.Pi * r * r
 		drag := concepts.Vector3{v[0], v[1], v[2]}
 		drag.MulSelf(drag.Length())
@@ -129,15 +134,15 @@ func (mc *MobileController) Frame() {
 			mc.MovementSoundDistance += mc.Body.Pos.Now.Dist(&prePos)
 		}
 		// TODO: Parameterize this
-		stepDist := 70 + rand.Float64()*10
+		stepDist := 70 + ecs.Simulation.Rand.Float64()*10
 		if mc.MovementSoundDistance > stepDist || speedSquared <= constants.VelocityEpsilon {
 			event, _ := audio.PlaySound(mc.StepSound, mc.Body.Entity, mc.Body.Entity.String()+" step", audio.SoundPlayInterruptPerTag)
 			if event != nil {
 				// TODO: Parameterize this
-				event.Offset[2] = -mc.Body.Size.Now[1] * 0.5
+				event.Offset[2] = -mc.Body.CollisionSize.Now[1] * 0.5
 				event.Offset[1] = 0
 				event.Offset[0] = 0
-				event.SetPitchMultiplier(0.9 + rand.Float64()*0.2)
+				event.SetPitchMultiplier(0.9 + ecs.Simulation.Rand.Float64()*0.2)
 				event.SetGain(0.2)
 			}
 			mc.MovementSoundDistance = 0
@@ -161,6 +166,7 @@ func (mc *MobileController) Precompute() {
 	for _, s := range mc.ContactScripts {
 		if !s.IsEmpty() {
 			s.Params = mobileContactScriptParams
+			s.SetOwner(mc.BaseController.Entity, mc.Mobile, "Mobile.Contact")
 			s.Compile()
 		}
 	}
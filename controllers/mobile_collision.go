@@ -6,10 +6,10 @@ package controllers
 import (
 	"log"
 	"math"
-	"math/rand/v2"
 	"tlyakhov/gofoom/components/behaviors"
 	"tlyakhov/gofoom/components/character"
 	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/components/inventory"
 	"tlyakhov/gofoom/components/materials"
 	"tlyakhov/gofoom/components/selection"
 	"tlyakhov/gofoom/concepts"
@@ -34,16 +34,16 @@ func (mc *MobileController) pushBack(sector *core.Sector, s *selection.Selectabl
 	}
 
 	d := segment.DistanceToPointSq(mc.pos2d)
-	if d > mc.Body.Size.Now[0]*mc.Body.Size.Now[0]*0.25 {
+	if d > mc.Body.CollisionSize.Now[0]*mc.Body.CollisionSize.Now[0]*0.25 {
 		return false
 	}
 
 	// What we are trying to do is ensure the body is always at least half of
-	// Body.Size.Now[0] distance away from a wall, but when we push away, we do
+	// Body.CollisionSize.Now[0] distance away from a wall, but when we push away, we do
 	// it tangent to the wall so the body can slide along walls. To achieve
 	// this, we first create the (unit length) vector `delta`, which points from
 	// wall->body. Then, we scale that by -d to move the player to align with
-	// the wall, and +mc.Body.Size.Now[0]*0.5 to get them the right distance
+	// the wall, and +mc.Body.CollisionSize.Now[0]*0.5 to get them the right distance
 	// away.
 
 	// side > 0 if the body is in the direction of the normal, or < 0 if on
@@ -74,12 +74,12 @@ func (mc *MobileController) pushBack(sector *core.Sector, s *selection.Selectabl
 
 	// For debugging collisions with segments:
 	//log.Printf("PushBack: sector=%v,p=%v, closest=%v, side=%v, delta=%v, d=%.2f, xsize=%.2f",
-	//	sector.Entity.String(), mc.pos2d.StringHuman(), closest.StringHuman(), side >= 0, delta.StringHuman(), d, mc.Body.Size.Now[0])
+	//	sector.Entity.String(), mc.pos2d.StringHuman(), closest.StringHuman(), side >= 0, delta.StringHuman(), d, mc.Body.CollisionSize.Now[0])
 
 	if side > 0 {
-		delta.MulSelf(-d + mc.Body.Size.Now[0]*0.5)
+		delta.MulSelf(-d + mc.Body.CollisionSize.Now[0]*0.5)
 	} else {
-		delta.MulSelf(-d - mc.Body.Size.Now[0]*0.5)
+		delta.MulSelf(-d - mc.Body.CollisionSize.Now[0]*0.5)
 	}
 
 	if side < 0 {
@@ -127,7 +127,7 @@ func (mc *MobileController) bodyTeleport() bool {
 			continue
 		}
 		d := segment.DistanceToPointSq(mc.pos2d)
-		if d > mc.Body.Size.Now[0]*mc.Body.Size.Now[0]*0.25 {
+		if d > mc.Body.CollisionSize.Now[0]*mc.Body.CollisionSize.Now[0]*0.25 {
 			continue
 		}
 		side := segment.WhichSide(mc.pos2d)
@@ -289,8 +289,8 @@ func (mc *MobileController) resolveCollision(bMobile *core.Mobile, bBody *core.B
 	// The code below to bounce rigid bodies is adapted from
 	// https://www.myphysicslab.com/engine2D/collision-en.html
 
-	aRadius := mc.Body.Size.Now[0] * 0.5
-	bRadius := bBody.Size.Now[0] * 0.5
+	aRadius := mc.Body.CollisionSize.Now[0] * 0.5
+	bRadius := bBody.CollisionSize.Now[0] * 0.5
 	UnitAtoB := mc.pos.Sub(&bBody.Pos.Now)
 	distance := UnitAtoB.Length()
 	if distance > constants.IntersectEpsilon {
@@ -298,7 +298,7 @@ func (mc *MobileController) resolveCollision(bMobile *core.Mobile, bBody *core.B
 	} else {
 		// The bodies are right on top of each other.
 		// Resolve the collision with a random direction vector.
-		UnitAtoB[0], UnitAtoB[1] = math.Sincos(rand.Float64() * math.Pi * 2)
+		UnitAtoB[0], UnitAtoB[1] = math.Sincos(ecs.Simulation.Rand.Float64() * math.Pi * 2)
 	}
 
 	// This code separates inter-penetrating bodies
@@ -389,7 +389,7 @@ func (mc *MobileController) resolveCollision(bMobile *core.Mobile, bBody *core.B
 }
 
 func (mc *MobileController) bodyBodyCollide() {
-	core.QuadTree.Root.RangeCircle(mc.Body.Pos.Now.To2D(), mc.Body.Size.Now[0]*0.5, func(body *core.Body) bool {
+	core.QuadTree.Root.RangeCircle(mc.Body.Pos.Now.To2D(), mc.Body.CollisionSize.Now[0]*0.5, func(body *core.Body) bool {
 		if !body.IsActive() || body == mc.Body {
 			return true
 		}
@@ -405,8 +405,8 @@ func (mc *MobileController) bodyBodyCollide() {
 
 		// From https://www.myphysicslab.com/engine2D/collision-en.html
 		d2 := mc.pos.DistSq(&body.Pos.Now)
-		r_a := mc.Body.Size.Now[0] * 0.5
-		r_b := body.Size.Now[0] * 0.5
+		r_a := mc.Body.CollisionSize.Now[0] * 0.5
+		r_b := body.CollisionSize.Now[0] * 0.5
 		if d2 < (r_a+r_b)*(r_a+r_b) {
 			for _, s := range mc.ContactScripts {
 				if s.IsCompiled() {
@@ -417,6 +417,10 @@ func (mc *MobileController) bodyBodyCollide() {
 					s.Act()
 				}
 			}
+			if p := inventory.GetProjectile(mc.Body.Entity); p != nil {
+				hit := mc.Body.Pos.Now
+				projectileHit(p, selection.SelectableFromBody(body), &hit)
+			}
 			mc.resolveCollision(mobile, body)
 		}
 
@@ -425,7 +429,7 @@ func (mc *MobileController) bodyBodyCollide() {
 }
 
 func (mc *MobileController) CollideZ() {
-	halfHeight := mc.Body.Size.Now[1] * 0.5
+	halfHeight := mc.Body.CollisionSize.Now[1] * 0.5
 	bodyTop := mc.Body.Pos.Now[2] + halfHeight
 	floorZ, ceilZ := mc.Sector.ZAt(mc.Body.Pos.Now.To2D())
 
@@ -564,6 +568,10 @@ func (mc *MobileController) Collide() {
 					s.Act()
 				}
 			}
+			if p := inventory.GetProjectile(mc.Body.Entity); p != nil {
+				hit := mc.Pos.Now
+				projectileHit(p, sel, &hit)
+			}
 			if mc.markController.Target(materials.GetMarkMaker(mc.Mobile.Entity), mc.Mobile.Entity) {
 				mc.markController.MakeMark(sel, &mc.Pos.Now)
 			}
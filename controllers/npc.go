@@ -5,7 +5,6 @@ package controllers
 
 import (
 	"math"
-	"math/rand/v2"
 	"tlyakhov/gofoom/components/audio"
 	"tlyakhov/gofoom/components/behaviors"
 	"tlyakhov/gofoom/components/character"
@@ -24,6 +23,9 @@ type NpcController struct {
 
 var npcFuncs = [character.NpcStateCount]func(*NpcController){}
 
+// NPCs need a body to do anything.
+var npcBodies = ecs.NewView2[character.Npc, core.Body]()
+
 func init() {
 	ecs.Types().RegisterController(func() ecs.Controller { return &NpcController{} }, 100)
 	npcFuncs[character.NpcStateIdle] = npcIdle
@@ -44,12 +46,8 @@ func (npc *NpcController) Methods() ecs.ControllerMethod {
 
 func (npc *NpcController) Target(target ecs.Component, e ecs.Entity) bool {
 	npc.Entity = e
-	npc.Npc = target.(*character.Npc)
-	if !npc.Npc.IsActive() {
-		return false
-	}
-	npc.Body = core.GetBody(npc.Entity)
-	if npc.Body == nil || !npc.Body.IsActive() {
+	npc.Npc, npc.Body = npcBodies.Get(e)
+	if npc.Npc == nil || !npc.Npc.IsActive() || !npc.Body.IsActive() {
 		return false
 	}
 	npc.Alive = behaviors.GetAlive(npc.Entity)
@@ -102,7 +100,7 @@ func npcIdle(npc *NpcController) {
 		if npc.NextIdleBark != 0 {
 			npc.playSound(npc.BarksIdle)
 		}
-		npc.NextIdleBark = ecs.Simulation.SimTimestamp + concepts.MillisToNanos(5000+rand.Float64()*15000)
+		npc.NextIdleBark = ecs.Simulation.SimTimestamp + concepts.MillisToNanos(5000+ecs.Simulation.Rand.Float64()*15000)
 	}
 }
 
@@ -111,7 +109,7 @@ func npcOther(npc *NpcController) {
 
 func (npc *NpcController) playSound(sounds ecs.EntityTable) {
 	sound := ecs.Entity(0)
-	r := rand.IntN(sounds.Len())
+	r := ecs.Simulation.Rand.IntN(sounds.Len())
 	for _, e := range sounds {
 		if e == 0 {
 			continue
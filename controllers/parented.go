@@ -0,0 +1,262 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"log"
+	"math"
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/dynamic"
+	"tlyakhov/gofoom/ecs"
+)
+
+// ParentedController moves children along with their parents, see
+// core.Parented.
+type ParentedController struct {
+	ecs.BaseController
+	*core.Parented
+}
+
+// Parents can be parented themselves. This limits how far up the chain we go
+// (and protects against cycles).
+const maxParentDepth = 16
+
+func init() {
+	// Should run after the MobileController, so that physics doesn't pull
+	// children away from their parents.
+	ecs.Types().RegisterController(func() ecs.Controller { return &ParentedController{} }, 85)
+	ecs.Types().Subscribe(core.BodyCID, ecs.ChangeDetached, parentDeleted)
+	ecs.Types().Subscribe(core.SectorCID, ecs.ChangeDetached, parentDeleted)
+}
+
+func (pc *ParentedController) ComponentID() ecs.ComponentID {
+	return core.ParentedCID
+}
+
+func (pc *ParentedController) Methods() ecs.ControllerMethod {
+	return ecs.ControllerFrame | ecs.ControllerPrecompute
+}
+
+func (pc *ParentedController) EditorPausedMethods() ecs.ControllerMethod {
+	return ecs.ControllerPrecompute
+}
+
+func (pc *ParentedController) Target(target ecs.Component, e ecs.Entity) bool {
+	pc.Entity = e
+	pc.Parented = target.(*core.Parented)
+	return pc.Parented.IsActive() && pc.Parent != 0 && pc.Parent != e
+}
+
+func (pc *ParentedController) Precompute() {
+	if !pc.Captured {
+		CaptureParentOffsets(pc.Parented, pc.Entity)
+		return
+	}
+	followParent(pc.Parented, pc.Entity, true, 0)
+}
+
+func (pc *ParentedController) Frame() {
+	followParent(pc.Parented, pc.Entity, false, 0)
+}
+
+// parentState selects one of the states of a parent's dynamic values.
+type parentState int
+
+const (
+	parentSpawn parentState = iota
+	parentPrevSimStep
+	parentPrevFrame
+	parentNow
+)
+
+func stateOf[T dynamic.DynamicType](d *dynamic.DynamicValue[T], state parentState) T {
+	switch state {
+	case parentSpawn:
+		return d.Spawn
+	case parentPrevSimStep:
+		return d.PrevSimStep
+	case parentPrevFrame:
+		return d.PrevFrame
+	default:
+		return d.Now
+	}
+}
+
+// parentFrame is the frame of reference of a parent at one of its states.
+type parentFrame struct {
+	m     concepts.Matrix2
+	z     float64
+	angle float64
+}
+
+func (f *parentFrame) toWorld(local *concepts.Vector3) concepts.Vector3 {
+	p := concepts.Vector2{local[0], local[1]}
+	f.m.ProjectSelf(&p)
+	return concepts.Vector3{p[0], p[1], local[2] + f.z}
+}
+
+func (f *parentFrame) toLocal(world *concepts.Vector3) concepts.Vector3 {
+	p := concepts.Vector2{world[0], world[1]}
+	f.m.UnprojectSelf(&p)
+	return concepts.Vector3{p[0], p[1], world[2] - f.z}
+}
+
+// getParentFrame returns false if the entity can't be a parent.
+func getParentFrame(parent ecs.Entity, state parentState, f *parentFrame) bool {
+	if body := core.GetBody(parent); body != nil {
+		pos := stateOf(&body.Pos, state)
+		f.angle = stateOf(&body.Angle, state)
+		sin, cos := math.Sincos(f.angle * concepts.Deg2rad)
+		f.m = concepts.Matrix2{cos, sin, -sin, cos, pos[0], pos[1]}
+		f.z = pos[2]
+		return true
+	}
+	if sector := core.GetSector(parent); sector != nil {
+		// Sector transforms aren't interpolated when rendering (the
+		// segments snap to .Now), so neither are the children. Floors are,
+		// though.
+		if state == parentPrevFrame {
+			f.m = sector.Transform.Now
+		} else {
+			f.m = stateOf(&sector.Transform, state)
+		}
+		f.m[4] += sector.TransformOrigin[0]
+		f.m[5] += sector.TransformOrigin[1]
+		f.angle, _, _ = f.m.GetTransform()
+		f.z = stateOf(&sector.Bottom.Z, state)
+		return true
+	}
+	return false
+}
+
+// CaptureParentOffsets sets the relative offsets of a child from the current
+// positions of the child and its parent.
+func CaptureParentOffsets(p *core.Parented, e ecs.Entity) {
+	var f parentFrame
+	if !getParentFrame(p.Parent, parentNow, &f) {
+		return
+	}
+	if body := core.GetBody(e); body != nil {
+		p.Pos = f.toLocal(&body.Pos.Now)
+		p.Angle = body.Angle.Now - f.angle
+	}
+	if seg := core.GetInternalSegment(e); seg != nil {
+		a := f.toLocal(&concepts.Vector3{seg.A[0], seg.A[1]})
+		b := f.toLocal(&concepts.Vector3{seg.B[0], seg.B[1]})
+		p.SegmentA = *a.To2D()
+		p.SegmentB = *b.To2D()
+	}
+	p.Captured = true
+}
+
+// followParent updates the child's body and segment from the parent. If
+// precompute is true, the spawn values are updated as well, and render values
+// are reset.
+func followParent(p *core.Parented, e ecs.Entity, precompute bool, depth int) {
+	if depth >= maxParentDepth {
+		log.Printf("followParent: %v has too many ancestors, is there a cycle?", e)
+		return
+	}
+	// Make sure the parent is up to date first.
+	if grandparent := core.GetParented(p.Parent); grandparent != nil &&
+		grandparent.IsActive() && grandparent.Captured {
+		followParent(grandparent, p.Parent, precompute, depth+1)
+	}
+
+	var f parentFrame
+	if !getParentFrame(p.Parent, parentNow, &f) {
+		return
+	}
+
+	if body := core.GetBody(e); body != nil {
+		followParentBody(p, body, precompute)
+	}
+
+	if seg := core.GetInternalSegment(e); seg != nil && p.FollowSegment {
+		a := f.toWorld(&concepts.Vector3{p.SegmentA[0], p.SegmentA[1]})
+		b := f.toWorld(&concepts.Vector3{p.SegmentB[0], p.SegmentB[1]})
+		if *seg.A != *a.To2D() || *seg.B != *b.To2D() {
+			*seg.A = *a.To2D()
+			*seg.B = *b.To2D()
+			seg.PrecomputeNormal()
+			seg.AttachToSectors()
+		}
+	}
+}
+
+func followParentBody(p *core.Parented, body *core.Body, precompute bool) {
+	var f parentFrame
+	states := []parentState{parentPrevSimStep, parentPrevFrame, parentNow}
+	if precompute {
+		states = append(states, parentSpawn)
+	}
+	for _, state := range states {
+		getParentFrame(p.Parent, state, &f)
+		pos := f.toWorld(&p.Pos)
+		angle := f.angle + p.Angle
+		switch {
+		case state == parentSpawn:
+			body.Pos.Spawn = pos
+		case body.Pos.Procedural:
+			// Procedural values follow the parent organically
+			if state == parentNow {
+				body.Pos.Input = pos
+			}
+		case state == parentPrevSimStep:
+			body.Pos.PrevSimStep = pos
+		case state == parentPrevFrame:
+			body.Pos.PrevFrame = pos
+		default:
+			body.Pos.Now = pos
+		}
+		if !p.InheritAngle {
+			continue
+		}
+		switch {
+		case state == parentSpawn:
+			body.Angle.Spawn = angle
+		case body.Angle.Procedural:
+			if state == parentNow {
+				body.Angle.Input = angle
+			}
+		case state == parentPrevSimStep:
+			body.Angle.PrevSimStep = angle
+		case state == parentPrevFrame:
+			body.Angle.PrevFrame = angle
+		default:
+			body.Angle.Now = angle
+		}
+	}
+	if precompute {
+		body.Pos.Render = body.Pos.Now
+		body.Angle.Render = body.Angle.Now
+	}
+
+	var bc BodyController
+	if bc.Target(body, body.Entity) {
+		bc.findBodySector()
+		core.QuadTree.Update(body)
+	}
+}
+
+// parentDeleted deletes or detaches the children of a deleted parent.
+func parentDeleted(kind ecs.ChangeKind, component ecs.Component, e ecs.Entity) {
+	if ecs.Entities.Contains(uint32(e)) {
+		// Only the component was detached, not the whole entity.
+		return
+	}
+	arena := ecs.ArenaFor[core.Parented](core.ParentedCID)
+	for i := range arena.Cap() {
+		p := arena.Value(i)
+		if p == nil || p.Parent != e {
+			continue
+		}
+		if p.Cascade {
+			ecs.Delete(p.Entity)
+		} else {
+			p.Parent = 0
+		}
+	}
+}
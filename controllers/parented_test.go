@@ -0,0 +1,126 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"math"
+	"testing"
+
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/ecs"
+)
+
+func newTestBody(pos concepts.Vector3, angle float64) (ecs.Entity, *core.Body) {
+	e := ecs.NewEntity()
+	body := ecs.NewAttachedComponent(e, core.BodyCID).(*core.Body)
+	body.Pos.SetAll(pos)
+	body.Angle.SetAll(angle)
+	return e, body
+}
+
+func newTestChild(e, parent ecs.Entity) *core.Parented {
+	p := ecs.NewAttachedComponent(e, core.ParentedCID).(*core.Parented)
+	p.Parent = parent
+	return p
+}
+
+func expectVector(t *testing.T, what string, expected, actual concepts.Vector3) {
+	t.Helper()
+	if expected.Dist(&actual) > 1e-6 {
+		t.Errorf("%v: expected %v, got %v", what, expected.StringHuman(2), actual.StringHuman(2))
+	}
+}
+
+func expectAngle(t *testing.T, what string, expected, actual float64) {
+	t.Helper()
+	if math.Abs(concepts.NormalizeAngle(expected)-concepts.NormalizeAngle(actual)) > 1e-6 {
+		t.Errorf("%v: expected %v, got %v", what, expected, actual)
+	}
+}
+
+func TestParentedBody(t *testing.T) {
+	ecs.Initialize()
+	CreateTestWorld2()
+	eParent, parent := newTestBody(concepts.Vector3{10, 10, 5}, 0)
+	eChild, child := newTestBody(concepts.Vector3{20, 10, 15}, 30)
+	p := newTestChild(eChild, eParent)
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+
+	if !p.Captured {
+		t.Fatalf("Offsets weren't captured")
+	}
+	expectVector(t, "Relative position", concepts.Vector3{10, 0, 10}, p.Pos)
+	expectAngle(t, "Relative angle", 30, p.Angle)
+
+	// Move and turn the parent within a frame.
+	parent.Pos.Now = concepts.Vector3{50, 50, 5}
+	parent.Angle.Now = 90
+	ecs.ActAllControllers(ecs.ControllerFrame)
+
+	expectVector(t, "Child Now", concepts.Vector3{50, 60, 15}, child.Pos.Now)
+	expectVector(t, "Child PrevFrame", concepts.Vector3{20, 10, 15}, child.Pos.PrevFrame)
+	expectAngle(t, "Child angle", 120, child.Angle.Now)
+	expectAngle(t, "Child previous angle", 30, child.Angle.PrevFrame)
+
+	// Halfway through the frame, the child should be halfway between.
+	child.Pos.Update(0.5)
+	expectVector(t, "Child Render", concepts.Vector3{35, 35, 15}, child.Pos.Render)
+}
+
+func TestParentedSector(t *testing.T) {
+	ecs.Initialize()
+	CreateTestWorld2()
+	sector := core.GetSector(ecs.GetEntityByName("sector1"))
+	sector.TransformOrigin = concepts.Vector2{0, 0}
+	eChild, child := newTestBody(concepts.Vector3{50, 0, 10}, 0)
+	newTestChild(eChild, sector.Entity)
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+
+	sector.Transform.Now.SetRotation(90)
+	sector.Bottom.Z.Now = 20
+	ecs.ActAllControllers(ecs.ControllerFrame)
+
+	expectVector(t, "Child Now", concepts.Vector3{0, 50, 30}, child.Pos.Now)
+	expectAngle(t, "Child angle", 90, child.Angle.Now)
+	// Sector transforms snap when rendering, but floors are interpolated.
+	expectVector(t, "Child PrevFrame", concepts.Vector3{0, 50, 10}, child.Pos.PrevFrame)
+}
+
+func TestParentedChain(t *testing.T) {
+	ecs.Initialize()
+	CreateTestWorld2()
+	eRoot, root := newTestBody(concepts.Vector3{0, 0, 5}, 0)
+	eMiddle, _ := newTestBody(concepts.Vector3{10, 0, 5}, 0)
+	eLeaf, leaf := newTestBody(concepts.Vector3{20, 0, 5}, 0)
+	// Children created before their parents
+	newTestChild(eLeaf, eMiddle)
+	newTestChild(eMiddle, eRoot)
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+
+	root.Angle.Now = 90
+	followParent(core.GetParented(eLeaf), eLeaf, false, 0)
+	expectVector(t, "Leaf", concepts.Vector3{0, 20, 5}, leaf.Pos.Now)
+}
+
+func TestParentedDelete(t *testing.T) {
+	ecs.Initialize()
+	CreateTestWorld2()
+	eParent, _ := newTestBody(concepts.Vector3{0, 0, 5}, 0)
+	eCascade, _ := newTestBody(concepts.Vector3{10, 0, 5}, 0)
+	eGrandchild, _ := newTestBody(concepts.Vector3{20, 0, 5}, 0)
+	eOrphan, _ := newTestBody(concepts.Vector3{-10, 0, 5}, 0)
+	newTestChild(eCascade, eParent).Cascade = true
+	newTestChild(eGrandchild, eCascade).Cascade = true
+	orphan := newTestChild(eOrphan, eParent)
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+
+	ecs.Delete(eParent)
+	if ecs.Entities.Contains(uint32(eCascade)) || ecs.Entities.Contains(uint32(eGrandchild)) {
+		t.Errorf("Children weren't deleted with their parent")
+	}
+	if !ecs.Entities.Contains(uint32(eOrphan)) || orphan.Parent != 0 {
+		t.Errorf("Child without Cascade should be kept and unparented")
+	}
+}
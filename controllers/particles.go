@@ -5,7 +5,6 @@ package controllers
 
 import (
 	"math"
-	"math/rand"
 	"tlyakhov/gofoom/components/behaviors"
 	"tlyakhov/gofoom/components/core"
 	"tlyakhov/gofoom/components/materials"
@@ -94,8 +93,8 @@ func (pc *ParticleController) spawn() {
 	}
 
 	body.Pos.SetAll(pc.Body.Pos.Now)
-	hAngle := pc.Body.Angle.Now + (rand.Float64()-0.5)*pc.XYSpread
-	vAngle := (rand.Float64() - 0.5) * pc.ZSpread
+	hAngle := pc.Body.Angle.Now + (ecs.Simulation.Rand.Float64()-0.5)*pc.XYSpread
+	vAngle := (ecs.Simulation.Rand.Float64() - 0.5) * pc.ZSpread
 	mobile.Vel.Spawn[0] = math.Cos(hAngle*concepts.Deg2rad) * math.Cos(vAngle*concepts.Deg2rad) * pc.Vel
 	mobile.Vel.Spawn[1] = math.Sin(hAngle*concepts.Deg2rad) * math.Cos(vAngle*concepts.Deg2rad) * pc.Vel
 	mobile.Vel.Spawn[2] = math.Sin(vAngle*concepts.Deg2rad) * pc.Vel
@@ -122,7 +121,7 @@ func (pc *ParticleController) Frame() {
 	iterations := int(probability) + 1
 	probability /= float64(iterations)
 	for range iterations {
-		if rand.Float64() > probability {
+		if ecs.Simulation.Rand.Float64() > probability {
 			return
 		}
 		pc.spawn()
@@ -77,9 +77,11 @@ func (pc *PlayerController) bob(uw bool) {
 	// an unintentional jump.
 	if pc.Crouching {
 		pc.Body.Size.Now[1] = constants.PlayerCrouchHeight
+		pc.Body.CollisionSize.Now[1] = constants.PlayerCrouchHeight
 		pc.Crouching = false
 	} else {
 		pc.Body.Size.Now[1] = constants.PlayerHeight
+		pc.Body.CollisionSize.Now[1] = constants.PlayerHeight
 	}
 
 	bob := math.Sin(pc.Bob) * 1.5
@@ -46,14 +46,17 @@ var playerTargetableScriptParams = []core.ScriptParam{
 func (ptc *PlayerTargetableController) Precompute() {
 	if !ptc.Frob.IsEmpty() {
 		ptc.Frob.Params = playerTargetableScriptParams
+		ptc.Frob.SetOwner(ptc.Entity, ptc.PlayerTargetable, "PlayerTargetable.Frob")
 		ptc.Frob.Compile()
 	}
 	if !ptc.Selected.IsEmpty() {
 		ptc.Selected.Params = playerTargetableScriptParams
+		ptc.Selected.SetOwner(ptc.Entity, ptc.PlayerTargetable, "PlayerTargetable.Selected")
 		ptc.Selected.Compile()
 	}
 	if !ptc.UnSelected.IsEmpty() {
 		ptc.UnSelected.Params = playerTargetableScriptParams
+		ptc.UnSelected.SetOwner(ptc.Entity, ptc.PlayerTargetable, "PlayerTargetable.UnSelected")
 		ptc.UnSelected.Compile()
 	}
 	var err error
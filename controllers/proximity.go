@@ -46,14 +46,17 @@ var proximityScriptParams = []core.ScriptParam{
 func (pc *ProximityController) Precompute() {
 	if !pc.InRange.IsEmpty() {
 		pc.InRange.Params = proximityScriptParams
+		pc.InRange.SetOwner(pc.Entity, pc.Proximity, "Proximity.InRange")
 		pc.InRange.Compile()
 	}
 	if !pc.Enter.IsEmpty() {
 		pc.Enter.Params = proximityScriptParams
+		pc.Enter.SetOwner(pc.Entity, pc.Proximity, "Proximity.Enter")
 		pc.Enter.Compile()
 	}
 	if !pc.Exit.IsEmpty() {
 		pc.Exit.Params = proximityScriptParams
+		pc.Exit.SetOwner(pc.Entity, pc.Proximity, "Proximity.Exit")
 		pc.Exit.Compile()
 	}
 }
@@ -98,8 +101,11 @@ func (pc *ProximityController) react(target ecs.Entity) {
 		return
 	}
 	state.LastFired = ecs.Simulation.SimTimestamp
-	if state.PrevStatus == behaviors.ProximityIdle && pc.Enter.IsCompiled() {
-		pc.actScript(&pc.Enter)
+	if state.PrevStatus == behaviors.ProximityIdle {
+		EventProximityEnter.Publish(pc.Entity, &ProximityEventParams{Source: pc.Entity, Target: target})
+		if pc.Enter.IsCompiled() {
+			pc.actScript(&pc.Enter)
+		}
 	}
 	state.Status = behaviors.ProximityFiring
 
@@ -210,6 +216,9 @@ func (pc *ProximityController) Frame() {
 			return true
 		}
 		if state.Status == behaviors.ProximityIdle {
+			if state.PrevStatus != behaviors.ProximityIdle {
+				EventProximityExit.Publish(pc.Entity, &ProximityEventParams{Source: pc.Entity, Target: state.Target})
+			}
 			if state.PrevStatus != behaviors.ProximityIdle && pc.Exit.IsCompiled() {
 				if state.Flags&behaviors.ProximityOnBody != 0 {
 					pc.TargetBody = core.GetBody(state.Target)
@@ -5,7 +5,6 @@ package controllers
 
 import (
 	"math"
-	"math/rand/v2"
 	"tlyakhov/gofoom/components/behaviors"
 	"tlyakhov/gofoom/components/character"
 	"tlyakhov/gofoom/components/core"
@@ -76,16 +75,19 @@ func (pc *PursuerController) Target(target ecs.Component, e ecs.Entity) bool {
 	return true
 }
 
+// Players that aren't spawn points.
+var spawnedPlayers = ecs.NewView1[character.Player](behaviors.SpawnerCID)
+
 func (pc *PursuerController) getPlayer() *character.Player {
-	arena := ecs.ArenaFor[character.Player](character.PlayerCID)
-	for i := range arena.Cap() {
-		player := arena.Value(i)
-		if player == nil || !player.IsActive() || behaviors.GetSpawner(player.Entity) != nil {
-			continue
+	var result *character.Player
+	spawnedPlayers.Each(func(e ecs.Entity, player *character.Player) bool {
+		if !player.IsActive() {
+			return true
 		}
-		return player
-	}
-	return nil
+		result = player
+		return false
+	})
+	return result
 }
 
 func (pc *PursuerController) getObstacleBodies() []*core.Body {
@@ -132,7 +134,7 @@ func (pc *PursuerController) targetOutOfView(enemy *behaviors.PursuerEnemy) {
 
 	b := enemy.Breadcrumbs.PeekMax()
 	enemy.Pos = &b.Data.Pos
-	radius := pc.Body.Size.Now[0] * 0.75
+	radius := pc.Body.CollisionSize.Now[0] * 0.75
 	if pc.Body.Pos.Now.DistSq(&b.Data.Pos) < radius*radius {
 		r := enemy.Breadcrumbs.RemoveMin()
 		r.Data.TargetTime = ecs.Simulation.SimTimestamp
@@ -181,7 +183,7 @@ func (pc *PursuerController) targetEnemy(enemy *behaviors.PursuerEnemy) {
 		if ecs.Simulation.SimTimestamp-latest.Key < constants.PursuitBreadcrumbRateNs {
 			return
 		}
-		radius := pc.Body.Size.Now[0] * 0.5
+		radius := pc.Body.CollisionSize.Now[0] * 0.5
 		if enemy.Body.Pos.Now.DistSq(&latest.Data.Pos) < radius*radius {
 			return
 		}
@@ -344,7 +346,7 @@ func (pc *PursuerController) pruneBreadcrumbs() {
 func (pc *PursuerController) Frame() {
 	if pc.ClockwiseSwitchTime == 0 || ecs.Simulation.SimTimestamp > pc.ClockwiseSwitchTime {
 		pc.ClockwisePreference = !pc.ClockwisePreference
-		pc.ClockwiseSwitchTime = ecs.Simulation.SimTimestamp + concepts.MillisToNanos(5000+10000*rand.Float64())
+		pc.ClockwiseSwitchTime = ecs.Simulation.SimTimestamp + concepts.MillisToNanos(5000+10000*ecs.Simulation.Rand.Float64())
 	}
 	pc.pruneBreadcrumbs()
 	pc.populateEnemies()
@@ -374,7 +376,7 @@ func (pc *PursuerController) fire() {
 			if carrier.SelectedWeapon != 0 {
 				if weapon := inventory.GetWeapon(carrier.SelectedWeapon); weapon != nil {
 					weapon.Intent = inventory.WeaponFire
-					pc.NextFireTime = ecs.Simulation.SimTimestamp + concepts.MillisToNanos(pc.FireDelay*0.5) + concepts.MillisToNanos(rand.Float64()*pc.FireDelay)
+					pc.NextFireTime = ecs.Simulation.SimTimestamp + concepts.MillisToNanos(pc.FireDelay*0.5) + concepts.MillisToNanos(ecs.Simulation.Rand.Float64()*pc.FireDelay)
 				}
 			}
 		}
@@ -0,0 +1,116 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tlyakhov/gofoom/components/behaviors"
+	"tlyakhov/gofoom/constants"
+	"tlyakhov/gofoom/ecs"
+)
+
+// Saving, continuing, and loading then continuing again should end up in
+// exactly the same state.
+func TestSaveGameContinues(t *testing.T) {
+	dir := t.TempDir()
+	savePath := filepath.Join(dir, "save.yaml")
+
+	ecs.Initialize()
+	ecs.Simulation.SetSeed(1)
+	CreateTestWorld2()
+	eSector := ecs.GetEntityByName("sector3")
+	door := ecs.NewAttachedComponent(eSector, behaviors.DoorCID).(*behaviors.Door)
+	door.Type = behaviors.DoorTypeVertical
+	door.Intent = behaviors.DoorIntentClosed
+	// Round trip through a world file, like the game would.
+	worldPath := filepath.Join(dir, "world.yaml")
+	ecs.Save(worldPath)
+	ecs.Initialize()
+	ecs.Simulation.SetSeed(1)
+	if err := ecs.Load(worldPath); err != nil {
+		t.Fatal(err)
+	}
+	RespawnAll()
+	RunHeadless(100)
+
+	// Make sure we have some damage cooling down when we save.
+	arena := ecs.ArenaFor[behaviors.Alive](behaviors.AliveCID)
+	for i := range arena.Cap() {
+		if a := arena.Value(i); a != nil {
+			a.Hurt("test", 5, 50)
+		}
+	}
+	if err := ecs.SaveGame(savePath); err != nil {
+		t.Fatal(err)
+	}
+
+	RunHeadless(200)
+	expected := saveGameBytes(t, filepath.Join(dir, "expected.yaml"))
+
+	if err := ecs.LoadGame(savePath); err != nil {
+		t.Fatal(err)
+	}
+	RunHeadless(200)
+	actual := saveGameBytes(t, filepath.Join(dir, "actual.yaml"))
+
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("State after loading doesn't match.\nExpected:\n%s\nActual:\n%s", expected, actual)
+	}
+}
+
+func saveGameBytes(t *testing.T, path string) []byte {
+	if err := ecs.SaveGame(path); err != nil {
+		t.Fatal(err)
+	}
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes
+}
+
+// Timers in components should keep counting down where they left off.
+func TestSaveGameTimers(t *testing.T) {
+	dir := t.TempDir()
+	worldPath := filepath.Join(dir, "world.yaml")
+	savePath := filepath.Join(dir, "save.yaml")
+
+	ecs.Initialize()
+	CreateTestWorld2()
+	ecs.Save(worldPath)
+	ecs.Initialize()
+	if err := ecs.Load(worldPath); err != nil {
+		t.Fatal(err)
+	}
+	e := ecs.NewEntity()
+	state := ecs.NewAttachedComponent(e, behaviors.ActorStateCID).(*behaviors.ActorState)
+	state.Delay.Schedule(ecs.Simulation, 10*constants.TimeStepNS, 0)
+	RunHeadless(4)
+	if err := ecs.SaveGame(savePath); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ecs.LoadGame(savePath); err != nil {
+		t.Fatal(err)
+	}
+	state = behaviors.GetActorState(e)
+	if state == nil {
+		t.Fatal("ActorState wasn't restored")
+	}
+	if remaining := state.Delay.Remaining(ecs.Simulation); remaining != 6*constants.TimeStepNS {
+		t.Errorf("Expected %v ns remaining, got %v", 6*constants.TimeStepNS, remaining)
+	}
+	RunHeadless(6)
+	if state.Delay.Expired() {
+		t.Errorf("Timer expired early")
+	}
+	RunHeadless(1)
+	if !state.Delay.Expired() {
+		t.Errorf("Timer didn't expire after loading")
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/ecs"
+)
+
+type ScriptLibraryController struct {
+	ecs.BaseController
+	*core.ScriptLibrary
+}
+
+func init() {
+	// Libraries have to be evaluated before any other scripts are compiled.
+	ecs.Types().RegisterController(func() ecs.Controller { return &ScriptLibraryController{} }, 10)
+}
+
+func (slc *ScriptLibraryController) ComponentID() ecs.ComponentID {
+	return core.ScriptLibraryCID
+}
+
+func (slc *ScriptLibraryController) Methods() ecs.ControllerMethod {
+	return ecs.ControllerPrecompute
+}
+
+func (slc *ScriptLibraryController) EditorPausedMethods() ecs.ControllerMethod {
+	return ecs.ControllerPrecompute
+}
+
+func (slc *ScriptLibraryController) Target(target ecs.Component, e ecs.Entity) bool {
+	slc.Entity = e
+	slc.ScriptLibrary = target.(*core.ScriptLibrary)
+	return slc.ScriptLibrary.IsActive()
+}
+
+func (slc *ScriptLibraryController) Precompute() {
+	// Compiling one library evaluates all of them, so only do it once.
+	if !slc.NeedsCompile() {
+		return
+	}
+	slc.Library.SetOwner(slc.Entity, slc.ScriptLibrary, "ScriptLibrary")
+	slc.Library.Compile()
+}
@@ -41,20 +41,41 @@ var scriptedScriptParams = []core.ScriptParam{
 func (sc *ScriptedController) Precompute() {
 	if !sc.OnFrame.IsEmpty() {
 		sc.OnFrame.Params = scriptedScriptParams
+		sc.OnFrame.SetOwner(sc.Entity, sc.Scripted, "Scripted.OnFrame")
 		sc.OnFrame.Compile()
 	}
+
+	scripted := sc.Scripted
+	scripted.Ticker.OnExpiry = func() {
+		// Shared components run the script for every entity.
+		for _, e := range scripted.Entities {
+			if e != 0 && scripted.IsActive() {
+				runScripted(scripted, e)
+			}
+		}
+	}
+	interval := concepts.MillisToNanos(sc.Timer)
+	switch {
+	case sc.Timer <= 0:
+		sc.Ticker.Cancel()
+	case !sc.Ticker.Running || sc.Ticker.Interval != interval:
+		sc.Ticker.Schedule(ecs.Simulation, interval, interval)
+	}
 }
 
-func (sc *ScriptedController) Frame() {
-	if !sc.OnFrame.IsCompiled() {
+func runScripted(scripted *core.Scripted, e ecs.Entity) {
+	if !scripted.OnFrame.IsCompiled() {
 		return
 	}
-	if sc.Timer != 0 && sc.TimerStart+concepts.MillisToNanos(sc.Timer) > ecs.Simulation.SimTimestamp {
-		return
+	scripted.OnFrame.Vars["scripted"] = scripted
+	scripted.OnFrame.Vars["onEntity"] = e
+	scripted.OnFrame.Vars["args"] = scripted.Args
+	scripted.OnFrame.Act()
+}
+
+func (sc *ScriptedController) Frame() {
+	// Otherwise, the ticker runs the script.
+	if sc.Timer <= 0 {
+		runScripted(sc.Scripted, sc.Entity)
 	}
-	sc.TimerStart = ecs.Simulation.SimTimestamp
-	sc.OnFrame.Vars["scripted"] = sc.Scripted
-	sc.OnFrame.Vars["onEntity"] = sc.Entity
-	sc.OnFrame.Vars["args"] = sc.Args
-	sc.OnFrame.Act()
 }
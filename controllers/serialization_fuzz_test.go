@@ -0,0 +1,219 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tlyakhov/gofoom/ecs"
+)
+
+// componentRandomizer fills in component fields with random values, keeping
+// track of which ones are in-memory state (see ecs.SerializeComponent).
+type componentRandomizer struct {
+	rng *rand.Rand
+	// Paths to the non-editable fields that were randomized.
+	cached [][]int
+	// Names of the fields that were left alone, see value.
+	skipped []string
+}
+
+var (
+	attachedType              = reflect.TypeFor[ecs.Attached]()
+	attachedWithIndirectsType = reflect.TypeFor[ecs.AttachedWithIndirects]()
+	entityTableType           = reflect.TypeFor[ecs.EntityTable]()
+	entityType                = reflect.TypeFor[ecs.Entity]()
+)
+
+func (r *componentRandomizer) float() float64 {
+	// Nice round numbers, to avoid precision issues with float32 fields.
+	return float64(r.rng.IntN(20000)-10000) / 8
+}
+
+func (r *componentRandomizer) entity() ecs.Entity {
+	return ecs.Entity(r.rng.IntN(100) + 1)
+}
+
+// value sets v to a random value, if it's a type we know how to handle.
+// Returns false otherwise. Strings with an edit_type (files, scripts, etc.),
+// maps, and slices other than entities and floats are skipped.
+func (r *componentRandomizer) value(v reflect.Value, field *reflect.StructField) bool {
+	if stringer, ok := v.Interface().(fmt.Stringer); ok && v.CanInt() || ok && v.CanUint() {
+		// Probably an enum, only pick valid values.
+		for range 10 {
+			candidate := reflect.New(v.Type()).Elem()
+			if v.CanInt() {
+				candidate.SetInt(int64(r.rng.IntN(8)))
+			} else {
+				candidate.SetUint(uint64(r.rng.IntN(8)))
+			}
+			stringer = candidate.Interface().(fmt.Stringer)
+			if !strings.Contains(stringer.String(), "(") {
+				v.Set(candidate)
+				return true
+			}
+		}
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.rng.IntN(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(r.rng.IntN(100)))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(r.rng.IntN(100) + 1))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(r.float())
+	case reflect.String:
+		// Other kinds of strings (files, scripts, etc.) have side effects.
+		if field.Tag.Get("edit_type") != "" {
+			return false
+		}
+		v.SetString(fmt.Sprintf("random %v", r.rng.IntN(1000)))
+	case reflect.Array:
+		if v.Type().Elem().Kind() != reflect.Float64 {
+			return false
+		}
+		for i := range v.Len() {
+			v.Index(i).SetFloat(r.float())
+		}
+	case reflect.Slice:
+		n := r.rng.IntN(4) + 1
+		switch {
+		case v.Type() == entityTableType:
+			var table ecs.EntityTable
+			for range n {
+				table.Set(r.entity())
+			}
+			v.Set(reflect.ValueOf(table))
+		case v.Type().Elem() == entityType:
+			entities := reflect.MakeSlice(v.Type(), n, n)
+			for i := range n {
+				entities.Index(i).Set(reflect.ValueOf(r.entity()))
+			}
+			v.Set(entities)
+		case v.Type().Elem().Kind() == reflect.Float64:
+			floats := reflect.MakeSlice(v.Type(), n, n)
+			for i := range n {
+				floats.Index(i).SetFloat(r.float())
+			}
+			v.Set(floats)
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// randomize walks the fields of a struct, setting editable fields and
+// in-memory state to random values.
+func (r *componentRandomizer) randomize(v reflect.Value, path []int) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() || field.Type == attachedType || field.Type == attachedWithIndirectsType {
+			continue
+		}
+		fv := v.Field(i)
+		fieldPath := append(append([]int{}, path...), i)
+		editable := field.Tag.Get("editable") != ""
+		if fv.Kind() == reflect.Struct {
+			// Dynamic values
+			if setAll := fv.Addr().MethodByName("SetAll"); setAll.IsValid() && editable {
+				arg := reflect.New(setAll.Type().In(0)).Elem()
+				if r.value(arg, &field) {
+					setAll.Call([]reflect.Value{arg})
+				}
+				continue
+			}
+			if ecs.FieldFlagsFromTag(field.Tag)&(ecs.FieldNonCacheable|ecs.FieldShallowCacheable) == 0 {
+				r.randomize(fv, fieldPath)
+			}
+			continue
+		}
+		if editable {
+			if !r.value(fv, &field) {
+				r.skipped = append(r.skipped, field.Name)
+			}
+		} else if ecs.FieldFlagsFromTag(field.Tag)&ecs.FieldNonCacheable == 0 {
+			if r.value(fv, &field) {
+				r.cached = append(r.cached, fieldPath)
+			} else {
+				r.skipped = append(r.skipped, field.Name)
+			}
+		}
+	}
+}
+
+func newRandomComponent(cid ecs.ComponentID, rng *rand.Rand) (ecs.Component, *componentRandomizer) {
+	c := ecs.Types().ArenaPlaceholders[cid].New()
+	c.Construct(nil)
+	r := &componentRandomizer{rng: rng}
+	r.randomize(reflect.ValueOf(c).Elem(), nil)
+	return c, r
+}
+
+// Randomized components should survive saving and loading, and saving again
+// should give the same result.
+func TestComponentRoundTripRandomized(t *testing.T) {
+	ecs.Initialize()
+	for cid, placeholder := range ecs.Types().ArenaPlaceholders {
+		if cid == 0 || placeholder == nil {
+			continue
+		}
+		t.Run(placeholder.String(), func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(uint64(cid), 1))
+			for i := range 20 {
+				c, r := newRandomComponent(ecs.ComponentID(cid), rng)
+				if i == 0 && len(r.skipped) > 0 {
+					t.Logf("Not randomized: %v", strings.Join(r.skipped, ", "))
+				}
+				testComponentRoundTrip(t, c)
+				if t.Failed() {
+					// Don't spam the same failure
+					return
+				}
+			}
+		})
+	}
+}
+
+// In-memory state should be preserved by snapshots, like undo/redo.
+func TestComponentSnapshotCachedFields(t *testing.T) {
+	ecs.Initialize()
+	for cid, placeholder := range ecs.Types().ArenaPlaceholders {
+		if cid == 0 || placeholder == nil {
+			continue
+		}
+		t.Run(placeholder.String(), func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(uint64(cid), 2))
+			for range 20 {
+				c, r := newRandomComponent(ecs.ComponentID(cid), rng)
+				data := ecs.SerializeComponent(c, true)
+				restored := placeholder.New()
+				ecs.ConstructComponent(restored, data, true)
+
+				original := reflect.ValueOf(c).Elem()
+				actual := reflect.ValueOf(restored).Elem()
+				for _, path := range r.cached {
+					expected := original.FieldByIndex(path)
+					if got := actual.FieldByIndex(path); !reflect.DeepEqual(expected.Interface(), got.Interface()) {
+						t.Errorf("%v: field %v wasn't restored, expected %v, got %v",
+							placeholder.String(), original.Type().FieldByIndex(path).Name, expected, got)
+					}
+				}
+				if t.Failed() {
+					return
+				}
+			}
+		})
+	}
+}
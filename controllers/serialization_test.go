@@ -0,0 +1,104 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/components/materials"
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/ecs"
+)
+
+// normalizeComponentData round-trips serialized data through JSON, like
+// saving and loading a world would.
+func normalizeComponentData(t *testing.T, data map[string]any) map[string]any {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result map[string]any
+	if err = json.Unmarshal(bytes, &result); err != nil {
+		t.Fatal(err)
+	}
+	// Attachments aren't part of the component itself.
+	delete(result, "Entities")
+	return result
+}
+
+func testComponentRoundTrip(t *testing.T, c ecs.Component) {
+	cid := c.ComponentID()
+	name := ecs.Types().ArenaPlaceholders[cid].String()
+	expected := normalizeComponentData(t, c.Serialize())
+	loaded := ecs.LoadComponentWithoutAttaching(cid, expected)
+	actual := normalizeComponentData(t, loaded.Serialize())
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("%v didn't round-trip:\nexpected %v\ngot      %v", name, expected, actual)
+	}
+}
+
+// Every registered component should survive being serialized and constructed
+// again, both with default values and as set up in a world.
+func TestComponentRoundTrip(t *testing.T) {
+	ecs.Initialize()
+	for cid, placeholder := range ecs.Types().ArenaPlaceholders {
+		if cid == 0 || placeholder == nil {
+			continue
+		}
+		c := placeholder.New()
+		c.Construct(nil)
+		testComponentRoundTrip(t, c)
+	}
+
+	CreateTestWorld2()
+	for cid := range ecs.Types().ArenaPlaceholders {
+		if cid == 0 {
+			continue
+		}
+		arena := ecs.ArenaByID(ecs.ComponentID(cid))
+		for i := range arena.Cap() {
+			if c := arena.Component(i); c != nil && c.Base().Flags&ecs.ComponentNoSave == 0 {
+				testComponentRoundTrip(t, c)
+			}
+		}
+	}
+}
+
+// Worlds saved before a component's migrations were registered should load
+// with their data upgraded.
+func TestComponentMigrations(t *testing.T) {
+	ecs.Initialize()
+	// No header, so everything is version 0.
+	old := `- Entity: ∈⋮2
+  core.Body:
+    Pos: 1, 2, 3
+    Size: 4, 6
+  materials.Visible:
+    Ambient: 0.5
+`
+	path := filepath.Join(t.TempDir(), "old.yaml")
+	if err := os.WriteFile(path, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ecs.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	e, _ := ecs.ParseEntity("∈⋮2")
+	body := core.GetBody(e)
+	if body == nil {
+		t.Fatalf("Expected %v to have a body", e)
+	}
+	if body.Size.Spawn != (concepts.Vector2{4, 6}) || body.CollisionSize.Spawn != (concepts.Vector2{4, 6}) {
+		t.Errorf("Expected Size to be split, got %v and %v", body.Size.Spawn, body.CollisionSize.Spawn)
+	}
+	visible := materials.GetVisible(e)
+	if visible == nil || visible.Opacity != 0.5 {
+		t.Errorf("Expected Ambient to be renamed to Opacity, got %v", visible)
+	}
+}
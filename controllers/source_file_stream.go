@@ -0,0 +1,145 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"log"
+
+	"tlyakhov/gofoom/components/character"
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/ecs"
+)
+
+// SourceFileStreamController loads and unloads streamed files depending on
+// how many portals away the player is from their anchors. Files are unloaded
+// one portal further away than they're loaded, to avoid thrashing when the
+// player walks back and forth.
+type SourceFileStreamController struct {
+	ecs.BaseController
+	*ecs.SourceFile
+
+	// Distances (in portals) from the player's sector, shared between all the
+	// files in a frame. The search is expanded as needed, and restarted when
+	// searched is behind the simulation.
+	distances map[ecs.Entity]int
+	frontier  []ecs.Entity
+	depth     int
+	searched  int64
+}
+
+func init() {
+	ecs.Types().RegisterController(func() ecs.Controller { return &SourceFileStreamController{} }, 100)
+}
+
+func (sfc *SourceFileStreamController) ComponentID() ecs.ComponentID {
+	return ecs.SourceFileCID
+}
+
+func (sfc *SourceFileStreamController) Methods() ecs.ControllerMethod {
+	return ecs.ControllerFrame
+}
+
+func (sfc *SourceFileStreamController) Target(target ecs.Component, e ecs.Entity) bool {
+	sfc.Entity = e
+	sfc.SourceFile = target.(*ecs.SourceFile)
+	return sfc.IsActive() && sfc.Streamed && ecs.StreamIncludes
+}
+
+func (sfc *SourceFileStreamController) playerSector() ecs.Entity {
+	var result ecs.Entity
+	spawnedPlayers.Each(func(e ecs.Entity, player *character.Player) bool {
+		if !player.IsActive() {
+			return true
+		}
+		if body := core.GetBody(e); body != nil {
+			result = body.SectorEntity
+		}
+		return false
+	})
+	return result
+}
+
+// distance returns the number of portals between the player and a sector, or
+// -1 if it's further than limit.
+func (sfc *SourceFileStreamController) distance(sector ecs.Entity, limit int) int {
+	if sfc.distances == nil || sfc.searched != ecs.Simulation.SimTimestamp {
+		sfc.distances = make(map[ecs.Entity]int)
+		sfc.frontier = sfc.frontier[:0]
+		sfc.depth = 0
+		sfc.searched = ecs.Simulation.SimTimestamp
+		if start := sfc.playerSector(); start != 0 {
+			sfc.distances[start] = 0
+			sfc.frontier = append(sfc.frontier, start)
+		}
+	}
+	for {
+		if d, ok := sfc.distances[sector]; ok {
+			return d
+		}
+		if sfc.depth >= limit || len(sfc.frontier) == 0 {
+			return -1
+		}
+		// Expand the search by one portal.
+		sfc.depth++
+		next := make([]ecs.Entity, 0, len(sfc.frontier))
+		for _, e := range sfc.frontier {
+			s := core.GetSector(e)
+			if s == nil {
+				continue
+			}
+			for _, seg := range s.Segments {
+				if seg.AdjacentSector == 0 {
+					continue
+				}
+				if _, ok := sfc.distances[seg.AdjacentSector]; ok {
+					continue
+				}
+				sfc.distances[seg.AdjacentSector] = sfc.depth
+				next = append(next, seg.AdjacentSector)
+			}
+		}
+		sfc.frontier = next
+	}
+}
+
+// nearby checks whether the player is within limit portals of any of the
+// file's anchors, or in one of the file's own sectors.
+func (sfc *SourceFileStreamController) nearby(limit int) bool {
+	if sfc.Loaded {
+		if start := sfc.playerSector(); start != 0 && start.SourceID() == sfc.ID {
+			return true
+		}
+	}
+	for _, e := range sfc.Anchors {
+		if e == 0 {
+			continue
+		}
+		if d := sfc.distance(e, limit); d >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (sfc *SourceFileStreamController) Frame() {
+	switch {
+	case sfc.IsStreaming():
+		if _, err := sfc.FinishStreaming(); err != nil {
+			log.Printf("SourceFileStreamController: %v", err)
+		}
+	case sfc.Loaded:
+		if !sfc.nearby(sfc.StreamDistance + 1) {
+			sfc.StreamOut()
+		}
+	default:
+		if sfc.nearby(sfc.StreamDistance) {
+			sfc.StreamIn()
+			// Reads are synchronous in headless simulations, so we might be
+			// able to finish right away.
+			if _, err := sfc.FinishStreaming(); err != nil {
+				log.Printf("SourceFileStreamController: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tlyakhov/gofoom/ecs"
+)
+
+const streamedChunk = `
+- Entity: ∈⋮1
+  ecs.Named:
+    Name: chunk thing
+`
+
+func TestSourceFileStreaming(t *testing.T) {
+	dir := t.TempDir()
+	chunkPath := filepath.Join(dir, "chunk.yaml")
+	if err := os.WriteFile(chunkPath, []byte(streamedChunk), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ecs.Initialize()
+	CreateTestWorld2()
+	include := ecs.NewAttachedComponent(ecs.NewEntity(), ecs.SourceFileCID).(*ecs.SourceFile)
+	include.Source = "chunk.yaml"
+	include.Streamed = true
+	include.StreamDistance = 1
+	include.Anchors = ecs.EntityTable{ecs.GetEntityByName("sector3")}
+	worldPath := filepath.Join(dir, "world.yaml")
+	ecs.Save(worldPath)
+	ecs.Initialize()
+	if err := ecs.Load(worldPath); err != nil {
+		t.Fatal(err)
+	}
+	RespawnAll()
+
+	include = ecs.SourceFileNames["chunk.yaml"]
+	if include == nil || include.ID == 0 {
+		t.Fatalf("Streamed file should be mapped after loading the world")
+	}
+	if include.Loaded || ecs.GetEntityByName("chunk thing") != 0 {
+		t.Fatalf("Streamed file shouldn't be loaded before the player is nearby")
+	}
+
+	// The player is in sector1, two portals away from sector3.
+	RunHeadless(1)
+	if include.Loaded {
+		t.Errorf("Streamed file shouldn't load when the player is further than StreamDistance")
+	}
+	include.StreamDistance = 2
+	RunHeadless(1)
+	loaded := ecs.GetEntityByName("chunk thing")
+	if !include.Loaded || loaded == 0 {
+		t.Fatalf("Streamed file should load when the player is within StreamDistance")
+	}
+	if loaded.SourceID() != include.ID {
+		t.Errorf("Expected streamed entity to have source ID %v, got %v", include.ID, loaded.SourceID())
+	}
+
+	include.StreamDistance = 0
+	RunHeadless(1)
+	if include.Loaded || ecs.GetEntityByName("chunk thing") != 0 {
+		t.Fatalf("Streamed file should unload when the player moves away")
+	}
+	if ecs.SourceFileIDs[include.ID] != include {
+		t.Errorf("Streamed file should keep its ID after unloading")
+	}
+
+	include.StreamDistance = 2
+	RunHeadless(1)
+	if reloaded := ecs.GetEntityByName("chunk thing"); reloaded != loaded {
+		t.Errorf("Expected streamed entity to be reloaded as %v, got %v", loaded, reloaded)
+	}
+}
@@ -6,7 +6,6 @@ package controllers
 import (
 	"fmt"
 	"log"
-	"math/rand"
 	"tlyakhov/gofoom/components/behaviors"
 	"tlyakhov/gofoom/components/core"
 	"tlyakhov/gofoom/components/inventory"
@@ -117,11 +116,42 @@ func DeleteSpawned(s *behaviors.Spawner) {
 	s.Spawned = make(map[ecs.Entity]int64)
 }
 
-func Spawn(s *behaviors.Spawner) ecs.Entity {
+func spawnPrefab(s *behaviors.Spawner) ecs.Entity {
+	e := ecs.NewEntity()
+	pi := ecs.NewAttachedComponent(e, ecs.PrefabInstanceCID).(*ecs.PrefabInstance)
+	pi.Source = s.Prefab
+	if err := pi.Instantiate(); err != nil {
+		log.Printf("controllers.Spawn: error instantiating prefab for %v: %v", s.Entity, err)
+		ecs.Delete(e)
+		return 0
+	}
+	if spawnerBody := core.GetBody(s.Entity); spawnerBody != nil {
+		if body := core.GetBody(e); body != nil {
+			body.Pos.SetAll(spawnerBody.Pos.Now)
+			body.Angle.SetAll(spawnerBody.Angle.Now)
+		}
+	}
+	spawned := []ecs.Entity{e}
+	for _, member := range pi.Members {
+		spawned = append(spawned, member)
+	}
+	for _, spawnedEntity := range spawned {
+		for _, c := range ecs.AllComponents(spawnedEntity) {
+			if c != nil {
+				c.Base().Flags |= ecs.ComponentHideEntityInEditor
+			}
+		}
+		ecs.ActAllControllersOneEntity(spawnedEntity, ecs.ControllerPrecompute)
+		ecs.ActAllControllersOneEntity(spawnedEntity, ecs.ControllerFrame)
+	}
+	return e
+}
+
+func spawnClone(s *behaviors.Spawner) ecs.Entity {
 	// Pick a random spawner
 	randomSpawner := s.Entity
 	if s.Targets.Len() > 0 {
-		picked := rand.Int() % s.Targets.Len()
+		picked := ecs.Simulation.Rand.Int() % s.Targets.Len()
 		i := 0
 		for _, e := range s.Targets {
 			if e == 0 {
@@ -159,6 +189,16 @@ func Spawn(s *behaviors.Spawner) ecs.Entity {
 			}
 			return true
 		})
+	return pastedEntity
+}
+
+func Spawn(s *behaviors.Spawner) ecs.Entity {
+	var pastedEntity ecs.Entity
+	if s.Prefab != "" {
+		pastedEntity = spawnPrefab(s)
+	} else {
+		pastedEntity = spawnClone(s)
+	}
 
 	if pastedEntity != 0 {
 		s.Spawned[pastedEntity] = ecs.Simulation.SimTimestamp
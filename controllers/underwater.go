@@ -29,6 +29,15 @@ func (uc *UnderwaterController) Methods() ecs.ControllerMethod {
 	return ecs.ControllerFrame | ecs.ControllerPrecompute
 }
 
+var underwaterAccess = ecs.ControllerAccess{
+	Reads:  []ecs.ComponentID{core.SectorCID},
+	Writes: []ecs.ComponentID{core.MobileCID},
+}
+
+func (uc *UnderwaterController) Access() *ecs.ControllerAccess {
+	return &underwaterAccess
+}
+
 func (uc *UnderwaterController) Target(target ecs.Component, e ecs.Entity) bool {
 	uc.Entity = e
 	uc.Underwater = target.(*behaviors.Underwater)
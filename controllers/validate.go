@@ -0,0 +1,178 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"math"
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/ecs"
+)
+
+const (
+	// A sector doesn't form a valid polygon (too few points, zero length
+	// segments or zero area).
+	ProblemOpenSector ecs.ProblemKind = "open-sector"
+	// A portal doesn't have a matching segment in the adjacent sector, or the
+	// matching segment doesn't lead back.
+	ProblemMismatchedPortal ecs.ProblemKind = "mismatched-portal"
+)
+
+// rangePortalsTo calls f for every segment of another sector that leads to
+// this one.
+func rangePortalsTo(sector *core.Sector, f func(seg *core.SectorSegment)) {
+	arena := ecs.ArenaFor[core.Sector](core.SectorCID)
+	for i := range arena.Cap() {
+		other := arena.Value(i)
+		if other == nil || other == sector {
+			continue
+		}
+		for _, seg := range other.Segments {
+			if seg.AdjacentSector == sector.Entity {
+				f(seg)
+			}
+		}
+	}
+}
+
+// isPortalTarget checks whether another sector's portal leads to the segment
+// at index i.
+func isPortalTarget(sector *core.Sector, i int) bool {
+	target := false
+	rangePortalsTo(sector, func(seg *core.SectorSegment) {
+		if seg.AdjacentSegment == sector.Segments[i] ||
+			(seg.PortalTeleports && seg.AdjacentSegmentIndex == i) {
+			target = true
+		}
+	})
+	return target
+}
+
+// validateSectorPolygon checks for degenerate sector geometry, removing
+// repeated points if fix is set. Segments that other sectors' portals lead to
+// are left alone, and teleporting portals to later segments are renumbered.
+func validateSectorPolygon(sector *core.Sector, fix bool) []ecs.Problem {
+	problems := make([]ecs.Problem, 0)
+	for i := 0; i < len(sector.Segments) && len(sector.Segments) > 1; i++ {
+		seg := sector.Segments[i]
+		next := sector.Segments[(i+1)%len(sector.Segments)]
+		if seg.P.Spawn.Dist(&next.P.Spawn) > 1e-4 {
+			continue
+		}
+		canFix := fix && !isPortalTarget(sector, i)
+		problems = append(problems, ecs.NewProblem(ProblemOpenSector, sector.Entity, sector, "Segments", canFix,
+			"segment %v has zero length at %v", i, seg.P.Spawn.StringHuman()))
+		if canFix {
+			sector.Segments = append(sector.Segments[:i], sector.Segments[i+1:]...)
+			rangePortalsTo(sector, func(other *core.SectorSegment) {
+				if other.PortalTeleports && other.AdjacentSegmentIndex > i {
+					other.AdjacentSegmentIndex--
+				}
+			})
+			i--
+		}
+	}
+	if len(sector.Segments) < 3 {
+		problems = append(problems, ecs.NewProblem(ProblemOpenSector, sector.Entity, sector, "Segments", false,
+			"has %v segments, needs at least 3", len(sector.Segments)))
+		return problems
+	}
+	area := 0.0
+	for i, seg := range sector.Segments {
+		next := sector.Segments[(i+1)%len(sector.Segments)]
+		area += seg.P.Spawn[0]*next.P.Spawn[1] - next.P.Spawn[0]*seg.P.Spawn[1]
+	}
+	if math.Abs(area) < 1e-4 {
+		problems = append(problems, ecs.NewProblem(ProblemOpenSector, sector.Entity, sector, "Segments", false,
+			"has zero area"))
+	}
+	return problems
+}
+
+// validateSectorPortals checks that every portal segment has a matching
+// segment in the adjacent sector that leads back. If fix is set, portals
+// without a match are removed, and one-way matches are wired up in both
+// directions.
+func validateSectorPortals(sector *core.Sector, fix bool) []ecs.Problem {
+	problems := make([]ecs.Problem, 0)
+	for i, seg := range sector.Segments {
+		if seg.AdjacentSector == 0 {
+			if fix {
+				seg.AdjacentSegment = nil
+			}
+			continue
+		}
+		adj := core.GetSector(seg.AdjacentSector)
+		if adj == nil {
+			// Reported as a broken reference, or the entity just isn't a
+			// sector.
+			if ecs.Entities.Contains(uint32(seg.AdjacentSector)) {
+				problems = append(problems, ecs.NewProblem(ProblemMismatchedPortal, sector.Entity, sector, "Segments", fix,
+					"segment %v leads to %v, which isn't a sector", i, seg.AdjacentSector))
+				if fix {
+					seg.AdjacentSector = 0
+					seg.AdjacentSegment = nil
+				}
+			}
+			continue
+		}
+		if seg.PortalTeleports {
+			if seg.AdjacentSegmentIndex >= len(adj.Segments) {
+				problems = append(problems, ecs.NewProblem(ProblemMismatchedPortal, sector.Entity, sector, "Segments", fix,
+					"segment %v teleports to segment %v of %v, which only has %v", i, seg.AdjacentSegmentIndex, adj.Entity, len(adj.Segments)))
+				if fix {
+					seg.AdjacentSegmentIndex = -1
+				}
+			}
+			continue
+		}
+		var match *core.SectorSegment
+		for _, s2 := range adj.Segments {
+			if s2.Matches(&seg.Segment) {
+				match = s2
+				break
+			}
+		}
+		if match == nil {
+			problems = append(problems, ecs.NewProblem(ProblemMismatchedPortal, sector.Entity, sector, "Segments", fix,
+				"segment %v leads to %v, which has no matching segment", i, adj.Entity))
+			if fix {
+				seg.AdjacentSector = 0
+				seg.AdjacentSegment = nil
+			}
+			continue
+		}
+		if match.AdjacentSector != sector.Entity {
+			problems = append(problems, ecs.NewProblem(ProblemMismatchedPortal, sector.Entity, sector, "Segments", fix,
+				"segment %v leads to %v, but the matching segment %v leads to %v", i, adj.Entity, match.Index, match.AdjacentSector))
+			if fix {
+				match.AdjacentSector = sector.Entity
+				match.AdjacentSegment = seg
+				seg.AdjacentSegment = match
+			}
+		}
+	}
+	return problems
+}
+
+// ValidateWorld checks the world for problems (see ecs.Validate), including
+// sector geometry and portals. If fix is set, anything that can be repaired
+// automatically is.
+func ValidateWorld(fix bool) []ecs.Problem {
+	problems := ecs.Validate(fix)
+	arena := ecs.ArenaFor[core.Sector](core.SectorCID)
+	for i := range arena.Cap() {
+		sector := arena.Value(i)
+		if sector == nil || sector.IsExternal() {
+			continue
+		}
+		// Portals first, since fixing the polygon invalidates segments until
+		// the next precompute.
+		problems = append(problems, validateSectorPortals(sector, fix)...)
+		problems = append(problems, validateSectorPolygon(sector, fix)...)
+	}
+	if fix {
+		ecs.ActAllControllers(ecs.ControllerPrecompute)
+	}
+	return problems
+}
@@ -0,0 +1,84 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"slices"
+	"testing"
+
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/ecs"
+)
+
+func TestValidateWorld(t *testing.T) {
+	ecs.Initialize()
+	CreateTestWorld2()
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+	if problems := ValidateWorld(false); len(problems) != 0 {
+		t.Fatalf("Expected test world to be valid, got %v", problems)
+	}
+
+	// Break one side of a portal.
+	sector := core.GetSector(ecs.GetEntityByName("sector1"))
+	var portal *core.SectorSegment
+	for _, seg := range sector.Segments {
+		if seg.AdjacentSector != 0 && seg.AdjacentSegment != nil {
+			portal = seg
+			break
+		}
+	}
+	if portal == nil {
+		t.Fatal("Expected sector1 to have a portal")
+	}
+	other := portal.AdjacentSegment
+	other.AdjacentSector = 0
+	// Add a repeated point.
+	sector.Segments[0].Split(sector.Segments[0].P.Spawn)
+
+	problems := ValidateWorld(false)
+	found := map[ecs.ProblemKind]int{}
+	for _, p := range problems {
+		found[p.Kind]++
+	}
+	if found[ProblemMismatchedPortal] != 1 || found[ProblemOpenSector] != 1 || len(problems) != 2 {
+		t.Fatalf("Expected a mismatched portal and an open sector, got %v", problems)
+	}
+
+	ValidateWorld(true)
+	if other.AdjacentSector != sector.Entity {
+		t.Errorf("Expected portal to be wired back to %v, got %v", sector.Entity, other.AdjacentSector)
+	}
+	if problems = ValidateWorld(false); len(problems) != 0 {
+		t.Errorf("Expected no problems after fixing, got %v", problems)
+	}
+}
+
+func TestValidateKeepsPortalTargets(t *testing.T) {
+	ecs.Initialize()
+	CreateTestWorld2()
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+
+	sector := core.GetSector(ecs.GetEntityByName("sector1"))
+	var portal *core.SectorSegment
+	for _, seg := range sector.Segments {
+		if seg.AdjacentSector != 0 && seg.AdjacentSegment != nil {
+			portal = seg
+			break
+		}
+	}
+	if portal == nil {
+		t.Fatal("Expected sector1 to have a portal")
+	}
+	// Splitting at its own start leaves the portal with zero length.
+	portal.Split(portal.P.Spawn)
+
+	problems := ValidateWorld(true)
+	open := slices.IndexFunc(problems, func(p ecs.Problem) bool { return p.Kind == ProblemOpenSector })
+	if open < 0 || problems[open].Fixed {
+		t.Fatalf("Expected an unfixed open sector, got %v", problems)
+	}
+	if !slices.Contains(sector.Segments, portal) {
+		t.Errorf("Expected portal segment to be kept")
+	}
+}
@@ -5,7 +5,6 @@ package controllers
 
 import (
 	"math"
-	"math/rand"
 	"tlyakhov/gofoom/components/behaviors"
 	"tlyakhov/gofoom/components/core"
 	"tlyakhov/gofoom/concepts"
@@ -61,12 +60,12 @@ func (wc *WanderController) Frame() {
 		wc.NextSector = wc.Body.SectorEntity
 	}
 
-	if ecs.Simulation.SimTimestamp-wc.LastTurn > concepts.MillisToNanos(float64(300+rand.Intn(100))) {
+	if ecs.Simulation.SimTimestamp-wc.LastTurn > concepts.MillisToNanos(float64(300+ecs.Simulation.Rand.IntN(100))) {
 		a := wc.Body.Angle.NewAnimation()
 		a.Coordinates = dynamic.AnimationCoordinatesAbsolute
 		a.Start = wc.Body.Angle.Now
 		// Bias towards the center of the sector
-		start := wc.Body.Angle.Now + rand.Float64()*60 - 30
+		start := wc.Body.Angle.Now + ecs.Simulation.Rand.Float64()*60 - 30
 		end := start
 		if sector := core.GetSector(wc.NextSector); sector != nil {
 			end = wc.Body.Angle2DTo(&sector.Center.Now)
@@ -78,7 +77,7 @@ func (wc *WanderController) Frame() {
 		a.Lifetime = dynamic.AnimationLifetimeOnce
 		wc.LastTurn = ecs.Simulation.SimTimestamp
 	}
-	if ecs.Simulation.SimTimestamp-wc.LastTarget > concepts.MillisToNanos(float64(5000+rand.Intn(5000))) {
+	if ecs.Simulation.SimTimestamp-wc.LastTarget > concepts.MillisToNanos(float64(5000+ecs.Simulation.Rand.IntN(5000))) {
 		sector := wc.Body.Sector()
 		if sector == nil {
 			return
@@ -68,6 +68,7 @@ func (wc *WeaponController) Target(target ecs.Component, e ecs.Entity) bool {
 
 func (w *WeaponController) newState(s inventory.WeaponState) {
 	//log.Printf("Weapon %v changing from state %v->%v after %vms", w.Entity, w.State, s, (ecs.Simulation.SimTimestamp-w.LastStateTimestamp)/1_000_000)
+	prev := w.State
 	w.State = s
 	w.LastStateTimestamp = ecs.Simulation.SimTimestamp
 	w.Fired = false
@@ -75,13 +76,31 @@ func (w *WeaponController) newState(s inventory.WeaponState) {
 	if p.Sound != 0 {
 		audio.PlaySound(p.Sound, w.Body.Entity, "weapon "+s.String(), audio.SoundPlayNormal)
 	}
+	if w.Class.StateChange.IsCompiled() {
+		w.Class.StateChange.Vars["from"] = prev
+		w.Class.StateChange.Vars["to"] = s
+		w.runScript(&w.Class.StateChange)
+	}
+}
+
+// wantsToFire checks the intent, and remembers whether it's an alt-fire.
+func (wc *WeaponController) wantsToFire() bool {
+	if wc.Intent != inventory.WeaponFire && wc.Intent != inventory.WeaponFireAlt {
+		return false
+	}
+	wc.Alt = wc.Intent == inventory.WeaponFireAlt
+	return true
 }
 
 func weaponIdle(wc *WeaponController) {
-	if wc.Intent == inventory.WeaponFire {
+	if wc.wantsToFire() {
 		wc.newState(inventory.WeaponFiring)
 		return
 	}
+	if wc.Intent == inventory.WeaponReload {
+		wc.Intent = inventory.WeaponHeld
+		wc.newState(inventory.WeaponReloading)
+	}
 }
 
 func weaponUnholstering(wc *WeaponController) {
@@ -106,17 +125,44 @@ func weaponFiring(wc *WeaponController) {
 	// or not.
 	wc.Intent = inventory.WeaponHeld
 	wc.Fired = true
+	if wc.Class.Fire.IsCompiled() {
+		wc.Class.Fire.Vars["alt"] = wc.Alt
+		wc.Class.Fire.Vars["shoot"] = weaponShooter(wc.Entity)
+		wc.runScript(&wc.Class.Fire)
+		return
+	}
+	wc.shoot(0, 0)
+}
+
+// weaponShooter returns a shoot function for scripts. It's bound to the
+// weapon entity rather than a controller, since controllers are reused for
+// other entities and scripts can hold on to the function.
+func weaponShooter(e ecs.Entity) func(float64, float64) {
+	return func(angle, pitch float64) {
+		weapon := inventory.GetWeapon(e)
+		if weapon == nil {
+			return
+		}
+		wc := &WeaponController{}
+		if wc.Target(weapon, e) {
+			wc.shoot(angle, pitch)
+		}
+	}
+}
+
+// shoot fires one shot, with the angles (in degrees) added to the spread.
+func (wc *WeaponController) shoot(angle, pitch float64) {
 	if instant := inventory.GetWeaponClassInstant(wc.Entity); instant != nil {
-		wc.fireWeaponInstant(instant)
+		wc.fireWeaponInstant(instant, angle, pitch)
 	}
 	if projectile := inventory.GetWeaponClassProjectile(wc.Entity); projectile != nil {
-		wc.fireWeaponProjectile(projectile)
+		wc.fireWeaponProjectile(projectile, angle, pitch)
 	}
 }
 
 func weaponCooling(wc *WeaponController) {
 	if wc.stateCompleted() {
-		if wc.Intent == inventory.WeaponFire {
+		if wc.wantsToFire() {
 			wc.newState(inventory.WeaponFiring)
 		} else {
 			wc.newState(inventory.WeaponIdle)
@@ -131,8 +177,18 @@ func weaponCooling(wc *WeaponController) {
 		wc.Intent = inventory.WeaponHeld
 	}
 }
+
 func weaponReloading(wc *WeaponController) {
+	if wc.Intent == inventory.WeaponHolstered {
+		wc.newState(inventory.WeaponHolstering)
+		return
+	}
+	if wc.stateCompleted() {
+		wc.runScript(&wc.Class.Reload)
+		wc.newState(inventory.WeaponIdle)
+	}
 }
+
 func weaponHolstering(wc *WeaponController) {
 }
 
@@ -4,7 +4,6 @@
 package controllers
 
 import (
-	"math/rand/v2"
 	"tlyakhov/gofoom/components/behaviors"
 	"tlyakhov/gofoom/components/character"
 	"tlyakhov/gofoom/components/core"
@@ -13,11 +12,12 @@ import (
 	"tlyakhov/gofoom/components/selection"
 	"tlyakhov/gofoom/concepts"
 	"tlyakhov/gofoom/constants"
+	"tlyakhov/gofoom/ecs"
 )
 
-func (wc *WeaponController) fireWeaponInstant(instant *inventory.WeaponClassInstant) {
-	angle := wc.Body.Angle.Now + (rand.Float64()-0.5)*wc.Class.Spread
-	pitchSpread := (rand.Float64() - 0.5) * wc.Class.Spread
+func (wc *WeaponController) fireWeaponInstant(instant *inventory.WeaponClassInstant, angleOffset, pitchOffset float64) {
+	angle := wc.Body.Angle.Now + angleOffset + (ecs.Simulation.Rand.Float64()-0.5)*wc.Class.Spread
+	pitchSpread := pitchOffset + (ecs.Simulation.Rand.Float64()-0.5)*wc.Class.Spread
 	// TODO: All bodies should probably be able to pitch
 	pitch := pitchSpread
 	if p := character.GetPlayer(wc.Body.Entity); p != nil {
@@ -37,6 +37,8 @@ func (wc *WeaponController) fireWeaponInstant(instant *inventory.WeaponClassInst
 	// time it would take to hit the thing and delaying the outcome? could be
 	// buggy though if the object in question moves
 	//log.Printf("Weapon hit! %v[%v] at %v", s.Type, s.Entity, wc.hit.StringHuman(2))
+	damage := instant.Damage
+	wc.runHitScript(&wc.Class.Damage, s, &wc.hit, &damage)
 	switch s.Type {
 	case selection.SelectableBody:
 		if mobile := core.GetMobile(s.Body.Entity); mobile != nil {
@@ -45,10 +47,10 @@ func (wc *WeaponController) fireWeaponInstant(instant *inventory.WeaponClassInst
 			mobile.Vel.Now.AddSelf(wc.delta.Mul(3))
 		}
 		// Hurt anything alive
-		if alive := behaviors.GetAlive(s.Body.Entity); alive != nil {
+		if alive := behaviors.GetAlive(s.Body.Entity); alive != nil && damage > 0 {
 			// TODO: Parameterize CoolDown in WeaponClassInstant?
 
-			alive.Hurt("Weapon "+s.Entity.String(), instant.Damage, 20)
+			alive.Hurt("Weapon "+s.Entity.String(), damage, 20)
 		}
 	}
 	if wc.markController.Target(materials.GetMarkMaker(wc.Entity), wc.Entity) {
@@ -57,4 +59,5 @@ func (wc *WeaponController) fireWeaponInstant(instant *inventory.WeaponClassInst
 	if wc.markController.Target(materials.GetMarkMaker(wc.Class.Entity), wc.Class.Entity) {
 		wc.markController.MakeMark(s, &wc.hit)
 	}
+	wc.runHitScript(&wc.Class.Hit, s, &wc.hit, &damage)
 }
@@ -5,16 +5,16 @@ package controllers
 
 import (
 	"math"
-	"math/rand/v2"
 	"tlyakhov/gofoom/components/behaviors"
 	"tlyakhov/gofoom/components/character"
 	"tlyakhov/gofoom/components/core"
 	"tlyakhov/gofoom/components/inventory"
+	"tlyakhov/gofoom/components/selection"
 	"tlyakhov/gofoom/concepts"
 	"tlyakhov/gofoom/ecs"
 )
 
-func (wc *WeaponController) fireWeaponProjectile(wcp *inventory.WeaponClassProjectile) {
+func (wc *WeaponController) fireWeaponProjectile(wcp *inventory.WeaponClassProjectile, angleOffset, pitchOffset float64) {
 	if wcp.Projectile == 0 {
 		return
 	}
@@ -48,16 +48,23 @@ func (wc *WeaponController) fireWeaponProjectile(wcp *inventory.WeaponClassProje
 		mobile.CrPlayer = core.CollideRemove
 		mobile.CrWall = core.CollideRemove
 	}
+	projectile := inventory.GetProjectile(e)
+	if projectile == nil {
+		projectile = ecs.NewAttachedComponent(e, inventory.ProjectileCID).(*inventory.Projectile)
+		projectile.Flags |= flags
+	}
+	projectile.Weapon = wc.Entity
+	projectile.Damage = wcp.Damage
 
-	hAngle := wc.Body.Angle.Now + (rand.Float64()-0.5)*wc.Class.Spread
-	pitchSpread := (rand.Float64() - 0.5) * wc.Class.Spread
+	hAngle := wc.Body.Angle.Now + angleOffset + (ecs.Simulation.Rand.Float64()-0.5)*wc.Class.Spread
+	pitchSpread := pitchOffset + (ecs.Simulation.Rand.Float64()-0.5)*wc.Class.Spread
 	// TODO: All bodies should probably be able to pitch
 	vAngle := pitchSpread
 	if p := character.GetPlayer(wc.Body.Entity); p != nil {
 		vAngle += p.Pitch
 	}
 
-	combinedRadius := wc.Body.Size.Now[0]*0.5 + body.Size.Now[0]*0.5 + 2
+	combinedRadius := wc.Body.CollisionSize.Now[0]*0.5 + body.CollisionSize.Now[0]*0.5 + 2
 
 	mobile.Vel.Spawn[0] = math.Cos(hAngle*concepts.Deg2rad) * math.Cos(vAngle*concepts.Deg2rad)
 	mobile.Vel.Spawn[1] = math.Sin(hAngle*concepts.Deg2rad) * math.Cos(vAngle*concepts.Deg2rad)
@@ -74,3 +81,26 @@ func (wc *WeaponController) fireWeaponProjectile(wcp *inventory.WeaponClassProje
 	}
 	ecs.ActAllControllersOneEntity(e, ecs.ControllerPrecompute)
 }
+
+// projectileHit is called by MobileController when a projectile hits a body or
+// a wall. Like instant weapons, the Damage script of the weapon's class can
+// change the damage before anything alive is hurt, and the Hit script runs
+// afterwards.
+func projectileHit(p *inventory.Projectile, s *selection.Selectable, hit *concepts.Vector3) {
+	if !p.IsActive() {
+		return
+	}
+	weapon := inventory.GetWeapon(p.Weapon)
+	var wc WeaponController
+	if weapon == nil || !wc.Target(weapon, p.Weapon) {
+		return
+	}
+	damage := p.Damage
+	wc.runHitScript(&wc.Class.Damage, s, hit, &damage)
+	if s.Type == selection.SelectableBody {
+		if alive := behaviors.GetAlive(s.Body.Entity); alive != nil && damage > 0 {
+			alive.Hurt("Projectile "+p.Entity.String(), damage, 20)
+		}
+	}
+	wc.runHitScript(&wc.Class.Hit, s, hit, &damage)
+}
@@ -0,0 +1,110 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/components/inventory"
+	"tlyakhov/gofoom/components/selection"
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/ecs"
+)
+
+// WeaponClassController compiles the scripts of weapon classes. They're run
+// by WeaponController.
+type WeaponClassController struct {
+	ecs.BaseController
+	*inventory.WeaponClass
+}
+
+func init() {
+	ecs.Types().RegisterController(func() ecs.Controller { return &WeaponClassController{} }, 100)
+}
+
+func (wcc *WeaponClassController) ComponentID() ecs.ComponentID {
+	return inventory.WeaponClassCID
+}
+
+func (wcc *WeaponClassController) Methods() ecs.ControllerMethod {
+	return ecs.ControllerPrecompute
+}
+
+func (wcc *WeaponClassController) EditorPausedMethods() ecs.ControllerMethod {
+	return ecs.ControllerPrecompute
+}
+
+func (wcc *WeaponClassController) Target(target ecs.Component, e ecs.Entity) bool {
+	wcc.Entity = e
+	wcc.WeaponClass = target.(*inventory.WeaponClass)
+	return wcc.WeaponClass.IsActive()
+}
+
+// Every weapon script gets these. onEntity is the weapon, and firer is the
+// entity holding it.
+var weaponScriptParams = []core.ScriptParam{
+	{Name: "onEntity", TypeName: "ecs.Entity"},
+	{Name: "weapon", TypeName: "*inventory.Weapon"},
+	{Name: "class", TypeName: "*inventory.WeaponClass"},
+	{Name: "firer", TypeName: "ecs.Entity"},
+	{Name: "body", TypeName: "*core.Body"},
+}
+
+// shoot fires a shot from the weapon, as long as it's still held.
+var weaponFireScriptParams = append([]core.ScriptParam{
+	{Name: "alt", TypeName: "bool"},
+	{Name: "shoot", TypeName: "func(float64, float64)"},
+}, weaponScriptParams...)
+
+var weaponHitScriptParams = append([]core.ScriptParam{
+	{Name: "hit", TypeName: "*selection.Selectable"},
+	{Name: "hitPoint", TypeName: "*concepts.Vector3"},
+	{Name: "damage", TypeName: "*float64"},
+}, weaponScriptParams...)
+
+var weaponStateScriptParams = append([]core.ScriptParam{
+	{Name: "from", TypeName: "inventory.WeaponState"},
+	{Name: "to", TypeName: "inventory.WeaponState"},
+}, weaponScriptParams...)
+
+func (wcc *WeaponClassController) Precompute() {
+	params := map[*core.Script][]core.ScriptParam{
+		&wcc.Fire:        weaponFireScriptParams,
+		&wcc.Damage:      weaponHitScriptParams,
+		&wcc.Hit:         weaponHitScriptParams,
+		&wcc.Reload:      weaponScriptParams,
+		&wcc.StateChange: weaponStateScriptParams,
+	}
+	for name, script := range wcc.Scripts() {
+		if script.IsEmpty() {
+			continue
+		}
+		script.Params = params[script]
+		script.SetOwner(wcc.Entity, wcc.WeaponClass, "WeaponClass."+name)
+		script.Compile()
+	}
+}
+
+// runScript runs one of the class's scripts for this weapon, with any extra
+// variables already set. Returns false if the script isn't compiled.
+func (wc *WeaponController) runScript(script *core.Script) bool {
+	if !script.IsCompiled() {
+		return false
+	}
+	script.Vars["onEntity"] = wc.Entity
+	script.Vars["weapon"] = wc.Weapon
+	script.Vars["class"] = wc.Class
+	script.Vars["firer"] = wc.Body.Entity
+	script.Vars["body"] = wc.Body
+	return script.Act()
+}
+
+func (wc *WeaponController) runHitScript(script *core.Script, s *selection.Selectable, hit *concepts.Vector3, damage *float64) {
+	if !script.IsCompiled() {
+		return
+	}
+	script.Vars["hit"] = s
+	script.Vars["hitPoint"] = hit
+	script.Vars["damage"] = damage
+	wc.runScript(script)
+}
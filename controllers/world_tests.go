@@ -6,7 +6,6 @@ package controllers
 import (
 	"fmt"
 	"log"
-	"math/rand"
 	"tlyakhov/gofoom/components/behaviors"
 	"tlyakhov/gofoom/components/character"
 	"tlyakhov/gofoom/components/core"
@@ -190,18 +189,18 @@ func CreateTestWorld() {
 		for y := range testh {
 			sector := CreateTestSector(fmt.Sprintf("land_%v_%v", x, y), float64(x*scale), float64(y*scale), float64(scale))
 			sector.Top.Z.SetAll(300)
-			sector.Bottom.Z.SetAll(rand.Float64() * 30)
-			//sector.FloorSlope = rand.Float64() * 0.2
+			sector.Bottom.Z.SetAll(ecs.Simulation.Rand.Float64() * 30)
+			//sector.FloorSlope = ecs.Simulation.Rand.Float64() * 0.2
 			sector.Top.Surface.Material = eSky
 			for i := 0; i < len(sector.Segments); i++ {
 				sector.Segments[i].Surface.Material = eSky
 				sector.Segments[i].LoSurface.Material = eDirt
 			}
 
-			if rand.Uint32()%45 == 0 {
+			if ecs.Simulation.Rand.Uint32()%45 == 0 {
 				eLight := CreateLightBody()
 				lightBody := core.GetBody(eLight)
-				lightBody.Pos.Spawn = concepts.Vector3{float64(x*scale) + rand.Float64()*float64(scale), float64(y*scale) + rand.Float64()*float64(scale), 200}
+				lightBody.Pos.Spawn = concepts.Vector3{float64(x*scale) + ecs.Simulation.Rand.Float64()*float64(scale), float64(y*scale) + ecs.Simulation.Rand.Float64()*float64(scale), 200}
 				lightBody.Pos.ResetToSpawn()
 				log.Println("Generated light")
 			}
@@ -212,7 +211,7 @@ func CreateTestWorld() {
 			eSector := ecs.GetEntityByName(fmt.Sprintf("land_%v_%v", x, y))
 			sector := core.GetSector(eSector)
 			// Randomly rotate the segments
-			rot := int(rand.Uint32() % 3)
+			rot := int(ecs.Simulation.Rand.Uint32() % 3)
 			for range rot {
 				sector.Segments = append(sector.Segments[1:], sector.Segments[0])
 			}
@@ -281,7 +280,7 @@ func CreateTestWorld3() {
 	var sample concepts.Vector4
 	for x := range testw {
 		for y := range testh {
-			//heightmap[y*testw+x] = rand.Float64() * 50
+			//heightmap[y*testw+x] = ecs.Simulation.Rand.Float64() * 50
 			heightImage.Sample(
 				float64(x)/float64(testw),
 				float64(y)/float64(testh),
@@ -351,7 +350,7 @@ func CreateTestWorld3() {
 	for range 8 {
 		eLight := CreateLightBody()
 		lightBody := core.GetBody(eLight)
-		lightBody.Pos.Spawn = concepts.Vector3{float64(testw*scale) * rand.Float64(), float64(testh*scale) * rand.Float64(), 450}
+		lightBody.Pos.Spawn = concepts.Vector3{float64(testw*scale) * ecs.Simulation.Rand.Float64(), float64(testh*scale) * ecs.Simulation.Rand.Float64(), 450}
 		lightBody.Pos.ResetToSpawn()
 		light := core.GetLight(eLight)
 		light.Strength = 3
@@ -365,8 +364,8 @@ func CreateTestWorld3() {
 	for range 32 {
 		eTreeBody := ecs.NewEntity()
 		body := ecs.NewAttachedComponent(eTreeBody, core.BodyCID).(*core.Body)
-		x := float64(testw*scale) * rand.Float64()
-		y := float64(testh*scale) * rand.Float64()
+		x := float64(testw*scale) * ecs.Simulation.Rand.Float64()
+		y := float64(testh*scale) * ecs.Simulation.Rand.Float64()
 		z := heightmap[(int(y/float64(scale))*testw + int(x/float64(scale)))]
 		body.Pos.Spawn = concepts.Vector3{x, y, z + 25}
 		body.Pos.ResetToSpawn()
@@ -284,6 +284,56 @@ func (d *DynamicValue[T]) Construct(data any) {
 	}
 }
 
+// SerializeRuntime returns everything Serialize() leaves out that's needed to
+// continue from a save game.
+func (d *DynamicValue[T]) SerializeRuntime() map[string]any {
+	result := map[string]any{
+		"Now":         d.serializeValue(d.Now),
+		"PrevFrame":   d.serializeValue(d.PrevFrame),
+		"PrevSimStep": d.serializeValue(d.PrevSimStep),
+	}
+	if d.Procedural {
+		result["Input"] = d.serializeValue(d.Input)
+		result["PrevInput"] = d.serializeValue(d.prevInput)
+		result["OutputV"] = d.serializeValue(d.outputV)
+	}
+	if d.Animation != nil {
+		result["Animation"] = map[string]any{
+			"Percent": d.Animation.Percent,
+			"Active":  d.Animation.Active,
+			"Reverse": d.Animation.Reverse,
+		}
+	}
+	return result
+}
+
+func (d *DynamicValue[T]) ConstructRuntime(data map[string]any) {
+	if v, ok := data["Now"]; ok {
+		d.Now = d.deserializeValue(v).(T)
+	}
+	if v, ok := data["PrevFrame"]; ok {
+		d.PrevFrame = d.deserializeValue(v).(T)
+	}
+	if v, ok := data["PrevSimStep"]; ok {
+		d.PrevSimStep = d.deserializeValue(v).(T)
+	}
+	if v, ok := data["Input"]; ok {
+		d.Input = d.deserializeValue(v).(T)
+	}
+	if v, ok := data["PrevInput"]; ok {
+		d.prevInput = d.deserializeValue(v).(T)
+	}
+	if v, ok := data["OutputV"]; ok {
+		d.outputV = d.deserializeValue(v).(T)
+	}
+	if a, ok := data["Animation"].(map[string]any); ok && d.Animation != nil {
+		d.Animation.Percent = cast.ToFloat64(a["Percent"])
+		d.Animation.Active = cast.ToBool(a["Active"])
+		d.Animation.Reverse = cast.ToBool(a["Reverse"])
+	}
+	d.Render = d.Now
+}
+
 func (d *DynamicValue[T]) GetAnimation() Animated {
 	return d.Animation
 }
@@ -291,11 +341,14 @@ func (d *DynamicValue[T]) GetAnimation() Animated {
 func (d *DynamicValue[T]) Precompute() {
 	// Based on "Giving Personality to Procedural Animations using Math"
 	// https://www.youtube.com/watch?v=KPoeNZZ6H4s
-	if d.Freq == 0 {
-		d.Freq = 0.000001
+	// Avoid dividing by zero without changing Freq itself, otherwise it
+	// wouldn't survive serialization.
+	freq := d.Freq
+	if freq == 0 {
+		freq = 0.000001
 	}
-	radians := (2.0 * math.Pi * d.Freq)
-	d.k1 = d.Damping / (math.Pi * d.Freq)
+	radians := (2.0 * math.Pi * freq)
+	d.k1 = d.Damping / (math.Pi * freq)
 	d.k2 = 1.0 / (radians * radians)
 	d.k3 = d.Response * d.Damping / radians
 	// 80% of actual limit, to be safe
@@ -4,8 +4,12 @@
 package dynamic
 
 import (
+	"fmt"
 	"log"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 type EventID int
@@ -14,26 +18,112 @@ type Event struct {
 	ID           EventID
 	Timestamp    int64 // Milliseconds
 	SimTimestamp int64 // Milliseconds
-	Data         any
+	// If non-zero, subscribers for this target (usually an entity, see
+	// SubscribeToTarget) are notified as well as the ones for every event of
+	// this class.
+	Target uint64
+	Data   any
+	// Cancelled events aren't delivered to any more consumers.
+	Cancelled bool
+}
+
+// Cancel stops the event from being delivered to consumers with a lower
+// priority. Same as returning true from an EventConsumer.
+func (evt *Event) Cancel() {
+	evt.Cancelled = true
+}
+
+func (evt *Event) String() string {
+	name := "Unknown"
+	if int(evt.ID) < len(eventClasses) && eventClasses[evt.ID] != nil {
+		name = eventClasses[evt.ID].Name
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%vms %v", evt.SimTimestamp/1_000_000, name)
+	if evt.Target != 0 {
+		fmt.Fprintf(&sb, " -> %v", evt.Target)
+	}
+	if evt.Data != nil {
+		fmt.Fprintf(&sb, " %+v", evt.Data)
+	}
+	return sb.String()
 }
 
 type EventClass struct {
-	ID        EventID
-	Name      string
-	Consumers []EventConsumer
+	ID   EventID
+	Name string
+	// Internal events are published by the simulation itself (e.g. damage),
+	// rather than coming from the outside world like input. Ledgers don't
+	// record them, since playback will publish them again.
+	Internal bool
+	// Number of events of this class that were published, and dropped
+	// because the queue was full. Events can be pushed from any goroutine,
+	// so these are atomic.
+	Published atomic.Uint64
+	Dropped   atomic.Uint64
+
+	subscriptions []*EventSubscription
+	targeted      map[uint64][]*EventSubscription
 }
 
-const MaxEvents = 1024
+const (
+	MaxEvents = 1024
+	// Number of events kept for debugging, see EventQueue.RecentEvents.
+	EventHistoryLength = 256
+	// Consumers with lower priorities are called first, like controllers.
+	DefaultEventPriority = 100
+)
 
+// EventConsumer handles an event. Returning true cancels the event, see
+// Event.Cancel.
 type EventConsumer func(evt *Event) bool
+
+type EventSubscription struct {
+	Class    EventID
+	Target   uint64
+	Priority int
+	Consumer EventConsumer
+
+	// Orders subscriptions with the same priority.
+	sequence uint64
+	removed  bool
+}
+
+// EventRecord is an event that's been delivered or dropped, for debugging.
+type EventRecord struct {
+	Event
+	// The number of consumers that saw the event.
+	Delivered int
+	Dropped   bool
+}
+
+func (r *EventRecord) String() string {
+	switch {
+	case r.Dropped:
+		return r.Event.String() + " (dropped)"
+	case r.Cancelled:
+		return fmt.Sprintf("%v (cancelled after %v)", r.Event.String(), r.Delivered)
+	default:
+		return fmt.Sprintf("%v (delivered to %v)", r.Event.String(), r.Delivered)
+	}
+}
+
 type EventQueue struct {
 	Queue [MaxEvents]*Event
 	Head  int
 	Tail  int
+	// Total number of events dropped because the queue was full.
+	Overflows uint64
+
+	history      [EventHistoryLength]EventRecord
+	historyIndex int
+	historyCount int
+	delivery     []*EventSubscription
 }
 
 var eventClassLock sync.Mutex
 var eventClasses = []*EventClass{nil}
+var subscriptionSequence uint64
 
 func RegisterEventClass(ec *EventClass) EventID {
 	eventClassLock.Lock()
@@ -48,21 +138,141 @@ func EventClasses() []*EventClass {
 	return eventClasses
 }
 
-func SubscribeToEvent(id EventID, c EventConsumer) {
+// eventClass returns the class for an ID, or nil if it isn't registered.
+func eventClass(id EventID) *EventClass {
 	eventClassLock.Lock()
 	defer eventClassLock.Unlock()
-	eventClasses[id].Consumers = append(eventClasses[id].Consumers, c)
+	if int(id) < len(eventClasses) {
+		return eventClasses[id]
+	}
+	return nil
 }
 
-// TODO: Unsubscribe
+func insertSubscription(list []*EventSubscription, sub *EventSubscription) []*EventSubscription {
+	i, _ := slices.BinarySearchFunc(list, sub, compareSubscriptions)
+	return slices.Insert(list, i, sub)
+}
+
+func compareSubscriptions(a, b *EventSubscription) int {
+	if a.Priority != b.Priority {
+		return a.Priority - b.Priority
+	}
+	if a.sequence < b.sequence {
+		return -1
+	} else if a.sequence > b.sequence {
+		return 1
+	}
+	return 0
+}
+
+// SubscribeToEvent calls c for every event of a class, with the default
+// priority.
+func SubscribeToEvent(id EventID, c EventConsumer) *EventSubscription {
+	return SubscribeToEventWithPriority(id, DefaultEventPriority, c)
+}
+
+func SubscribeToEventWithPriority(id EventID, priority int, c EventConsumer) *EventSubscription {
+	return SubscribeToTarget(id, 0, priority, c)
+}
+
+// SubscribeToTarget calls c for events of a class that are published for a
+// specific target. A target of 0 subscribes to every event of the class.
+func SubscribeToTarget(id EventID, target uint64, priority int, c EventConsumer) *EventSubscription {
+	eventClassLock.Lock()
+	defer eventClassLock.Unlock()
+	subscriptionSequence++
+	sub := &EventSubscription{
+		Class:    id,
+		Target:   target,
+		Priority: priority,
+		Consumer: c,
+		sequence: subscriptionSequence,
+	}
+	ec := eventClasses[id]
+	if target == 0 {
+		ec.subscriptions = insertSubscription(ec.subscriptions, sub)
+		return sub
+	}
+	if ec.targeted == nil {
+		ec.targeted = make(map[uint64][]*EventSubscription)
+	}
+	ec.targeted[target] = insertSubscription(ec.targeted[target], sub)
+	return sub
+}
+
+// Unsubscribe removes a subscription. It's safe to call from a consumer.
+func Unsubscribe(sub *EventSubscription) {
+	if sub == nil {
+		return
+	}
+	eventClassLock.Lock()
+	defer eventClassLock.Unlock()
+	sub.removed = true
+	ec := eventClasses[sub.Class]
+	isSub := func(s *EventSubscription) bool { return s == sub }
+	if sub.Target == 0 {
+		ec.subscriptions = slices.DeleteFunc(ec.subscriptions, isSub)
+		return
+	}
+	ec.targeted[sub.Target] = slices.DeleteFunc(ec.targeted[sub.Target], isSub)
+	if len(ec.targeted[sub.Target]) == 0 {
+		delete(ec.targeted, sub.Target)
+	}
+}
+
+// UnsubscribeTarget removes all subscriptions for a target, for example when
+// an entity is deleted. A target of 0 removes all targeted subscriptions.
+func UnsubscribeTarget(target uint64) {
+	eventClassLock.Lock()
+	defer eventClassLock.Unlock()
+	for _, ec := range eventClasses {
+		if ec == nil || ec.targeted == nil {
+			continue
+		}
+		for t, subs := range ec.targeted {
+			if target != 0 && t != target {
+				continue
+			}
+			for _, sub := range subs {
+				sub.removed = true
+			}
+			delete(ec.targeted, t)
+		}
+	}
+}
 
 func (q *EventQueue) PushEvent(evt *Event) {
+	if ec := eventClass(evt.ID); ec != nil {
+		ec.Published.Add(1)
+	}
 	q.Queue[q.Tail] = evt
 	q.Tail = (q.Tail + 1) % MaxEvents
 	if q.Tail == q.Head {
-		log.Printf("Warning: too many events for queue size %v.", MaxEvents)
+		dropped := q.Queue[q.Head]
 		q.Head = (q.Head + 1) % MaxEvents
+		q.Overflows++
+		if ec := eventClass(dropped.ID); ec != nil {
+			ec.Dropped.Add(1)
+		}
+		q.record(dropped, 0, true)
+		// Don't spam the log, report 1, 2, 4, 8... overflows.
+		if q.Overflows&(q.Overflows-1) == 0 {
+			log.Printf("Warning: too many events for queue size %v, dropped %v events so far (last: %v)",
+				MaxEvents, q.Overflows, dropped.String())
+		}
+	}
+}
+
+// filter removes queued events for which keep returns false.
+func (q *EventQueue) filter(keep func(evt *Event) bool) {
+	tail := q.Head
+	for i := q.Head; i != q.Tail; i = (i + 1) % MaxEvents {
+		if keep(q.Queue[i]) {
+			q.Queue[tail] = q.Queue[i]
+			tail = (tail + 1) % MaxEvents
+		}
 	}
+	q.Tail = tail
 }
 
 func (q *EventQueue) popEvent() *Event {
@@ -74,14 +284,70 @@ func (q *EventQueue) popEvent() *Event {
 	return evt
 }
 
+// subscribers merges the subscriptions for an event's class and target, in
+// priority order. Targeted subscriptions go first if the priorities are equal.
+func (q *EventQueue) subscribers(evt *Event) []*EventSubscription {
+	eventClassLock.Lock()
+	defer eventClassLock.Unlock()
+	ec := eventClasses[evt.ID]
+	q.delivery = q.delivery[:0]
+	var targeted []*EventSubscription
+	if evt.Target != 0 && ec.targeted != nil {
+		targeted = ec.targeted[evt.Target]
+	}
+	all := ec.subscriptions
+	for len(targeted) > 0 || len(all) > 0 {
+		if len(all) == 0 || (len(targeted) > 0 && targeted[0].Priority <= all[0].Priority) {
+			q.delivery = append(q.delivery, targeted[0])
+			targeted = targeted[1:]
+		} else {
+			q.delivery = append(q.delivery, all[0])
+			all = all[1:]
+		}
+	}
+	return q.delivery
+}
+
 func (q *EventQueue) ConsumeEvent() {
-	if q.Head == q.Tail {
+	evt := q.popEvent()
+	if evt == nil {
 		return
 	}
-	evt := q.popEvent()
-	for _, c := range eventClasses[evt.ID].Consumers {
-		if c(evt) {
-			return
+	delivered := 0
+	// Consumers may publish more events, so we can't reuse q.delivery for
+	// the whole loop.
+	subs := slices.Clone(q.subscribers(evt))
+	for _, sub := range subs {
+		if sub.removed {
+			continue
+		}
+		delivered++
+		if sub.Consumer(evt) {
+			evt.Cancelled = true
+		}
+		if evt.Cancelled {
+			break
 		}
 	}
+	q.record(evt, delivered, false)
+}
+
+func (q *EventQueue) record(evt *Event, delivered int, dropped bool) {
+	q.history[q.historyIndex] = EventRecord{Event: *evt, Delivered: delivered, Dropped: dropped}
+	q.historyIndex = (q.historyIndex + 1) % EventHistoryLength
+	q.historyCount = min(q.historyCount+1, EventHistoryLength)
+}
+
+// RecentEvents returns the last events that were delivered or dropped, oldest
+// first.
+func (q *EventQueue) RecentEvents() []EventRecord {
+	result := make([]EventRecord, 0, q.historyCount)
+	start := q.historyIndex - q.historyCount
+	if start < 0 {
+		start += EventHistoryLength
+	}
+	for i := range q.historyCount {
+		result = append(result, q.history[(start+i)%EventHistoryLength])
+	}
+	return result
 }
@@ -0,0 +1,98 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package dynamic
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEventDelivery(t *testing.T) {
+	sim := NewSimulation()
+	id := RegisterEventClass(&EventClass{Name: "TestDelivery"})
+	var order []string
+	record := func(name string, cancel bool) EventConsumer {
+		return func(evt *Event) bool {
+			order = append(order, name)
+			return cancel
+		}
+	}
+	SubscribeToEventWithPriority(id, 200, record("late", false))
+	SubscribeToEvent(id, record("default", false))
+	SubscribeToEventWithPriority(id, 50, record("early", false))
+	SubscribeToTarget(id, 42, DefaultEventPriority, record("target 42", false))
+	SubscribeToTarget(id, 43, DefaultEventPriority, record("target 43", false))
+	removed := SubscribeToEvent(id, record("removed", false))
+	Unsubscribe(removed)
+
+	sim.NewTargetedEvent(id, 42, nil)
+	sim.Tick()
+	expected := []string{"early", "target 42", "default", "late"}
+	if !slices.Equal(order, expected) {
+		t.Errorf("Expected %v, got %v", expected, order)
+	}
+
+	// Cancelling stops delivery to later subscribers.
+	order = nil
+	SubscribeToTarget(id, 43, 10, func(evt *Event) bool {
+		order = append(order, "cancel")
+		evt.Cancel()
+		return false
+	})
+	sim.NewTargetedEvent(id, 43, nil)
+	sim.Tick()
+	if !slices.Equal(order, []string{"cancel"}) {
+		t.Errorf("Expected only the cancelling subscriber, got %v", order)
+	}
+
+	// Targets can be unsubscribed all at once (e.g. deleted entities).
+	order = nil
+	UnsubscribeTarget(43)
+	sim.NewTargetedEvent(id, 43, nil)
+	sim.Tick()
+	if !slices.Equal(order, []string{"early", "default", "late"}) {
+		t.Errorf("Expected only global subscribers, got %v", order)
+	}
+
+	history := sim.Events.RecentEvents()
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 events in history, got %v", len(history))
+	}
+	if !history[1].Cancelled || history[1].Delivered != 1 || history[1].Target != 43 {
+		t.Errorf("Expected cancelled event in history, got %v", history[1].String())
+	}
+	if history[2].Delivered != 3 {
+		t.Errorf("Expected event delivered to 3 subscribers, got %v", history[2].String())
+	}
+}
+
+func TestEventOverflow(t *testing.T) {
+	sim := NewSimulation()
+	ec := &EventClass{Name: "TestOverflow"}
+	id := RegisterEventClass(ec)
+	delivered := 0
+	SubscribeToEvent(id, func(evt *Event) bool {
+		delivered++
+		return false
+	})
+
+	for i := range MaxEvents + 10 {
+		sim.NewEvent(id, i)
+	}
+	// The queue holds one less than its size.
+	if sim.Events.Overflows != 11 || ec.Dropped.Load() != 11 {
+		t.Errorf("Expected 11 dropped events, got %v (class: %v)", sim.Events.Overflows, ec.Dropped.Load())
+	}
+	sim.Tick()
+	if delivered != MaxEvents-1 {
+		t.Errorf("Expected %v delivered events, got %v", MaxEvents-1, delivered)
+	}
+	history := sim.Events.RecentEvents()
+	if len(history) != EventHistoryLength {
+		t.Fatalf("Expected a full history, got %v", len(history))
+	}
+	if last := history[len(history)-1]; last.Data != MaxEvents+9 || last.Dropped {
+		t.Errorf("Expected the newest event last, got %v", last.String())
+	}
+}
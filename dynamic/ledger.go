@@ -0,0 +1,308 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package dynamic
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// A Ledger is a log of everything that feeds into the simulation from the
+// outside world: wall clock timestamps, the RNG seed, and input events. When
+// recording, every frame is appended to the ledger. When playing back, the
+// simulation ignores the wall clock and live input, and instead uses the
+// recorded values. This is how we do DOOM-style demos, replays, and
+// reproducible bug reports.
+//
+// The file format is a gzipped gob stream: a LedgerHeader followed by one
+// LedgerFrame per call to Simulation.Step.
+type Ledger struct {
+	Header LedgerHeader
+	// Set when playback has consumed every recorded frame.
+	Finished bool
+	// Called once when playback runs out of frames.
+	OnFinished func()
+
+	recording bool
+	closer    io.Closer
+	gz        io.Closer
+	encoder   *gob.Encoder
+	decoder   *gob.Decoder
+	frame     LedgerFrame
+	step      int
+	// Maps recorded event IDs to the event IDs registered in this binary.
+	eventMap []EventID
+}
+
+const LedgerVersion = 1
+
+type LedgerHeader struct {
+	Version int
+	Seed    uint64
+	// The world file this ledger was recorded against. Informational only.
+	World string
+	// Simulation state at the start of recording
+	Counter      uint64
+	Frame        uint64
+	SimTimestamp int64
+	RenderTime   int64
+	// Names of all registered event classes, indexed by EventID at the time
+	// of recording. Used to remap IDs if registration order changes.
+	EventClasses []string
+}
+
+type LedgerEvent struct {
+	ID     EventID
+	Target uint64
+	Data   any
+}
+
+type LedgerStep struct {
+	Counter      uint64
+	SimTimestamp int64
+	Events       []LedgerEvent
+}
+
+type LedgerFrame struct {
+	Frame         uint64
+	PrevTimestamp int64
+	Timestamp     int64
+	Steps         []LedgerStep
+}
+
+// RegisterEventData makes an event payload type known to the ledger. Event
+// payloads are stored as `any`, so every concrete type that might be sent with
+// an event must be registered before recording or playing back.
+func RegisterEventData(data any) {
+	gob.Register(data)
+}
+
+// NewLedgerRecorder creates a ledger that writes to w, starting from the
+// current state of the simulation. If w is an io.Closer, it will be closed
+// along with the ledger.
+func NewLedgerRecorder(w io.Writer, s *Simulation, world string) (*Ledger, error) {
+	l := &Ledger{recording: true}
+	if c, ok := w.(io.Closer); ok {
+		l.closer = c
+	}
+	gz := gzip.NewWriter(w)
+	l.gz = gz
+	l.encoder = gob.NewEncoder(gz)
+	l.Header = LedgerHeader{
+		Version:      LedgerVersion,
+		Seed:         s.Seed,
+		World:        world,
+		Counter:      s.Counter,
+		Frame:        s.Frame,
+		SimTimestamp: s.SimTimestamp,
+		RenderTime:   s.renderTime,
+	}
+	for _, ec := range EventClasses() {
+		if ec == nil {
+			l.Header.EventClasses = append(l.Header.EventClasses, "")
+			continue
+		}
+		l.Header.EventClasses = append(l.Header.EventClasses, ec.Name)
+	}
+	if err := l.encoder.Encode(&l.Header); err != nil {
+		return nil, fmt.Errorf("dynamic.NewLedgerRecorder: writing header: %w", err)
+	}
+	return l, nil
+}
+
+// NewLedgerPlayer creates a ledger that reads from r. If r is an io.Closer,
+// it will be closed along with the ledger.
+func NewLedgerPlayer(r io.Reader) (*Ledger, error) {
+	l := &Ledger{recording: false}
+	if c, ok := r.(io.Closer); ok {
+		l.closer = c
+	}
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("dynamic.NewLedgerPlayer: %w", err)
+	}
+	l.gz = gz
+	l.decoder = gob.NewDecoder(gz)
+	if err := l.decoder.Decode(&l.Header); err != nil {
+		return nil, fmt.Errorf("dynamic.NewLedgerPlayer: reading header: %w", err)
+	}
+	if l.Header.Version != LedgerVersion {
+		return nil, fmt.Errorf("dynamic.NewLedgerPlayer: unsupported version %v", l.Header.Version)
+	}
+
+	names := make(map[string]EventID)
+	for _, ec := range EventClasses() {
+		if ec != nil {
+			names[ec.Name] = ec.ID
+		}
+	}
+	l.eventMap = make([]EventID, len(l.Header.EventClasses))
+	for i, name := range l.Header.EventClasses {
+		if id, ok := names[name]; ok {
+			l.eventMap[i] = id
+		} else if name != "" {
+			log.Printf("dynamic.NewLedgerPlayer: recorded event class %v is not registered", name)
+		}
+	}
+	return l, nil
+}
+
+// RecordToFile is a convenience wrapper for NewLedgerRecorder.
+func RecordToFile(filename string, s *Simulation, world string) (*Ledger, error) {
+	if err := CheckSandbox("recording " + filename); err != nil {
+		return nil, fmt.Errorf("dynamic.RecordToFile: %w", err)
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("dynamic.RecordToFile: %w", err)
+	}
+	l, err := NewLedgerRecorder(f, s, world)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// PlayFromFile is a convenience wrapper for NewLedgerPlayer.
+func PlayFromFile(filename string) (*Ledger, error) {
+	if err := CheckSandbox("playing " + filename); err != nil {
+		return nil, fmt.Errorf("dynamic.PlayFromFile: %w", err)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("dynamic.PlayFromFile: %w", err)
+	}
+	l, err := NewLedgerPlayer(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Ledger) IsRecording() bool {
+	return l != nil && l.recording
+}
+
+func (l *Ledger) IsPlaying() bool {
+	return l != nil && !l.recording && !l.Finished
+}
+
+// Close flushes any buffered data and closes the underlying file.
+func (l *Ledger) Close() error {
+	var err error
+	if l.gz != nil {
+		err = l.gz.Close()
+		l.gz = nil
+	}
+	if l.closer != nil {
+		err = errors.Join(err, l.closer.Close())
+		l.closer = nil
+	}
+	return err
+}
+
+// restore resets the simulation to the state it was in when recording began.
+func (l *Ledger) restore(s *Simulation) {
+	s.Counter = l.Header.Counter
+	s.Frame = l.Header.Frame
+	s.SimTimestamp = l.Header.SimTimestamp
+	s.renderTime = l.Header.RenderTime
+	s.SetSeed(l.Header.Seed)
+}
+
+func (l *Ledger) beginFrame(s *Simulation) {
+	if l.recording {
+		l.frame = LedgerFrame{
+			Frame:         s.Frame,
+			PrevTimestamp: s.PrevTimestamp,
+			Timestamp:     s.Timestamp,
+		}
+		return
+	}
+
+	l.frame = LedgerFrame{}
+	l.step = 0
+	if err := l.decoder.Decode(&l.frame); err != nil {
+		if !errors.Is(err, io.EOF) {
+			log.Printf("Ledger.beginFrame: error reading frame: %v", err)
+		}
+		l.finish()
+	}
+}
+
+func (l *Ledger) endFrame() {
+	if !l.recording {
+		return
+	}
+	if err := l.encoder.Encode(&l.frame); err != nil {
+		log.Printf("Ledger.endFrame: error writing frame: %v", err)
+	}
+}
+
+// simStep either records the events in the queue or replaces them with the
+// recorded ones, depending on the mode.
+func (l *Ledger) simStep(s *Simulation) {
+	if l.recording {
+		step := LedgerStep{Counter: s.Counter, SimTimestamp: s.SimTimestamp}
+		for i := s.Events.Head; i != s.Events.Tail; i = (i + 1) % MaxEvents {
+			evt := s.Events.Queue[i]
+			if eventClasses[evt.ID].Internal {
+				continue
+			}
+			step.Events = append(step.Events, LedgerEvent{ID: evt.ID, Target: evt.Target, Data: evt.Data})
+		}
+		l.frame.Steps = append(l.frame.Steps, step)
+		return
+	}
+
+	// Playback: throw away any live input, but keep events published by the
+	// simulation itself.
+	s.Events.filter(func(evt *Event) bool { return eventClasses[evt.ID].Internal })
+	if l.Finished {
+		return
+	}
+	if l.step >= len(l.frame.Steps) {
+		log.Printf("Ledger.simStep: desync, simulation ran more steps than recorded in frame %v", l.frame.Frame)
+		return
+	}
+	step := &l.frame.Steps[l.step]
+	l.step++
+	if step.Counter != s.Counter || step.SimTimestamp != s.SimTimestamp {
+		log.Printf("Ledger.simStep: desync, recorded step %v@%v, simulation step %v@%v",
+			step.Counter, step.SimTimestamp, s.Counter, s.SimTimestamp)
+	}
+	for _, le := range step.Events {
+		id := le.ID
+		if int(id) < len(l.eventMap) {
+			id = l.eventMap[id]
+		}
+		if id == 0 {
+			continue
+		}
+		s.Events.PushEvent(&Event{
+			ID:           id,
+			Timestamp:    l.frame.Timestamp,
+			SimTimestamp: s.SimTimestamp,
+			Target:       le.Target,
+			Data:         le.Data,
+		})
+	}
+}
+
+func (l *Ledger) finish() {
+	if l.Finished {
+		return
+	}
+	l.Finished = true
+	if l.OnFinished != nil {
+		l.OnFinished()
+	}
+}
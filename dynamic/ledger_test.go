@@ -0,0 +1,87 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package dynamic
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+type ledgerTestParams struct {
+	Value float64
+}
+
+var ledgerTestEvent = RegisterEventClass(&EventClass{Name: "LedgerTest"})
+
+func TestLedgerRoundTrip(t *testing.T) {
+	RegisterEventData(&ledgerTestParams{})
+
+	var consumed []float64
+	SubscribeToEvent(ledgerTestEvent, func(evt *Event) bool {
+		consumed = append(consumed, evt.Data.(*ledgerTestParams).Value)
+		return false
+	})
+
+	var buf bytes.Buffer
+	sim := NewSimulation()
+	randomValues := []float64{}
+	sim.Integrate = func() {
+		v := sim.Rand.Float64()
+		randomValues = append(randomValues, v)
+		sim.NewEvent(ledgerTestEvent, &ledgerTestParams{Value: v})
+	}
+	l, err := NewLedgerRecorder(&buf, sim, "test.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.Ledger = l
+	for range 20 {
+		time.Sleep(time.Millisecond * 5)
+		sim.Step()
+	}
+	if err := sim.StopLedger(); err != nil {
+		t.Fatal(err)
+	}
+	recorded := consumed
+	recordedSteps := sim.Counter
+	if recordedSteps == 0 {
+		t.Fatal("Expected at least one simulation step")
+	}
+
+	// Playback: live input should be ignored, and the RNG should produce the
+	// same sequence.
+	consumed = nil
+	playedRandomValues := []float64{}
+	sim = NewSimulation()
+	sim.Integrate = func() {
+		playedRandomValues = append(playedRandomValues, sim.Rand.Float64())
+		sim.NewEvent(ledgerTestEvent, &ledgerTestParams{Value: -1})
+	}
+	l, err = NewLedgerPlayer(io.NopCloser(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.restore(sim)
+	sim.Ledger = l
+	for !sim.Ledger.Finished {
+		sim.Step()
+	}
+
+	if sim.Counter != recordedSteps {
+		t.Errorf("Expected %v steps, got %v", recordedSteps, sim.Counter)
+	}
+	if len(consumed) != len(recorded) {
+		t.Fatalf("Expected %v events, got %v", len(recorded), len(consumed))
+	}
+	for i := range recorded {
+		if consumed[i] != recorded[i] {
+			t.Errorf("Event %v: expected %v, got %v", i, recorded[i], consumed[i])
+		}
+		if playedRandomValues[i] != randomValues[i] {
+			t.Errorf("Random value %v: expected %v, got %v", i, randomValues[i], playedRandomValues[i])
+		}
+	}
+}
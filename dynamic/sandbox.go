@@ -0,0 +1,43 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package dynamic
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// While sandboxed scripts are running (see core.ScriptSandbox), the engine
+// refuses to write files, load saves or demos, or replace the world. The
+// checks are in the functions that do the file IO, so they catch every way a
+// script could reach them (method values, aliases, etc.), which the static
+// checks on script code can't.
+var sandboxDepth atomic.Int32
+
+var ErrSandboxed = errors.New("not allowed in sandboxed scripts")
+
+// EnterSandbox is called when a sandboxed script starts running.
+func EnterSandbox() {
+	sandboxDepth.Add(1)
+}
+
+// ExitSandbox is called when a sandboxed script is done.
+func ExitSandbox() {
+	sandboxDepth.Add(-1)
+}
+
+// Sandboxed is true while a sandboxed script is running.
+func Sandboxed() bool {
+	return sandboxDepth.Load() > 0
+}
+
+// CheckSandbox returns an error wrapping ErrSandboxed if a sandboxed script is
+// running. what describes the operation, e.g. "writing world.yaml".
+func CheckSandbox(what string) error {
+	if Sandboxed() {
+		return fmt.Errorf("%v: %w", what, ErrSandboxed)
+	}
+	return nil
+}
@@ -4,9 +4,17 @@
 package dynamic
 
 import (
+	"cmp"
+	"encoding/base64"
+	"log"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"time"
 	"tlyakhov/gofoom/constants"
 
 	"github.com/loov/hrtime"
+	"github.com/spf13/cast"
 )
 
 // This is based on the "Fix your timestep" blog post here:
@@ -31,12 +39,21 @@ type Simulation struct {
 	Spawnables   map[Spawnable]struct{} //  *xsync.MapOf[Spawnable, struct{}]
 	Timers       map[*Timer]struct{}
 	Events       EventQueue
+	// Controllers should use this rather than the global math/rand functions
+	// so that demos play back deterministically.
+	Rand *rand.Rand
+	Seed uint64
+	// If non-nil, either records or plays back simulation input.
+	Ledger *Ledger
 
-	renderTime int64
+	renderTime    int64
+	pcg           *rand.PCG
+	timerSequence uint64
+	expiredTimers []*Timer
 }
 
 func NewSimulation() *Simulation {
-	return &Simulation{
+	s := &Simulation{
 		PrevTimestamp: hrtime.Now().Nanoseconds(),
 		Timestamp:     hrtime.Now().Nanoseconds(),
 		SimTimestamp:  0,
@@ -45,15 +62,151 @@ func NewSimulation() *Simulation {
 		Spawnables:    make(map[Spawnable]struct{}),
 		Timers:        make(map[*Timer]struct{}),
 	}
+	s.SetSeed(uint64(time.Now().UnixNano()))
+	return s
+}
+
+// SetSeed resets the simulation random number generator.
+func (s *Simulation) SetSeed(seed uint64) {
+	s.Seed = seed
+	s.pcg = rand.NewPCG(seed, seed^0x9E3779B97F4A7C15)
+	s.Rand = rand.New(s.pcg)
+}
+
+// SerializeRuntime returns the state needed to continue the simulation from
+// a save game.
+func (s *Simulation) SerializeRuntime() map[string]any {
+	result := map[string]any{
+		"SimTimestamp": s.SimTimestamp,
+		"Counter":      s.Counter,
+		"Frame":        s.Frame,
+		// Seeds can be larger than YAML/JSON numbers can handle.
+		"Seed":          strconv.FormatUint(s.Seed, 10),
+		"TimerSequence": strconv.FormatUint(s.timerSequence, 10),
+	}
+	timers := make([]any, 0)
+	for _, t := range s.sortedTimers() {
+		if t.standalone && t.Running && t.Handler != "" {
+			timers = append(timers, t.serialize())
+		}
+	}
+	if len(timers) > 0 {
+		result["Timers"] = timers
+	}
+	if bytes, err := s.pcg.MarshalBinary(); err == nil {
+		result["Rand"] = base64.StdEncoding.EncodeToString(bytes)
+	}
+	return result
+}
+
+// ConstructRuntime restores state saved with SerializeRuntime.
+func (s *Simulation) ConstructRuntime(data map[string]any) {
+	if data == nil {
+		return
+	}
+	if v, ok := data["SimTimestamp"]; ok {
+		s.SimTimestamp = cast.ToInt64(v)
+	}
+	if v, ok := data["Counter"]; ok {
+		s.Counter = cast.ToUint64(v)
+	}
+	if v, ok := data["Frame"]; ok {
+		s.Frame = cast.ToUint64(v)
+	}
+	if v, ok := data["Seed"]; ok {
+		if seed, err := strconv.ParseUint(cast.ToString(v), 10, 64); err == nil {
+			s.SetSeed(seed)
+		} else {
+			log.Printf("Simulation.ConstructRuntime: error parsing seed: %v", err)
+		}
+	}
+	if v, ok := data["TimerSequence"]; ok {
+		s.timerSequence, _ = strconv.ParseUint(cast.ToString(v), 10, 64)
+	}
+	// Saved timers replace any that were created while loading.
+	for t := range s.Timers {
+		if t.standalone && t.Handler != "" {
+			delete(s.Timers, t)
+		}
+	}
+	if v, ok := data["Timers"].([]any); ok {
+		for _, item := range v {
+			if timerData, ok := item.(map[string]any); ok {
+				t := new(Timer)
+				t.construct(timerData)
+				t.Attach(s)
+			}
+		}
+	}
+	if v, ok := data["Rand"]; ok {
+		bytes, err := base64.StdEncoding.DecodeString(cast.ToString(v))
+		if err == nil {
+			err = s.pcg.UnmarshalBinary(bytes)
+		}
+		if err != nil {
+			log.Printf("Simulation.ConstructRuntime: error restoring random state: %v", err)
+		}
+	}
+}
+
+// Record starts recording a ledger to a file. The world is just stored for
+// reference.
+func (s *Simulation) Record(filename string, world string) error {
+	s.StopLedger()
+	// Reseed so that the ledger fully describes the random sequence.
+	s.SetSeed(s.Seed)
+	l, err := RecordToFile(filename, s, world)
+	if err != nil {
+		return err
+	}
+	s.Ledger = l
+	return nil
+}
+
+// Play starts playing back a ledger from a file. The caller is responsible
+// for loading the same world that the ledger was recorded against.
+func (s *Simulation) Play(filename string) error {
+	s.StopLedger()
+	l, err := PlayFromFile(filename)
+	if err != nil {
+		return err
+	}
+	l.restore(s)
+	s.Ledger = l
+	return nil
+}
+
+// StopLedger finishes recording or playback.
+func (s *Simulation) StopLedger() error {
+	if s.Ledger == nil {
+		return nil
+	}
+	err := s.Ledger.Close()
+	s.Ledger = nil
+	return err
 }
 
 func (s *Simulation) Step() {
-	// TODO: We should add some functionality to save all sim values including
-	// time to a ledger. Would be great for DOOM style "demos", replays, and
-	// also for debugging weird edge cases.
 	s.PrevTimestamp = s.Timestamp
-	s.Timestamp = hrtime.Now().Nanoseconds()
+	if s.Ledger.IsPlaying() {
+		s.Ledger.beginFrame(s)
+		if s.Ledger.Finished {
+			// Don't simulate anything past the end of the recording.
+			return
+		}
+		s.PrevTimestamp = s.Ledger.frame.PrevTimestamp
+		s.Timestamp = s.Ledger.frame.Timestamp
+	} else {
+		s.Timestamp = hrtime.Now().Nanoseconds()
+	}
+	if s.Ledger.IsRecording() {
+		s.Ledger.beginFrame(s)
+	}
 	s.FrameNanos = s.Timestamp - s.PrevTimestamp
+	if s.FrameNanos < 0 {
+		// Can happen when switching between ledger and wall clock time.
+		s.FrameNanos = 0
+	}
 	if s.FrameNanos != 0 {
 		s.FPS = float64(1_000_000_000) / float64(s.FrameNanos)
 	}
@@ -73,30 +226,12 @@ func (s *Simulation) Step() {
 	}
 
 	for s.renderTime >= constants.TimeStepNS {
-		for d := range s.Dynamics {
-			d.NewSimStep()
-			if a := d.GetAnimation(); a != nil && !s.EditorPaused {
-				a.Animate()
-			}
-		}
-
-		for t := range s.Timers {
-			if s.SimTimestamp > t.End && t.OnExpiry != nil {
-				t.OnExpiry()
-			}
+		s.beginSimStep()
+		if s.Ledger != nil {
+			s.Ledger.simStep(s)
 		}
-
-		if s.Integrate != nil {
-			s.Integrate()
-		}
-
-		for s.Events.Head != s.Events.Tail {
-			s.Events.ConsumeEvent()
-		}
-
-		s.Counter++
+		s.endSimStep()
 		s.renderTime -= constants.TimeStepNS
-		s.SimTimestamp += constants.TimeStepNS
 	}
 
 	// Update the blended values
@@ -109,6 +244,10 @@ func (s *Simulation) Step() {
 		d.Update(s.RenderStateBlend)
 	}
 
+	if s.Ledger.IsRecording() {
+		s.Ledger.endFrame()
+	}
+
 	if s.Render != nil {
 		s.Render()
 		s.Frame++
@@ -121,6 +260,55 @@ func (s *Simulation) Step() {
 	*/
 }
 
+func (s *Simulation) beginSimStep() {
+	for d := range s.Dynamics {
+		d.NewSimStep()
+		if a := d.GetAnimation(); a != nil && !s.EditorPaused {
+			a.Animate()
+		}
+	}
+
+	s.updateTimers()
+
+	if s.Integrate != nil {
+		s.Integrate()
+	}
+}
+
+func (s *Simulation) endSimStep() {
+	for s.Events.Head != s.Events.Tail {
+		s.Events.ConsumeEvent()
+	}
+
+	s.Counter++
+	s.SimTimestamp += constants.TimeStepNS
+}
+
+// Tick advances the simulation by exactly one time step, without consulting
+// the wall clock or the ledger. Each tick is treated as a whole frame, and
+// render values are updated to match. Useful for headless runs and tests.
+func (s *Simulation) Tick() {
+	if s.NewFrame != nil {
+		s.NewFrame()
+	}
+
+	for d := range s.Dynamics {
+		d.NewFrame()
+	}
+
+	s.beginSimStep()
+	s.endSimStep()
+
+	s.RenderStateBlend = 1
+	for d := range s.Dynamics {
+		if s.Precompute {
+			d.Precompute()
+		}
+		d.Update(s.RenderStateBlend)
+	}
+	s.Frame++
+}
+
 // NewEvent wraps adding a timestamped event to the queue
 func (s *Simulation) NewEvent(id EventID, data any) {
 	s.Events.PushEvent(&Event{
@@ -131,20 +319,114 @@ func (s *Simulation) NewEvent(id EventID, data any) {
 	})
 }
 
+// NewTargetedEvent adds a timestamped event to the queue, delivered to
+// subscribers for the target as well as the event class. See
+// SubscribeToTarget.
+func (s *Simulation) NewTargetedEvent(id EventID, target uint64, data any) {
+	s.Events.PushEvent(&Event{
+		ID:           id,
+		Timestamp:    s.Timestamp,
+		SimTimestamp: s.SimTimestamp,
+		Target:       target,
+		Data:         data,
+	})
+}
+
 func (s *Simulation) TimerAge(t *Timer) int64 {
 	return s.SimTimestamp - t.Start
 }
 
+// NewTimer creates a one-shot timer that calls onExpiry after duration
+// nanoseconds. Timers with plain callbacks aren't saved in save games, see
+// NewHandlerTimer.
 func (s *Simulation) NewTimer(duration int64, onExpiry func()) *Timer {
-	t := &Timer{
-		Start:    s.SimTimestamp,
-		End:      s.SimTimestamp + duration,
-		OnExpiry: onExpiry,
-	}
-	s.Timers[t] = struct{}{}
+	t := &Timer{OnExpiry: onExpiry, standalone: true}
+	t.Schedule(s, duration, 0)
+	return t
+}
+
+// NewRepeatingTimer creates a timer that calls onExpiry every interval
+// nanoseconds, until cancelled.
+func (s *Simulation) NewRepeatingTimer(interval int64, onExpiry func()) *Timer {
+	t := &Timer{OnExpiry: onExpiry, standalone: true}
+	t.Schedule(s, interval, interval)
+	return t
+}
+
+// NewHandlerTimer creates a timer that calls a registered handler (see
+// RegisterTimerHandler) with some data. These are saved in save games.
+func (s *Simulation) NewHandlerTimer(delay, interval int64, handler string, data string) *Timer {
+	t := &Timer{Handler: handler, Data: data, standalone: true}
+	t.Schedule(s, delay, interval)
 	return t
 }
 
+// After is NewTimer in milliseconds, for scripts.
+func (s *Simulation) After(ms float64, onExpiry func()) *Timer {
+	return s.NewTimer(int64(ms*1_000_000), onExpiry)
+}
+
+// Every is NewRepeatingTimer in milliseconds, for scripts.
+func (s *Simulation) Every(ms float64, onExpiry func()) *Timer {
+	return s.NewRepeatingTimer(int64(ms*1_000_000), onExpiry)
+}
+
 func (s *Simulation) DeleteTimer(t *Timer) {
+	t.Cancel()
 	delete(s.Timers, t)
 }
+
+// sortedTimers returns the attached timers in the order they were scheduled,
+// since map order is random and we want demos and save games to be
+// deterministic.
+func (s *Simulation) sortedTimers() []*Timer {
+	timers := make([]*Timer, 0, len(s.Timers))
+	for t := range s.Timers {
+		timers = append(timers, t)
+	}
+	slices.SortFunc(timers, func(a, b *Timer) int {
+		return cmp.Compare(a.Sequence, b.Sequence)
+	})
+	return timers
+}
+
+func (s *Simulation) updateTimers() {
+	s.expiredTimers = s.expiredTimers[:0]
+	for t := range s.Timers {
+		switch {
+		case !t.Running:
+			if t.standalone {
+				delete(s.Timers, t)
+			}
+		case s.EditorPaused || t.Paused:
+			t.Start += constants.TimeStepNS
+			t.End += constants.TimeStepNS
+		case s.SimTimestamp >= t.End:
+			s.expiredTimers = append(s.expiredTimers, t)
+		}
+	}
+	slices.SortFunc(s.expiredTimers, func(a, b *Timer) int {
+		if c := cmp.Compare(a.End, b.End); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Sequence, b.Sequence)
+	})
+	for _, t := range s.expiredTimers {
+		// An earlier timer may have cancelled this one.
+		if !t.Running {
+			continue
+		}
+		t.Fired++
+		if t.Interval > 0 {
+			// Don't build up a backlog if the interval is shorter than the
+			// time step.
+			for t.End <= s.SimTimestamp {
+				t.Start = t.End
+				t.End += t.Interval
+			}
+		} else {
+			t.Running = false
+		}
+		t.expire()
+	}
+}
@@ -1,9 +1,66 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
 package dynamic
 
+import (
+	"log"
+	"sync"
+
+	"github.com/spf13/cast"
+)
+
+// A Timer calls OnExpiry after some amount of simulation time, either once or
+// repeatedly. Timers are processed at the start of every simulation step, and
+// don't count down while the editor (or the timer itself) is paused.
+//
+// Like DynamicValues, timers can be fields of components: Attach them in
+// OnAttach and Detach them in OnDelete. Their state is then saved along with
+// the component in save games. Functions can't be saved, so OnExpiry should
+// be set by a controller in Precompute. Standalone timers (see
+// Simulation.NewTimer) are only saved if they use a Handler, see
+// RegisterTimerHandler.
 type Timer struct {
-	Start    int64
-	End      int64
+	// Simulation time when the timer was scheduled or last repeated (ns)
+	Start int64
+	// Simulation time when the timer expires next (ns)
+	End int64
+	// If non-zero, the timer is rescheduled after expiring (ns)
+	Interval int64
+	// Number of times the timer has expired since it was scheduled
+	Fired   int
+	Running bool
+	Paused  bool
+	// Timers that expire during the same step are processed in the order
+	// they were scheduled.
+	Sequence uint64
+
+	// Name of a function registered with RegisterTimerHandler, which is
+	// called with the timer (and its Data) when it expires.
+	Handler string
+	Data    string
+
 	OnExpiry func()
+
+	// Standalone timers are removed from the simulation once they stop.
+	standalone bool
+}
+
+// TimerHandler is a named timer callback, see RegisterTimerHandler.
+type TimerHandler func(t *Timer)
+
+var (
+	timerHandlers     = make(map[string]TimerHandler)
+	timerHandlersLock sync.RWMutex
+)
+
+// RegisterTimerHandler makes a callback available to timers by name. Unlike
+// OnExpiry functions, timers using handlers can be saved. Usually called in
+// init().
+func RegisterTimerHandler(name string, handler TimerHandler) {
+	timerHandlersLock.Lock()
+	defer timerHandlersLock.Unlock()
+	timerHandlers[name] = handler
 }
 
 func (t *Timer) Attach(sim *Simulation) {
@@ -14,6 +71,98 @@ func (t *Timer) Detach(sim *Simulation) {
 	delete(sim.Timers, t)
 }
 
+// Schedule (re)starts the timer, expiring after delay nanoseconds. If interval
+// is non-zero, it repeats after that until cancelled.
+func (t *Timer) Schedule(sim *Simulation, delay, interval int64) {
+	t.Start = sim.SimTimestamp
+	t.End = sim.SimTimestamp + delay
+	t.Interval = interval
+	t.Fired = 0
+	t.Running = true
+	t.Paused = false
+	sim.timerSequence++
+	t.Sequence = sim.timerSequence
+	t.Attach(sim)
+}
+
+// Cancel stops the timer without calling OnExpiry.
+func (t *Timer) Cancel() {
+	t.Running = false
+}
+
+// Pause stops the timer from counting down until Resume is called.
+func (t *Timer) Pause() {
+	t.Paused = true
+}
+
+func (t *Timer) Resume() {
+	t.Paused = false
+}
+
+// Expired is true if the timer isn't counting down: a one-shot timer has
+// fired, or the timer was cancelled or never scheduled. Repeating timers
+// don't expire.
 func (t *Timer) Expired() bool {
-	return false
+	return !t.Running
+}
+
+// Remaining returns the time until the timer expires next (ns).
+func (t *Timer) Remaining(sim *Simulation) int64 {
+	if !t.Running {
+		return 0
+	}
+	return max(t.End-sim.SimTimestamp, 0)
+}
+
+func (t *Timer) expire() {
+	if t.Handler != "" {
+		timerHandlersLock.RLock()
+		handler, ok := timerHandlers[t.Handler]
+		timerHandlersLock.RUnlock()
+		if ok {
+			handler(t)
+		} else {
+			log.Printf("Timer.expire: no handler registered for %v", t.Handler)
+		}
+	}
+	if t.OnExpiry != nil {
+		t.OnExpiry()
+	}
+}
+
+// serialize is used for standalone timers, see Simulation.SerializeRuntime.
+// Timers in components are saved along with the other runtime fields.
+func (t *Timer) serialize() map[string]any {
+	result := map[string]any{
+		"Start":    t.Start,
+		"End":      t.End,
+		"Handler":  t.Handler,
+		"Sequence": t.Sequence,
+	}
+	if t.Interval != 0 {
+		result["Interval"] = t.Interval
+	}
+	if t.Fired != 0 {
+		result["Fired"] = t.Fired
+	}
+	if t.Paused {
+		result["Paused"] = true
+	}
+	if t.Data != "" {
+		result["Data"] = t.Data
+	}
+	return result
+}
+
+func (t *Timer) construct(data map[string]any) {
+	t.Start = cast.ToInt64(data["Start"])
+	t.End = cast.ToInt64(data["End"])
+	t.Interval = cast.ToInt64(data["Interval"])
+	t.Fired = cast.ToInt(data["Fired"])
+	t.Paused = cast.ToBool(data["Paused"])
+	t.Sequence = cast.ToUint64(data["Sequence"])
+	t.Handler = cast.ToString(data["Handler"])
+	t.Data = cast.ToString(data["Data"])
+	t.Running = true
+	t.standalone = true
 }
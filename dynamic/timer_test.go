@@ -0,0 +1,92 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package dynamic
+
+import (
+	"testing"
+
+	"tlyakhov/gofoom/constants"
+)
+
+func TestTimers(t *testing.T) {
+	sim := NewSimulation()
+	var order []string
+	once := sim.NewTimer(3*constants.TimeStepNS, func() { order = append(order, "once") })
+	repeating := sim.NewRepeatingTimer(2*constants.TimeStepNS, func() { order = append(order, "repeat") })
+	cancelled := sim.NewTimer(constants.TimeStepNS, func() { order = append(order, "cancelled") })
+	cancelled.Cancel()
+
+	for range 5 {
+		sim.Tick()
+	}
+	expected := []string{"repeat", "once", "repeat"}
+	if len(order) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, order)
+		}
+	}
+	if !once.Expired() || once.Fired != 1 {
+		t.Errorf("One-shot timer should have expired once, fired %v times", once.Fired)
+	}
+	if repeating.Expired() || repeating.Fired != 2 {
+		t.Errorf("Repeating timer should still be running, fired %v times", repeating.Fired)
+	}
+	if _, ok := sim.Timers[once]; ok {
+		t.Errorf("Expired standalone timers should be removed")
+	}
+}
+
+func TestTimersPause(t *testing.T) {
+	sim := NewSimulation()
+	fired := 0
+	timer := sim.NewTimer(2*constants.TimeStepNS, func() { fired++ })
+
+	sim.EditorPaused = true
+	for range 5 {
+		sim.Tick()
+	}
+	timer.Pause()
+	sim.EditorPaused = false
+	for range 5 {
+		sim.Tick()
+	}
+	if fired != 0 {
+		t.Fatalf("Paused timer fired")
+	}
+	timer.Resume()
+	for range 2 {
+		sim.Tick()
+	}
+	if fired != 0 {
+		t.Fatalf("Timer fired early")
+	}
+	sim.Tick()
+	if fired != 1 {
+		t.Fatalf("Timer didn't fire after resuming")
+	}
+}
+
+func TestTimersSerializeRuntime(t *testing.T) {
+	var data []string
+	RegisterTimerHandler("TimerTest", func(timer *Timer) {
+		data = append(data, timer.Data)
+	})
+	sim := NewSimulation()
+	sim.NewHandlerTimer(3*constants.TimeStepNS, 0, "TimerTest", "saved")
+	sim.NewTimer(3*constants.TimeStepNS, func() { data = append(data, "not saved") })
+	sim.Tick()
+	saved := sim.SerializeRuntime()
+
+	loaded := NewSimulation()
+	loaded.ConstructRuntime(saved)
+	for range 3 {
+		loaded.Tick()
+	}
+	if len(data) != 1 || data[0] != "saved" {
+		t.Errorf("Expected only the handler timer to be restored, got %v", data)
+	}
+}
@@ -36,8 +36,11 @@ type Arena[T any, PT GenericAttachable[T]] struct {
 
 	// There should only be one element in this arena.
 	isSingleton bool
-	// The actual data
+	// The actual data. Chunks can be nil if they've been released, see
+	// Compact.
 	data []*componentChunk[T, PT]
+	// chunkFill is the number of components in each chunk.
+	chunkFill []int
 	// fill is a bitmap that tracks which slots in the arena are occupied by components.
 	fill bitmap.Bitmap
 	// typeOfT is the reflect.Type of the component data.
@@ -60,7 +63,11 @@ func (arena *Arena[T, PT]) From(source ComponentArena) {
 // It performs no bounds checking for performance reasons.
 func (arena *Arena[T, PT]) Value(index int) PT {
 	// No bounds checking for performance. This should always be inlined
-	ptr := PT(&(arena.data[index/chunkSize][index%chunkSize]))
+	chunk := arena.data[index/chunkSize]
+	if chunk == nil {
+		return nil
+	}
+	ptr := PT(&chunk[index%chunkSize])
 	if !ptr.IsAttached() {
 		return nil
 	}
@@ -73,7 +80,11 @@ func (arena *Arena[T, PT]) Component(index int) Component {
 	// No bounds checking for performance. This should always be inlined
 	// Duplicates code in .Value() because the return type is different here and nil
 	// in golang behaves idiosyncratically
-	ptr := PT(&(arena.data[index/chunkSize][index%chunkSize]))
+	chunk := arena.data[index/chunkSize]
+	if chunk == nil {
+		return nil
+	}
+	ptr := PT(&chunk[index%chunkSize])
 	if !ptr.IsAttached() {
 		return nil
 	}
@@ -93,7 +104,59 @@ func (arena *Arena[T, PT]) Detach(index int) {
 	// become invalid). Instead we use a bitmap fill list.
 	arena.fill.Remove(uint32(index))
 	arena.Length--
-	// TODO: Remove empty chunks
+	// Empty chunks aren't released here, since callers often detach
+	// components while iterating over the arena. See Compact.
+	arena.chunkFill[index/chunkSize]--
+}
+
+// trim releases any empty chunks at the end of the arena.
+func (arena *Arena[T, PT]) trim() {
+	n := len(arena.data)
+	for n > 0 && arena.chunkFill[n-1] == 0 {
+		arena.data[n-1] = nil
+		n--
+	}
+	arena.data = arena.data[:n]
+	arena.chunkFill = arena.chunkFill[:n]
+}
+
+// Compact releases the memory of any empty chunks. Components never move,
+// since there are long-lived pointers to them all over the place (and their
+// arena indices have to stay consistent, see Attached.SetArenaIndex).
+// Instead, Add fills the lowest free slots first, so that chunks near the end
+// tend to empty out over time. This shouldn't be called while iterating over
+// the arena. Returns the number of chunks released.
+func (arena *Arena[T, PT]) Compact() int {
+	released := 0
+	for i, chunk := range arena.data {
+		if chunk != nil && arena.chunkFill[i] == 0 {
+			arena.data[i] = nil
+			released++
+		}
+	}
+	arena.trim()
+	return released
+}
+
+// Stats reports the number of components and memory used by this arena.
+func (arena *Arena[T, PT]) Stats() ArenaStats {
+	stats := ArenaStats{
+		ID:       arena.componentID,
+		Name:     arena.String(),
+		Count:    arena.Length,
+		Capacity: arena.Cap(),
+	}
+	for i, chunk := range arena.data {
+		if chunk == nil {
+			continue
+		}
+		stats.Chunks++
+		if arena.chunkFill[i] == 0 {
+			stats.EmptyChunks++
+		}
+	}
+	stats.Bytes = stats.Chunks * chunkSize * int(arena.typeOfT.Size())
+	return stats
 }
 
 // AddTyped adds a component to the arena, automatically handling the Component
@@ -119,6 +182,10 @@ func (arena *Arena[T, PT]) Add(component *Component) {
 	if chunk >= uint32(len(arena.data)) {
 		// Create a new chunk
 		arena.data = append(arena.data, new(componentChunk[T, PT]))
+		arena.chunkFill = append(arena.chunkFill, 0)
+	} else if arena.data[chunk] == nil {
+		// This one was released, see Compact
+		arena.data[chunk] = new(componentChunk[T, PT])
 	}
 
 	indexInChunk := nextFree % chunkSize
@@ -129,8 +196,9 @@ func (arena *Arena[T, PT]) Add(component *Component) {
 
 	arena.fill.Set(nextFree)
 	*component = PT(&arena.data[chunk][indexInChunk])
-	(*component).Base().indexInArena = (int(nextFree))
+	(*component).Base().SetArenaIndex(int(nextFree))
 	arena.Length++
+	arena.chunkFill[chunk]++
 }
 
 // Replace replaces the component at the given index with the provided
@@ -144,7 +212,7 @@ func (arena *Arena[T, PT]) Replace(component *Component, index int) {
 	ptr := arena.Value(index)
 	*ptr = *((*component).(PT))
 	*component = ptr
-	ptr.Base().indexInArena = index
+	ptr.Base().SetArenaIndex(index)
 }
 
 // New creates a new Attachable component of the type stored in this arena.
@@ -0,0 +1,82 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ArenaStats describes the memory used by a component arena.
+type ArenaStats struct {
+	ID   ComponentID
+	Name string
+	// Count is the number of attached components.
+	Count int
+	// Capacity is the number of slots, including released chunks.
+	Capacity int
+	// Chunks is the number of allocated chunks.
+	Chunks int
+	// EmptyChunks is the number of allocated chunks without any components,
+	// which would be released by Compact.
+	EmptyChunks int
+	// Bytes is the memory used by allocated chunks.
+	Bytes int
+}
+
+func (s *ArenaStats) String() string {
+	return fmt.Sprintf("%v: %v/%v, %v chunks (%v empty), %v",
+		s.Name, s.Count, s.Capacity, s.Chunks, s.EmptyChunks, HumanBytes(s.Bytes))
+}
+
+// HumanBytes formats a byte count for display.
+func HumanBytes(bytes int) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(bytes)/(1<<10))
+	}
+	return fmt.Sprintf("%v B", bytes)
+}
+
+// MemoryStats reports the memory used by every component arena, largest
+// first. The total is the sum of all of them.
+func MemoryStats() (stats []ArenaStats, total ArenaStats) {
+	total.Name = "Total"
+	for i, arena := range arenas {
+		if i == 0 || arena == nil {
+			continue
+		}
+		s := arena.Stats()
+		total.Count += s.Count
+		total.Capacity += s.Capacity
+		total.Chunks += s.Chunks
+		total.EmptyChunks += s.EmptyChunks
+		total.Bytes += s.Bytes
+		stats = append(stats, s)
+	}
+	slices.SortFunc(stats, func(a, b ArenaStats) int {
+		if a.Bytes != b.Bytes {
+			return b.Bytes - a.Bytes
+		}
+		return int(a.ID) - int(b.ID)
+	})
+	return stats, total
+}
+
+// CompactArenas releases empty chunks in every component arena (see
+// Arena.Compact). Returns the number of bytes freed.
+func CompactArenas() int {
+	freed := 0
+	for i, arena := range arenas {
+		if i == 0 || arena == nil {
+			continue
+		}
+		before := arena.Stats().Bytes
+		arena.Compact()
+		freed += before - arena.Stats().Bytes
+	}
+	return freed
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import "testing"
+
+func TestArenaCompact(t *testing.T) {
+	Initialize()
+	arena := ArenaFor[mockComponent](mockCID)
+	entities := make([]Entity, chunkSize*3)
+	for i := range entities {
+		entities[i] = NewEntity()
+		NewAttachedComponent(entities[i], mockCID)
+	}
+	stats := arena.Stats()
+	if stats.Count != chunkSize*3 || stats.Chunks != 3 {
+		t.Fatalf("Expected 3 full chunks, got %v", stats.String())
+	}
+	kept := GetMockComponent(entities[0])
+	// Empty the middle and last chunks
+	for _, e := range entities[chunkSize:] {
+		Delete(e)
+	}
+	stats = arena.Stats()
+	if stats.Chunks != 3 || stats.EmptyChunks != 2 {
+		t.Fatalf("Expected 2 empty chunks before compacting, got %v", stats.String())
+	}
+	if freed := CompactArenas(); freed != stats.Bytes*2/3 {
+		t.Errorf("Expected %v bytes freed, got %v", stats.Bytes*2/3, freed)
+	}
+	stats = arena.Stats()
+	if stats.Chunks != 1 || stats.EmptyChunks != 0 || stats.Capacity != chunkSize {
+		t.Errorf("Expected 1 chunk after compacting, got %v", stats.String())
+	}
+	if GetMockComponent(entities[0]) != kept || kept.Base().indexInArena != 0 {
+		t.Errorf("Compacting moved a component")
+	}
+
+	// Fill a hole in the first chunk, then grow past it again.
+	Delete(entities[1])
+	e := NewEntity()
+	c := NewAttachedComponent(e, mockCID)
+	if c.Base().indexInArena != 1 {
+		t.Errorf("Expected freed slot 1 to be reused, got %v", c.Base().indexInArena)
+	}
+	for range chunkSize {
+		NewAttachedComponent(NewEntity(), mockCID)
+	}
+	if stats = arena.Stats(); stats.Chunks != 2 || stats.Count != chunkSize*2 {
+		t.Errorf("Expected 2 chunks after growing, got %v", stats.String())
+	}
+
+	all, total := MemoryStats()
+	if len(all) == 0 || total.Count < stats.Count || total.Bytes < stats.Bytes {
+		t.Errorf("Unexpected memory stats total %v", total.String())
+	}
+}
+
+func TestArenaCompactHole(t *testing.T) {
+	Initialize()
+	arena := ArenaFor[mockComponent](mockCID)
+	entities := make([]Entity, chunkSize*3)
+	for i := range entities {
+		entities[i] = NewEntity()
+		NewAttachedComponent(entities[i], mockCID)
+	}
+	for _, e := range entities[chunkSize : chunkSize*2] {
+		Delete(e)
+	}
+	arena.Compact()
+	if stats := arena.Stats(); stats.Chunks != 2 || stats.Capacity != chunkSize*3 {
+		t.Fatalf("Expected middle chunk to be released, got %v", stats.String())
+	}
+	for i := chunkSize; i < chunkSize*2; i++ {
+		if arena.Value(i) != nil || arena.Component(i) != nil {
+			t.Fatalf("Expected nil component in released chunk at %v", i)
+		}
+	}
+	// The released chunk should be reallocated before growing.
+	c := NewAttachedComponent(NewEntity(), mockCID)
+	if c.Base().indexInArena != chunkSize {
+		t.Errorf("Expected index %v, got %v", chunkSize, c.Base().indexInArena)
+	}
+	if GetMockComponent(entities[chunkSize*2]) == nil {
+		t.Errorf("Lost a component in the last chunk")
+	}
+}
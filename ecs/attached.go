@@ -31,6 +31,9 @@ type Attached struct {
 	Flags ComponentFlags `editable:"Flags" edit_type:"Flags"`
 	// indexInArena is the index of this component within its arena in the ECS.
 	indexInArena int
+	// generation is the value of the global change counter the last time this
+	// component was attached or modified, see MarkModified.
+	generation uint64
 	// Entities is a table of entities to which this component is attached. This
 	// is used for components that can be attached to multiple entities.
 	// TODO: Consider breaking this out into a `Shared` mixin, to avoid wasting
@@ -38,6 +41,12 @@ type Attached struct {
 	Entities EntityTable `editable:"Component" edit_type:"Component" edit_sort:"0"`
 }
 
+// Generation returns the change generation of this component, which increases
+// every time it's attached or modified.
+func (a *Attached) Generation() uint64 {
+	return a.generation
+}
+
 // IsActive checks if the component is active, attached to any entities, and not nil.
 func (a *Attached) IsActive() bool {
 	return a.IsAttached() && (a.Flags&ComponentActive != 0)
@@ -29,6 +29,12 @@ func (c *BaseController) EditorPausedMethods() ControllerMethod {
 	return 0
 }
 
+// Access returns the components that this controller reads and writes. The
+// base implementation returns nil, so the controller always runs serially.
+func (c *BaseController) Access() *ControllerAccess {
+	return nil
+}
+
 // Target determines whether the controller should act on a specific entity and
 // component. The base implementation always returns true.
 func (c *BaseController) Target(a Component, entity Entity) bool {
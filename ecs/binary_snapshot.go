@@ -0,0 +1,441 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"tlyakhov/gofoom/dynamic"
+
+	"sigs.k8s.io/yaml"
+)
+
+// BinaryWorldExtension is the file extension for worlds stored in the binary
+// format. Anything else is read and written as YAML.
+const BinaryWorldExtension = ".foom"
+
+/*
+The binary format stores exactly the same tree of values as the YAML one
+(maps, arrays, strings, numbers, bools, and nulls), so any file can be
+converted back and forth without losing anything. Like YAML, numbers are
+always read back as float64 and map keys are sorted.
+
+Every string (component type names, entity references, map keys, etc...) is
+only stored once: the first time it appears it's written in full, and after
+that it's written as an index into the list of strings seen so far.
+
+	file   = magic version value
+	magic  = "FOOM"
+	value  = tag payload
+*/
+
+const binarySnapshotVersion = 1
+
+var binarySnapshotMagic = []byte("FOOM")
+
+const (
+	binaryTagNil byte = iota
+	binaryTagFalse
+	binaryTagTrue
+	binaryTagInt    // zig-zag varint
+	binaryTagFloat  // 8 bytes, little endian
+	binaryTagString // uvarint length, bytes. Adds to the string table.
+	binaryTagStringRef
+	binaryTagArray // uvarint count, values
+	binaryTagMap   // uvarint count, (key value) pairs. Keys are strings.
+)
+
+// Integers in this range survive the trip through float64.
+const maxBinaryInt = 1 << 53
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+type binaryEncoder struct {
+	buf     []byte
+	strings map[string]int
+}
+
+func (enc *binaryEncoder) writeString(s string) {
+	if index, ok := enc.strings[s]; ok {
+		enc.buf = append(enc.buf, binaryTagStringRef)
+		enc.buf = binary.AppendUvarint(enc.buf, uint64(index))
+		return
+	}
+	enc.strings[s] = len(enc.strings)
+	enc.buf = append(enc.buf, binaryTagString)
+	enc.buf = binary.AppendUvarint(enc.buf, uint64(len(s)))
+	enc.buf = append(enc.buf, s...)
+}
+
+func (enc *binaryEncoder) writeFloat(f float64) {
+	if f == math.Trunc(f) && f >= -maxBinaryInt && f <= maxBinaryInt && !(f == 0 && math.Signbit(f)) {
+		enc.buf = append(enc.buf, binaryTagInt)
+		enc.buf = binary.AppendVarint(enc.buf, int64(f))
+		return
+	}
+	enc.buf = append(enc.buf, binaryTagFloat)
+	enc.buf = binary.LittleEndian.AppendUint64(enc.buf, math.Float64bits(f))
+}
+
+func (enc *binaryEncoder) writeMap(m map[string]any) error {
+	enc.buf = append(enc.buf, binaryTagMap)
+	enc.buf = binary.AppendUvarint(enc.buf, uint64(len(m)))
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		enc.writeString(key)
+		if err := enc.write(m[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (enc *binaryEncoder) write(value any) error {
+	// Fast paths for what YAML parsing and most Serialize() methods return.
+	switch v := value.(type) {
+	case nil:
+		enc.buf = append(enc.buf, binaryTagNil)
+		return nil
+	case bool:
+		if v {
+			enc.buf = append(enc.buf, binaryTagTrue)
+		} else {
+			enc.buf = append(enc.buf, binaryTagFalse)
+		}
+		return nil
+	case string:
+		enc.writeString(v)
+		return nil
+	case float64:
+		enc.writeFloat(v)
+		return nil
+	case int:
+		enc.writeFloat(float64(v))
+		return nil
+	case map[string]any:
+		if v == nil {
+			return enc.write(nil)
+		}
+		return enc.writeMap(v)
+	case []any:
+		if v == nil {
+			return enc.write(nil)
+		}
+		enc.buf = append(enc.buf, binaryTagArray)
+		enc.buf = binary.AppendUvarint(enc.buf, uint64(len(v)))
+		for _, item := range v {
+			if err := enc.write(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Snapshot:
+		return enc.write([]any(v))
+	case []string:
+		if v == nil {
+			return enc.write(nil)
+		}
+		enc.buf = append(enc.buf, binaryTagArray)
+		enc.buf = binary.AppendUvarint(enc.buf, uint64(len(v)))
+		for _, item := range v {
+			enc.writeString(item)
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(value)
+	t := rv.Type()
+	if !t.Implements(jsonMarshalerType) && !t.Implements(textMarshalerType) {
+		switch t.Kind() {
+		case reflect.Bool:
+			return enc.write(rv.Bool())
+		case reflect.String:
+			enc.writeString(rv.String())
+			return nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			enc.writeFloat(float64(rv.Int()))
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			enc.writeFloat(float64(rv.Uint()))
+			return nil
+		case reflect.Float32:
+			// Match the shortest representation that YAML would write.
+			f, _ := strconv.ParseFloat(strconv.FormatFloat(rv.Float(), 'g', -1, 32), 64)
+			enc.writeFloat(f)
+			return nil
+		case reflect.Float64:
+			enc.writeFloat(rv.Float())
+			return nil
+		case reflect.Pointer, reflect.Interface:
+			if rv.IsNil() {
+				return enc.write(nil)
+			}
+			return enc.write(rv.Elem().Interface())
+		case reflect.Slice, reflect.Array:
+			if t.Kind() == reflect.Slice && rv.IsNil() {
+				return enc.write(nil)
+			}
+			// JSON stores byte slices as base64, let that path handle it.
+			if t.Elem().Kind() != reflect.Uint8 {
+				enc.buf = append(enc.buf, binaryTagArray)
+				enc.buf = binary.AppendUvarint(enc.buf, uint64(rv.Len()))
+				for i := range rv.Len() {
+					if err := enc.write(rv.Index(i).Interface()); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+		}
+	}
+
+	// Anything else gets the same treatment as it would when writing YAML.
+	// This is slow, but rare.
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("ecs.MarshalBinarySnapshot: %w", err)
+	}
+	var generic any
+	if err = json.Unmarshal(data, &generic); err != nil {
+		return fmt.Errorf("ecs.MarshalBinarySnapshot: %w", err)
+	}
+	return enc.write(generic)
+}
+
+// MarshalBinarySnapshot encodes a snapshot in the binary world format.
+func MarshalBinarySnapshot(snapshot Snapshot) ([]byte, error) {
+	enc := binaryEncoder{strings: make(map[string]int)}
+	enc.buf = append(enc.buf, binarySnapshotMagic...)
+	enc.buf = binary.AppendUvarint(enc.buf, binarySnapshotVersion)
+	if err := enc.write(snapshot); err != nil {
+		return nil, err
+	}
+	return enc.buf, nil
+}
+
+var errBinarySnapshotTruncated = errors.New("ecs.UnmarshalBinarySnapshot: unexpected end of data")
+
+type binaryDecoder struct {
+	data    []byte
+	pos     int
+	strings []string
+}
+
+func (dec *binaryDecoder) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(dec.data[dec.pos:])
+	if n <= 0 {
+		return 0, errBinarySnapshotTruncated
+	}
+	dec.pos += n
+	return v, nil
+}
+
+// count reads the length of an array, map, or string, making sure it's not
+// obviously corrupt (every element takes at least one byte).
+func (dec *binaryDecoder) count() (int, error) {
+	n, err := dec.uvarint()
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(len(dec.data)-dec.pos) {
+		return 0, errBinarySnapshotTruncated
+	}
+	return int(n), nil
+}
+
+func (dec *binaryDecoder) read() (any, error) {
+	if dec.pos >= len(dec.data) {
+		return nil, errBinarySnapshotTruncated
+	}
+	tag := dec.data[dec.pos]
+	dec.pos++
+
+	switch tag {
+	case binaryTagNil:
+		return nil, nil
+	case binaryTagFalse:
+		return false, nil
+	case binaryTagTrue:
+		return true, nil
+	case binaryTagInt:
+		v, n := binary.Varint(dec.data[dec.pos:])
+		if n <= 0 {
+			return nil, errBinarySnapshotTruncated
+		}
+		dec.pos += n
+		return float64(v), nil
+	case binaryTagFloat:
+		if dec.pos+8 > len(dec.data) {
+			return nil, errBinarySnapshotTruncated
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(dec.data[dec.pos:]))
+		dec.pos += 8
+		return v, nil
+	case binaryTagString, binaryTagStringRef:
+		return dec.readString(tag)
+	case binaryTagArray:
+		n, err := dec.count()
+		if err != nil {
+			return nil, err
+		}
+		result := make([]any, n)
+		for i := range n {
+			if result[i], err = dec.read(); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case binaryTagMap:
+		n, err := dec.count()
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[string]any, n)
+		for range n {
+			if dec.pos >= len(dec.data) {
+				return nil, errBinarySnapshotTruncated
+			}
+			tag := dec.data[dec.pos]
+			dec.pos++
+			key, err := dec.readString(tag)
+			if err != nil {
+				return nil, err
+			}
+			if result[key], err = dec.read(); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("ecs.UnmarshalBinarySnapshot: unknown tag %v at offset %v", tag, dec.pos-1)
+}
+
+func (dec *binaryDecoder) readString(tag byte) (string, error) {
+	switch tag {
+	case binaryTagString:
+		n, err := dec.count()
+		if err != nil {
+			return "", err
+		}
+		s := string(dec.data[dec.pos : dec.pos+n])
+		dec.pos += n
+		dec.strings = append(dec.strings, s)
+		return s, nil
+	case binaryTagStringRef:
+		index, err := dec.uvarint()
+		if err != nil {
+			return "", err
+		}
+		if index >= uint64(len(dec.strings)) {
+			return "", fmt.Errorf("ecs.UnmarshalBinarySnapshot: string reference %v out of range at offset %v", index, dec.pos)
+		}
+		return dec.strings[index], nil
+	}
+	return "", fmt.Errorf("ecs.UnmarshalBinarySnapshot: expected string at offset %v", dec.pos-1)
+}
+
+// UnmarshalBinarySnapshot decodes a snapshot written by MarshalBinarySnapshot.
+func UnmarshalBinarySnapshot(data []byte) (Snapshot, error) {
+	if !bytes.HasPrefix(data, binarySnapshotMagic) {
+		return nil, errors.New("ecs.UnmarshalBinarySnapshot: not a binary world file")
+	}
+	dec := binaryDecoder{data: data, pos: len(binarySnapshotMagic)}
+	version, err := dec.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if version != binarySnapshotVersion {
+		return nil, fmt.Errorf("ecs.UnmarshalBinarySnapshot: unsupported version %v (expected %v)", version, binarySnapshotVersion)
+	}
+	root, err := dec.read()
+	if err != nil {
+		return nil, err
+	}
+	if dec.pos != len(data) {
+		return nil, fmt.Errorf("ecs.UnmarshalBinarySnapshot: %v bytes of trailing data", len(data)-dec.pos)
+	}
+	snapshot, ok := root.([]any)
+	if !ok || snapshot == nil {
+		return nil, errors.New("ecs.UnmarshalBinarySnapshot: root must be an array")
+	}
+	return snapshot, nil
+}
+
+// IsBinaryWorld checks whether a world file should be stored in the binary
+// format, based on its extension.
+func IsBinaryWorld(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), BinaryWorldExtension)
+}
+
+// IsWorldFile checks whether a file is a world in any of the supported
+// formats.
+func IsWorldFile(path string) bool {
+	return IsBinaryWorld(path) || strings.HasSuffix(path, ".yaml")
+}
+
+// ReadSnapshotFile reads a world file in either format. It doesn't touch any
+// ECS state, so it's safe to call from any goroutine.
+func ReadSnapshotFile(path string) (Snapshot, error) {
+	bytes, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("ecs.ReadSnapshotFile: reading file: %w", err)
+	}
+
+	if IsBinaryWorld(path) {
+		snapshot, err := UnmarshalBinarySnapshot(bytes)
+		if err != nil {
+			return nil, fmt.Errorf("ecs.ReadSnapshotFile: %v: %w", path, err)
+		}
+		return snapshot, nil
+	}
+
+	var yamlTree any
+	if err := yaml.Unmarshal(bytes, &yamlTree); err != nil {
+		return nil, fmt.Errorf("ecs.ReadSnapshotFile: yaml parsing: %w", err)
+	}
+
+	snapshot, ok := yamlTree.([]any)
+	if !ok || snapshot == nil {
+		return nil, fmt.Errorf("ecs.ReadSnapshotFile: YAML root must be an array")
+	}
+
+	return snapshot, nil
+}
+
+// WriteSnapshotFile writes a world file, in the binary format if the path has
+// BinaryWorldExtension, or YAML otherwise.
+func WriteSnapshotFile(path string, snapshot Snapshot) error {
+	if err := dynamic.CheckSandbox("writing " + path); err != nil {
+		return fmt.Errorf("ecs.WriteSnapshotFile: %w", err)
+	}
+	var bytes []byte
+	var err error
+	if IsBinaryWorld(path) {
+		bytes, err = MarshalBinarySnapshot(snapshot)
+	} else {
+		bytes, err = yaml.Marshal(snapshot)
+	}
+	if err != nil {
+		return fmt.Errorf("ecs.WriteSnapshotFile: %w", err)
+	}
+	if err = os.WriteFile(path, bytes, os.ModePerm); err != nil {
+		return fmt.Errorf("ecs.WriteSnapshotFile: %w", err)
+	}
+	return nil
+}
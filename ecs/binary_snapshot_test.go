@@ -0,0 +1,133 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+const binaryTestWorld = `
+- Entity: ∈⋮1
+  ecs.Named:
+    Name: first
+  Numbers: [0, -1, 1.5, 9007199254740993, -0.25, 1e300]
+  Flags: [true, false, null]
+- Entity: ∈⋮2
+  ecs.Named:
+    Name: second
+  Nested:
+    Link: ∈⋮1
+    Empty: {}
+    List: []
+`
+
+func yamlRoundTrip(t *testing.T, snapshot Snapshot) Snapshot {
+	bytes, err := yaml.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result Snapshot
+	if err = yaml.Unmarshal(bytes, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func binaryRoundTrip(t *testing.T, snapshot Snapshot) Snapshot {
+	bytes, err := MarshalBinarySnapshot(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := UnmarshalBinarySnapshot(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestBinarySnapshotRoundTrip(t *testing.T) {
+	var snapshot Snapshot
+	if err := yaml.Unmarshal([]byte(binaryTestWorld), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if result := binaryRoundTrip(t, snapshot); !reflect.DeepEqual(snapshot, result) {
+		t.Errorf("Binary round trip doesn't match.\nExpected: %v\nActual: %v", snapshot, result)
+	}
+
+	bytes, _ := MarshalBinarySnapshot(snapshot)
+	yamlBytes, _ := yaml.Marshal(snapshot)
+	if len(bytes) >= len(yamlBytes) {
+		t.Errorf("Expected binary (%v bytes) to be smaller than YAML (%v bytes)", len(bytes), len(yamlBytes))
+	}
+}
+
+type binaryTestEnum int
+
+func (e binaryTestEnum) MarshalText() ([]byte, error) {
+	return []byte("enum"), nil
+}
+
+// Serialize() returns all kinds of Go types, which should end up the same as
+// if they'd been written to YAML and read back.
+func TestBinarySnapshotGoTypes(t *testing.T) {
+	snapshot := Snapshot{map[string]any{
+		"Entity":  Entity(5).Serialize(),
+		"ID":      EntitySourceID(3),
+		"Int":     -42,
+		"Uint":    uint32(42),
+		"Float32": float32(0.1),
+		"Strings": []string{"a", "b", "a"},
+		"Vector":  [3]float64{1, 2.5, 3},
+		"Bytes":   []byte{1, 2, 3},
+		"Enum":    binaryTestEnum(1),
+		"Map":     map[string]int{"x": 1},
+		"Struct":  struct{ A, b int }{1, 2},
+		"Nil":     []any(nil),
+	}}
+	expected := yamlRoundTrip(t, snapshot)
+	if result := binaryRoundTrip(t, snapshot); !reflect.DeepEqual(expected, result) {
+		t.Errorf("Binary round trip doesn't match YAML.\nExpected: %v\nActual: %v", expected, result)
+	}
+}
+
+func TestBinarySnapshotCorrupt(t *testing.T) {
+	var snapshot Snapshot
+	if err := yaml.Unmarshal([]byte(binaryTestWorld), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	bytes, _ := MarshalBinarySnapshot(snapshot)
+	for i := range len(bytes) {
+		if _, err := UnmarshalBinarySnapshot(bytes[:i]); err == nil {
+			t.Fatalf("Expected error for data truncated to %v bytes", i)
+		}
+	}
+	if _, err := UnmarshalBinarySnapshot(append(bytes, 0)); err == nil {
+		t.Errorf("Expected error for trailing data")
+	}
+}
+
+func TestSnapshotFileFormats(t *testing.T) {
+	var snapshot Snapshot
+	if err := yaml.Unmarshal([]byte(binaryTestWorld), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"world.yaml", "world" + BinaryWorldExtension} {
+		path := filepath.Join(dir, name)
+		if err := WriteSnapshotFile(path, snapshot); err != nil {
+			t.Fatal(err)
+		}
+		result, err := ReadSnapshotFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(snapshot, result) {
+			t.Errorf("%v doesn't match after writing and reading", name)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/kelindar/bitmap"
+)
+
+// ChangeKind is a bitmask of the kinds of component changes that can be
+// subscribed to.
+type ChangeKind uint8
+
+const (
+	// ChangeAttached is sent after a component is attached to an entity.
+	ChangeAttached ChangeKind = 1 << iota
+	// ChangeDetached is sent after a component is detached from an entity
+	// (including when the entity is deleted).
+	ChangeDetached
+	// ChangeModified is sent when MarkModified is called for a component.
+	ChangeModified
+)
+
+// ChangeHandler is called for changes to components of a subscribed type.
+type ChangeHandler func(kind ChangeKind, component Component, entity Entity)
+
+type changeSubscription struct {
+	Kinds   ChangeKind
+	Handler ChangeHandler
+}
+
+var (
+	// changeGeneration is incremented every time any component changes.
+	changeGeneration atomic.Uint64
+	// changedEntities are the entities that have changed since the last
+	// precompute or frame, see PrecomputeChanged. Controllers can change
+	// components concurrently, so it's guarded by changedLock.
+	changedEntities bitmap.Bitmap
+	changedLock     sync.Mutex
+)
+
+// Subscribe registers a handler for changes to components with a given ID.
+// Like controllers, subscriptions are usually registered in init() and last
+// for the lifetime of the program.
+func (types *typeMetadata) Subscribe(id ComponentID, kinds ChangeKind, handler ChangeHandler) {
+	types.lock.Lock()
+	defer types.lock.Unlock()
+	for len(types.Subscriptions) <= int(id) {
+		types.Subscriptions = append(types.Subscriptions, nil)
+	}
+	types.Subscriptions[id] = append(types.Subscriptions[id], changeSubscription{
+		Kinds:   kinds,
+		Handler: handler,
+	})
+}
+
+// componentChanged records a change to a component and notifies subscribers.
+func componentChanged(kind ChangeKind, component Component, entity Entity) {
+	generation := changeGeneration.Add(1)
+	if kind != ChangeDetached {
+		component.Base().generation = generation
+	}
+	changedLock.Lock()
+	changedEntities.Set(uint32(entity))
+	changedLock.Unlock()
+
+	id := component.ComponentID()
+	subs := Types().Subscriptions
+	if int(id) >= len(subs) {
+		return
+	}
+	for _, sub := range subs[id] {
+		if sub.Kinds&kind != 0 {
+			sub.Handler(kind, component, entity)
+		}
+	}
+}
+
+// MarkModified should be called after changing a component's fields outside
+// of its controllers (e.g. in the editor). The entity will be precomputed by
+// the next call to PrecomputeChanged, and subscribers are notified.
+func MarkModified(component Component, entity Entity) {
+	if component == nil || entity == 0 {
+		return
+	}
+	componentChanged(ChangeModified, component, entity)
+}
+
+// markEntitiesModified calls MarkModified for every entity a component is
+// attached to.
+func markEntitiesModified(component Component) {
+	for _, e := range component.Base().Entities {
+		MarkModified(component, e)
+	}
+}
+
+// ChangeGeneration returns the current value of the global change counter.
+// Comparing it with Attached.Generation can tell whether a component has
+// changed since some point in time.
+func ChangeGeneration() uint64 {
+	return changeGeneration.Load()
+}
+
+// IsChanged checks whether an entity has changed since the last precompute or
+// frame.
+func IsChanged(entity Entity) bool {
+	changedLock.Lock()
+	defer changedLock.Unlock()
+	return changedEntities.Contains(uint32(entity))
+}
+
+// clearChanged forgets the changed entities, e.g. once they've been
+// precomputed.
+func clearChanged() {
+	changedLock.Lock()
+	changedEntities.Clear()
+	changedLock.Unlock()
+}
+
+// PrecomputeChanged runs ControllerPrecompute for every entity that was
+// attached to, detached from, or modified since the last precompute. This is
+// much cheaper than precomputing the whole world, but controllers that depend
+// on other entities (e.g. portals) may still need a full precompute.
+func PrecomputeChanged() {
+	changedLock.Lock()
+	changed := changedEntities
+	changedEntities = bitmap.Bitmap{}
+	changedLock.Unlock()
+	changed.Range(func(entity uint32) {
+		if Entities.Contains(entity) {
+			ActAllControllersOneEntity(Entity(entity), ControllerPrecompute)
+		}
+	})
+}
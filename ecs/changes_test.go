@@ -0,0 +1,111 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+type mockChange struct {
+	kind ChangeKind
+	Entity
+}
+
+func TestChangeSubscriptions(t *testing.T) {
+	Initialize()
+	var changes []mockChange
+	Types().Subscribe(mockCounterBCID, ChangeAttached|ChangeDetached|ChangeModified, func(kind ChangeKind, c Component, e Entity) {
+		if changes != nil {
+			changes = append(changes, mockChange{kind, e})
+		}
+	})
+	defer func() { Types().Subscriptions[mockCounterBCID] = nil }()
+
+	changes = make([]mockChange, 0)
+	e1, e2 := NewEntity(), NewEntity()
+	b := NewAttachedComponent(e1, mockCounterBCID)
+	gen := b.Base().Generation()
+	NewAttachedComponent(e2, mockCounterACID)
+	MarkModified(b, e1)
+	if b.Base().Generation() <= gen || b.Base().Generation() != ChangeGeneration() {
+		t.Errorf("Expected generation to increase after modification")
+	}
+	NewAttachedComponent(e2, mockCounterBCID)
+	DetachComponent(mockCounterBCID, e1)
+	Delete(e2)
+
+	expected := []mockChange{
+		{ChangeAttached, e1},
+		{ChangeModified, e1},
+		{ChangeAttached, e2},
+		{ChangeDetached, e1},
+		{ChangeDetached, e2},
+	}
+	if !slices.Equal(changes, expected) {
+		t.Errorf("Expected changes %v, got %v", expected, changes)
+	}
+}
+
+func TestPrecomputeChanged(t *testing.T) {
+	Initialize()
+	e1, e2, e3 := NewEntity(), NewEntity(), NewEntity()
+	defer deleteEntities([]Entity{e1, e2, e3})
+	c1 := NewAttachedComponent(e1, mockCID)
+	NewAttachedComponent(e2, mockCID)
+	NewAttachedComponent(e3, mockCID)
+	ActAllControllers(ControllerPrecompute)
+	if IsChanged(e1) {
+		t.Errorf("Expected no changes after a full precompute")
+	}
+
+	MarkModified(c1, e1)
+	NewAttachedComponent(e3, mockCounterACID)
+	mockPrecomputed = make([]Entity, 0)
+	defer func() { mockPrecomputed = nil }()
+	PrecomputeChanged()
+	if expected := []Entity{e1, e3}; !slices.Equal(mockPrecomputed, expected) {
+		t.Errorf("Expected %v to be precomputed, got %v", expected, mockPrecomputed)
+	}
+	if IsChanged(e1) || IsChanged(e3) {
+		t.Errorf("Expected no changes after PrecomputeChanged")
+	}
+
+	// Changes that nothing precomputes are dropped by the next frame.
+	MarkModified(c1, e1)
+	ActAllControllers(ControllerFrame)
+	if IsChanged(e1) {
+		t.Errorf("Expected no changes after a frame")
+	}
+}
+
+func TestConcurrentChanges(t *testing.T) {
+	Initialize()
+	entities := make([]Entity, 8)
+	components := make([]Component, len(entities))
+	for i := range entities {
+		entities[i] = NewEntity()
+		components[i] = NewAttachedComponent(entities[i], mockCounterACID)
+	}
+	defer deleteEntities(entities)
+	gen := ChangeGeneration()
+	var wg sync.WaitGroup
+	for i := range entities {
+		wg.Go(func() {
+			for range 100 {
+				MarkModified(components[i], entities[i])
+			}
+		})
+	}
+	wg.Wait()
+	if actual := ChangeGeneration() - gen; actual != 800 {
+		t.Errorf("Expected 800 changes, got %v", actual)
+	}
+	for _, e := range entities {
+		if !IsChanged(e) {
+			t.Errorf("Expected %v to be changed", e)
+		}
+	}
+}
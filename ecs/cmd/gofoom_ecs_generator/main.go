@@ -6,10 +6,13 @@ import (
 	"go/ast"
 	"go/format"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 	"text/template"
 
 	"golang.org/x/tools/go/packages"
@@ -22,6 +25,8 @@ SET UP: go install ./ecs/cmd/gofoom_ecs_generator
 Then, add `//go:generate gofoom_ecs_generator .` in a file to any directory with
 components.
 
+TestGeneratedUpToDate fails if the generated files in components/ are stale.
+
 ***/
 
 // componentTemplate is for boilerplate code for components.
@@ -29,17 +34,27 @@ const componentTemplate = `
 // Code generated by ecs/cmd/gofoom_ecs_generator. DO NOT EDIT.
 package {{.PackageName}}
 
-import "tlyakhov/gofoom/ecs"
+{{- if eq (len .Imports) 1 }}
+import {{index .Imports 0}}
+{{- else }}
+import (
+{{- range .Imports }}
+	{{.}}
+{{- end }}
+)
+{{- end }}
 
 {{range $name, $attached := .Components }}
 var {{$name}}CID ecs.ComponentID
 {{- end }}
 
+{{- if .Components }}
 func init() {
 {{- range $name, $attached := .Components }}
 	{{$name}}CID = ecs.RegisterComponent(&ecs.Arena[{{$name}}, *{{$name}}]{})
 {{- end }}
 }
+{{- end }}
 
 {{- range $name, $attached := .Components }}
 func Get{{$name}}(e ecs.Entity) *{{$name}} {
@@ -57,15 +72,21 @@ func (*{{$name}}) ComponentID() ecs.ComponentID {
     return {{$name}}CID
 }
 {{- end }}
+{{.Serialization}}
 `
 
+// generatedFileName is the conventional name for the generated code.
+const generatedFileName = "zz_ecs_generated.go"
+
 type AttachedStruct struct {
 	Singleton bool
 }
 
 type TemplateData struct {
-	Components  map[string]AttachedStruct
-	PackageName string
+	Components    map[string]AttachedStruct
+	PackageName   string
+	Imports       []string
+	Serialization string
 }
 
 // A slice to store the names of structs that embed "Attached".
@@ -147,28 +168,28 @@ func inspectTypes(pkg *packages.Package, n ast.Node) bool {
 
 }
 
-func main() {
-	// --- 1. Argument Handling ---
-	// Expect a directory path as the argument.
-	if len(os.Args) != 2 {
-		log.Fatalf("Usage: %s <directory>", os.Args[0])
-	}
-	dir := os.Args[1]
+// generate loads the packages under dir and returns the formatted
+// zz_ecs_generated.go contents for each package that needs one, keyed by
+// output path.
+func generate(dir string) (map[string][]byte, error) {
+	componentsByPackage = make(map[*packages.Package]map[string]AttachedStruct)
+	numComponents = 0
 
-	// --- 2. Package Loading ---
+	// --- 1. Package Loading ---
 	// Configure the package loader. We need to parse syntax trees.
 	cfg := &packages.Config{
-		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
 		Dir:   dir,
 		Tests: false, // Don't include test files
 	}
 	// Load all packages in the specified directory.
 	pkgs, err := packages.Load(cfg, "./...")
 	if err != nil {
-		log.Fatalf("Failed to load packages in directory %s: %v", dir, err)
+		return nil, fmt.Errorf("failed to load packages in directory %s: %w", dir, err)
 	}
 
-	// --- 3. AST Traversal & Struct Identification ---
+	// --- 2. AST Traversal & Struct Identification ---
 
 	for {
 		prevNum := numComponents
@@ -184,26 +205,47 @@ func main() {
 		}
 	}
 
-	// If no relevant structs were found, exit gracefully.
-	if numComponents == 0 {
-		fmt.Println("No structs embedding 'ecs.Attached' found in the file.")
-		return
-	}
-
-	// --- 4. Code Generation ---
+	// --- 3. Code Generation ---
 	// Parse our boilerplate template.
 	tmpl, err := template.New("boilerplate").Parse(componentTemplate)
 	if err != nil {
-		log.Fatalf("Failed to parse template: %v", err)
+		return nil, fmt.Errorf("failed to parse template: %w", err)
 	}
 
-	for pkg, components := range componentsByPackage {
+	files := make(map[string][]byte)
+	for _, pkg := range pkgs {
+		components := componentsByPackage[pkg]
+		imports := make(map[string]string)
+		serialization, err := generateSerialization(pkg, imports)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate serialization for package %v: %w", pkg.Name, err)
+		}
+		if len(components) == 0 && serialization == "" {
+			continue
+		}
+		if len(components) > 0 {
+			imports["tlyakhov/gofoom/ecs"] = "ecs"
+		}
+		data := TemplateData{
+			Components:    components,
+			PackageName:   pkg.Name,
+			Serialization: serialization,
+		}
+		for path, name := range imports {
+			if strings.HasSuffix(path, "/"+name) || path == name {
+				data.Imports = append(data.Imports, strconv.Quote(path))
+			} else {
+				data.Imports = append(data.Imports, name+" "+strconv.Quote(path))
+			}
+		}
+		slices.Sort(data.Imports)
+
 		// A buffer to hold the generated code.
 		var generatedCode bytes.Buffer
 
-		err := tmpl.Execute(&generatedCode, TemplateData{Components: components, PackageName: pkg.Name})
+		err = tmpl.Execute(&generatedCode, data)
 		if err != nil {
-			log.Fatalf("Failed to execute template for package %v: %v", pkg.Name, err)
+			return nil, fmt.Errorf("failed to execute template for package %v: %w", pkg.Name, err)
 		}
 
 		formattedCode, err := format.Source(generatedCode.Bytes())
@@ -214,15 +256,34 @@ func main() {
 
 		// We use the directory of the first Go file in the package.
 		outputDir := filepath.Dir(pkg.GoFiles[0])
-		// A common convention for the generated file name.
-		outputFileName := "zz_ecs_generated.go"
-		outputFilePath := filepath.Join(outputDir, outputFileName)
+		files[filepath.Join(outputDir, generatedFileName)] = formattedCode
+	}
+	return files, nil
+}
 
+func main() {
+	// Expect a directory path as the argument.
+	if len(os.Args) != 2 {
+		log.Fatalf("Usage: %s <directory>", os.Args[0])
+	}
+
+	files, err := generate(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	paths := slices.Sorted(maps.Keys(files))
+	for _, outputFilePath := range paths {
 		// Write the generated code to the new file.
-		err = os.WriteFile(outputFilePath, formattedCode, 0644)
+		err = os.WriteFile(outputFilePath, files[outputFilePath], 0644)
 		if err != nil {
 			log.Fatalf("Failed to write generated code to file %s: %v", outputFilePath, err)
 		}
-		fmt.Printf("Successfully generated boilerplate for %d struct(s) in %s\n", len(components), outputFilePath)
+		fmt.Printf("Successfully generated boilerplate in %s\n", outputFilePath)
+	}
+
+	// If no relevant structs were found, exit gracefully.
+	if len(files) == 0 {
+		fmt.Println("No structs embedding 'ecs.Attached' or with an //ecs:serialize directive found.")
 	}
 }
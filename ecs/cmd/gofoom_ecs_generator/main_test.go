@@ -0,0 +1,50 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGeneratedUpToDate fails if any checked-in zz_ecs_generated.go differs
+// from what the generator produces now. To fix it, run
+// `go run ../ecs/cmd/gofoom_ecs_generator .` from the components directory.
+func TestGeneratedUpToDate(t *testing.T) {
+	dir := filepath.Join("..", "..", "..", "components")
+	files, err := generate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("Nothing generated in %v", dir)
+	}
+	for path, generated := range files {
+		existing, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("%v", err)
+			continue
+		}
+		if !bytes.Equal(existing, generated) {
+			t.Errorf("%v is stale, re-run the generator", path)
+		}
+	}
+
+	// Generated files without a package to generate them are stale too.
+	existing, err := filepath.Glob(filepath.Join(dir, "*", generatedFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range existing {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := files[abs]; !ok {
+			t.Errorf("%v isn't generated anymore, delete it", path)
+		}
+	}
+}
@@ -0,0 +1,536 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+/***
+
+Construct/Serialize generation: add a `//ecs:serialize` directive to the doc
+comment of a struct type, and remove any hand-written Construct/Serialize
+methods. Every exported field is then serialized under its own name, based on
+its type:
+
+- Basic types (bool, numbers, strings) and slices of them.
+- Enums generated by enumer (serialized by name). Bitmasks need
+  `serialize:"flags"`.
+- ecs.Entity and ecs.EntityTable.
+- Types with Deserialize(string)/Serialize() string, like concepts.Vector3.
+- Types with Construct(any)/Serialize() any, like dynamic.DynamicValue.
+- Structs (or pointers/slices of pointers to structs) with
+  Construct(map[string]any)/Serialize() map[string]any.
+- Embedded structs with Construct/Serialize (like ecs.Attached) share the
+  same data map.
+
+Field tags:
+
+- `default:"<Go expression>"` sets the value used when the data doesn't have
+  the field. Fields equal to their default aren't saved.
+- `serialize:"-"` skips the field.
+- `serialize:"always"` saves the field even if it's equal to its default.
+- `serialize:"flags"` treats an enum as a bitmask.
+
+For anything else, the type can declare hooks, which are called at the end of
+the generated methods:
+
+	func (t *T) construct(data map[string]any) // data may be nil
+	func (t *T) serialize(result map[string]any)
+
+***/
+
+const serializeDirective = "//ecs:serialize"
+
+const (
+	castPath = "github.com/spf13/cast"
+	ecsPath  = "tlyakhov/gofoom/ecs"
+)
+
+// serializationGenerator builds Construct/Serialize methods for the types in
+// a single package.
+type serializationGenerator struct {
+	pkg     *packages.Package
+	imports map[string]string
+	// Types with an //ecs:serialize directive, and the files they're in.
+	serializable map[string]*ast.File
+}
+
+// serializableTypes finds the structs with an //ecs:serialize directive, with
+// the file they're declared in.
+func serializableTypes(pkg *packages.Package) map[string]*ast.File {
+	result := make(map[string]*ast.File)
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				if hasDirective(gen.Doc) || hasDirective(typeSpec.Doc) {
+					result[typeSpec.Name.Name] = file
+				}
+			}
+		}
+	}
+	return result
+}
+
+func hasDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == serializeDirective {
+			return true
+		}
+	}
+	return false
+}
+
+// ref returns a reference to an exported name in another package, adding an
+// import if needed.
+func (g *serializationGenerator) ref(pkgPath, name string) string {
+	if pkgPath == g.pkg.PkgPath {
+		return name
+	}
+	pkgName := pkgPath[strings.LastIndex(pkgPath, "/")+1:]
+	g.imports[pkgPath] = pkgName
+	return pkgName + "." + name
+}
+
+func (g *serializationGenerator) qualifier(p *types.Package) string {
+	if p.Path() == g.pkg.PkgPath {
+		return ""
+	}
+	g.imports[p.Path()] = p.Name()
+	return p.Name()
+}
+
+func (g *serializationGenerator) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+// importExpression adds imports for any packages referenced by a Go
+// expression from a tag, using the imports of the file it came from.
+func (g *serializationGenerator) importExpression(expr string, file *ast.File) error {
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		return err
+	}
+	ast.Inspect(parsed, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		ident, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		for _, imp := range file.Imports {
+			path, _ := strconv.Unquote(imp.Path.Value)
+			name := path[strings.LastIndex(path, "/")+1:]
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			if name == ident.Name {
+				g.imports[path] = name
+			}
+		}
+		return true
+	})
+	return nil
+}
+
+// method finds a method of *t (including promoted ones).
+func (g *serializationGenerator) method(t types.Type, name string) *types.Signature {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), true, g.pkg.Types, name)
+	if f, ok := obj.(*types.Func); ok {
+		return f.Type().(*types.Signature)
+	}
+	return nil
+}
+
+// handWritten checks whether a type declares a method itself, outside of
+// generated code.
+func (g *serializationGenerator) handWritten(named *types.Named, name string) bool {
+	for i := range named.NumMethods() {
+		f := named.Method(i)
+		if f.Name() == name && filepath.Base(g.pkg.Fset.Position(f.Pos()).Filename) != generatedFileName {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyInterface(t types.Type) bool {
+	i, ok := t.Underlying().(*types.Interface)
+	return ok && i.Empty()
+}
+
+func isDataMap(t types.Type) bool {
+	m, ok := t.Underlying().(*types.Map)
+	if !ok {
+		return false
+	}
+	key, ok := m.Key().(*types.Basic)
+	return ok && key.Kind() == types.String && isEmptyInterface(m.Elem())
+}
+
+func isString(t types.Type) bool {
+	b, ok := t.(*types.Basic)
+	return ok && b.Kind() == types.String
+}
+
+// signatureIs checks that a method has the given parameter and result types.
+func signatureIs(sig *types.Signature, param, result func(types.Type) bool) bool {
+	if sig == nil {
+		return false
+	}
+	if param == nil && sig.Params().Len() != 0 || param != nil && (sig.Params().Len() != 1 || !param(sig.Params().At(0).Type())) {
+		return false
+	}
+	if result == nil && sig.Results().Len() != 0 || result != nil && (sig.Results().Len() != 1 || !result(sig.Results().At(0).Type())) {
+		return false
+	}
+	return true
+}
+
+// hasDataMethods checks for Construct(map[string]any)/Serialize() map[string]any
+// (or will have them once generated).
+func (g *serializationGenerator) hasDataMethods(t types.Type) bool {
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() == g.pkg.Types {
+		if _, ok := g.serializable[named.Obj().Name()]; ok {
+			return true
+		}
+	}
+	return signatureIs(g.method(t, "Construct"), isDataMap, nil) &&
+		signatureIs(g.method(t, "Serialize"), nil, isDataMap)
+}
+
+// hasAnyMethods checks for Construct(any)/Serialize() any
+func (g *serializationGenerator) hasAnyMethods(t types.Type) bool {
+	return signatureIs(g.method(t, "Construct"), isEmptyInterface, nil) &&
+		signatureIs(g.method(t, "Serialize"), nil, isEmptyInterface)
+}
+
+// hasStringMethods checks for Deserialize(string)/Serialize() string
+func (g *serializationGenerator) hasStringMethods(t types.Type) bool {
+	return signatureIs(g.method(t, "Deserialize"), isString, nil) &&
+		signatureIs(g.method(t, "Serialize"), nil, isString)
+}
+
+func isNamed(t types.Type, pkgPath, name string) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == pkgPath && named.Obj().Name() == name
+}
+
+// isEnum checks for an integer type with an enumer <Type>String function.
+func isEnum(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	if b, ok := named.Underlying().(*types.Basic); !ok || b.Info()&types.IsInteger == 0 {
+		return false
+	}
+	_, ok = named.Obj().Pkg().Scope().Lookup(named.Obj().Name() + "String").(*types.Func)
+	return ok
+}
+
+// castFunction returns the cast function for a basic type, e.g. ToFloat64.
+func castFunction(t types.Type) string {
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok || b.Info()&(types.IsBoolean|types.IsNumeric|types.IsString) == 0 || b.Info()&types.IsComplex != 0 {
+		return ""
+	}
+	name := b.Name()
+	if name == "byte" {
+		name = "uint8"
+	} else if name == "rune" {
+		name = "int32"
+	}
+	return "To" + strings.ToUpper(name[:1]) + name[1:]
+}
+
+func castSliceFunction(t types.Type) string {
+	s, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return ""
+	}
+	b, ok := s.Elem().(*types.Basic)
+	if !ok {
+		return ""
+	}
+	switch b.Kind() {
+	case types.Bool:
+		return "ToBoolSlice"
+	case types.Int:
+		return "ToIntSlice"
+	case types.Float64:
+		return "ToFloat64Slice"
+	case types.String:
+		return "ToStringSlice"
+	}
+	return ""
+}
+
+// zeroValue returns a Go expression for the zero value of a type.
+func (g *serializationGenerator) zeroValue(t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		}
+		return "0"
+	case *types.Array, *types.Struct:
+		return "(" + g.typeString(t) + "{})"
+	}
+	return "nil"
+}
+
+// fieldCode is the generated code for a single field.
+type fieldCode struct {
+	// Reset the field to its default
+	reset string
+	// Read the field from `v`
+	read string
+	// Write the field to `result`
+	write string
+}
+
+func (g *serializationGenerator) field(recv string, f *types.Var, tag reflect.StructTag, file *ast.File) (*fieldCode, error) {
+	name := f.Name()
+	t := f.Type()
+	access := recv + "." + name
+	options := strings.Split(tag.Get("serialize"), ",")
+	always := slices.Contains(options, "always")
+	tagDefault, hasDefault := tag.Lookup("default")
+	if hasDefault {
+		if err := g.importExpression(tagDefault, file); err != nil {
+			return nil, fmt.Errorf("bad default for %v: %w", name, err)
+		}
+		if strings.Contains(tagDefault, "{") {
+			tagDefault = "(" + tagDefault + ")"
+		}
+	}
+	// This is lazy, since the zero value can need imports that aren't
+	// otherwise used.
+	def := func() string {
+		if hasDefault {
+			return tagDefault
+		}
+		return g.zeroValue(t)
+	}
+	code := &fieldCode{}
+	// Writes the field if it's not equal to its default.
+	omitDefault := func(value string) string {
+		write := fmt.Sprintf("result[%q] = %v", name, value)
+		if always || !types.Comparable(t) {
+			return write
+		}
+		def := def()
+		condition := fmt.Sprintf("%v != %v", access, def)
+		if b, ok := t.Underlying().(*types.Basic); ok && b.Info()&types.IsBoolean != 0 {
+			if def == "true" {
+				condition = "!" + access
+			} else if def == "false" {
+				condition = access
+			}
+		}
+		return fmt.Sprintf("if %v {\n%v\n}", condition, write)
+	}
+	omitEmpty := func(value string) string {
+		write := fmt.Sprintf("result[%q] = %v", name, value)
+		if always {
+			return write
+		}
+		return fmt.Sprintf("if len(%v) != 0 {\n%v\n}", access, write)
+	}
+	cast := func(fn string) string { return g.ref(castPath, fn) }
+
+	switch {
+	case isNamed(t, ecsPath, "Entity"):
+		code.read = fmt.Sprintf("%v, _ = %v(%v(v))", access, g.ref(ecsPath, "ParseEntity"), cast("ToString"))
+		code.write = omitDefault(access + ".Serialize()")
+	case isNamed(t, ecsPath, "EntityTable"):
+		code.read = fmt.Sprintf("%v = %v(v, true)", access, g.ref(ecsPath, "ParseEntityTable"))
+		code.write = omitEmpty(access + ".Serialize()")
+	case isEnum(t):
+		obj := t.(*types.Named).Obj()
+		parse := g.ref(obj.Pkg().Path(), obj.Name()+"String")
+		if slices.Contains(options, "flags") {
+			code.read = fmt.Sprintf("%v = %v(%v(v), %v)", access, g.ref("tlyakhov/gofoom/concepts", "ParseFlags"), cast("ToString"), parse)
+			code.write = omitDefault(fmt.Sprintf("%v(%v, %v())", g.ref("tlyakhov/gofoom/concepts", "SerializeFlags"), access, g.ref(obj.Pkg().Path(), obj.Name()+"Values")))
+		} else {
+			code.read = fmt.Sprintf("if parsed, err := %v(%v(v)); err == nil {\n%v = parsed\n}", parse, cast("ToString"), access)
+			code.write = omitDefault(access + ".String()")
+		}
+	case castFunction(t) != "":
+		value := fmt.Sprintf("%v(v)", cast(castFunction(t)))
+		if _, ok := t.(*types.Named); ok {
+			value = fmt.Sprintf("%v(%v)", g.typeString(t), value)
+		}
+		code.read = fmt.Sprintf("%v = %v", access, value)
+		code.write = omitDefault(access)
+	case castSliceFunction(t) != "":
+		code.read = fmt.Sprintf("%v = %v(v)", access, cast(castSliceFunction(t)))
+		code.write = omitEmpty(access)
+	case g.hasStringMethods(t):
+		code.read = fmt.Sprintf("%v.Deserialize(%v(v))", access, cast("ToString"))
+		code.write = omitDefault(access + ".Serialize()")
+	case g.hasAnyMethods(t):
+		if hasDefault {
+			return nil, fmt.Errorf("field %v can't have a default, use a construct hook", name)
+		}
+		code.reset = access + ".Construct(nil)"
+		code.read = access + ".Construct(v)"
+		code.write = fmt.Sprintf("result[%q] = %v.Serialize()", name, access)
+	case g.hasDataMethods(t):
+		if hasDefault {
+			return nil, fmt.Errorf("field %v can't have a default, use a construct hook", name)
+		}
+		code.reset = access + ".Construct(nil)"
+		code.read = fmt.Sprintf("if m, ok := v.(map[string]any); ok {\n%v.Construct(m)\n}", access)
+		code.write = fmt.Sprintf("result[%q] = %v.Serialize()", name, access)
+	default:
+		if ptr, ok := t.(*types.Pointer); ok && g.hasDataMethods(ptr.Elem()) {
+			code.reset = access + " = nil"
+			code.read = fmt.Sprintf("if m, ok := v.(map[string]any); ok {\n%v = new(%v)\n%v.Construct(m)\n}", access, g.typeString(ptr.Elem()), access)
+			code.write = fmt.Sprintf("if %v != nil {\nresult[%q] = %v.Serialize()\n}", access, name, access)
+			break
+		}
+		if s, ok := t.Underlying().(*types.Slice); ok {
+			if ptr, ok := s.Elem().(*types.Pointer); ok && g.hasDataMethods(ptr.Elem()) {
+				code.reset = access + " = nil"
+				code.read = fmt.Sprintf("%v = %v[%v](v, nil)", access, g.ref(ecsPath, "ConstructSlice"), g.typeString(ptr))
+				code.write = omitEmpty(fmt.Sprintf("%v(%v)", g.ref(ecsPath, "SerializeSlice"), access))
+				break
+			}
+		}
+		return nil, fmt.Errorf("field %v has unsupported type %v, tag it with serialize:\"-\" and use hooks", name, t)
+	}
+	if code.reset == "" {
+		code.reset = fmt.Sprintf("%v = %v", access, def())
+	}
+	return code, nil
+}
+
+// generate builds the Construct/Serialize methods for a type.
+func (g *serializationGenerator) generate(name string, file *ast.File) (string, error) {
+	obj, ok := g.pkg.Types.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return "", fmt.Errorf("%v: type not found", name)
+	}
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return "", fmt.Errorf("%v: not a struct", name)
+	}
+	for _, method := range []string{"Construct", "Serialize"} {
+		if g.handWritten(obj.Type().(*types.Named), method) {
+			return "", fmt.Errorf("%v: remove the hand-written %v, or the %v directive", name, method, serializeDirective)
+		}
+	}
+	recv := strings.ToLower(name[:1])
+	if recv == "v" || recv == "m" || recv == "g" {
+		// These would shadow locals in the generated code.
+		recv = strings.ToLower(name[:2])
+	}
+
+	var construct, read, serialize strings.Builder
+	hasResult := false
+	for i := range st.NumFields() {
+		f := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i))
+		if tag.Get("serialize") == "-" {
+			continue
+		}
+		if f.Embedded() {
+			// Embedded pointers are usually back-references, e.g. SectorPlane's
+			// *Sector, so only values are serialized.
+			if _, ok := f.Type().(*types.Pointer); ok || !g.hasDataMethods(f.Type()) {
+				continue
+			}
+			fmt.Fprintf(&construct, "%v.%v.Construct(data)\n", recv, f.Name())
+			if !hasResult {
+				fmt.Fprintf(&serialize, "result := %v.%v.Serialize()\n", recv, f.Name())
+				hasResult = true
+			} else {
+				fmt.Fprintf(&serialize, "%v(result, %v.%v.Serialize())\n", g.ref("maps", "Copy"), recv, f.Name())
+			}
+			continue
+		}
+		if !f.Exported() {
+			continue
+		}
+		code, err := g.field(recv, f, tag, file)
+		if err != nil {
+			return "", fmt.Errorf("%v: %w", name, err)
+		}
+		fmt.Fprintf(&construct, "%v\n", code.reset)
+		fmt.Fprintf(&read, "if v, ok := data[%q]; ok {\n%v\n}\n", f.Name(), code.read)
+		fmt.Fprintf(&serialize, "%v\n", code.write)
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "\nfunc (%v *%v) Construct(data map[string]any) {\n", recv, name)
+	result.WriteString(construct.String())
+	if read.Len() > 0 {
+		result.WriteString("if data != nil {\n")
+		result.WriteString(read.String())
+		result.WriteString("}\n")
+	}
+	if signatureIs(g.method(obj.Type(), "construct"), isDataMap, nil) {
+		fmt.Fprintf(&result, "%v.construct(data)\n", recv)
+	}
+	result.WriteString("}\n")
+
+	fmt.Fprintf(&result, "\nfunc (%v *%v) Serialize() map[string]any {\n", recv, name)
+	if !hasResult {
+		result.WriteString("result := make(map[string]any)\n")
+	}
+	result.WriteString(serialize.String())
+	if signatureIs(g.method(obj.Type(), "serialize"), isDataMap, nil) {
+		fmt.Fprintf(&result, "%v.serialize(result)\n", recv)
+	}
+	result.WriteString("return result\n}\n")
+	return result.String(), nil
+}
+
+// generateSerialization returns the generated methods for every type with an
+// //ecs:serialize directive in the package, sorted by name.
+func generateSerialization(pkg *packages.Package, imports map[string]string) (string, error) {
+	g := &serializationGenerator{pkg: pkg, imports: imports, serializable: serializableTypes(pkg)}
+	names := make([]string, 0, len(g.serializable))
+	for name := range g.serializable {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var result strings.Builder
+	for _, name := range names {
+		code, err := g.generate(name, g.serializable[name])
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(code)
+	}
+	return result.String(), nil
+}
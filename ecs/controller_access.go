@@ -0,0 +1,180 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ControllerAccess declares which components a controller touches during
+// ControllerFrame, other than the one it's registered for (which is always
+// considered written). Controllers that declare their access can run
+// concurrently with other controllers of the same priority, as long as neither
+// writes a component that the other one reads or writes.
+//
+// Controllers that create or delete entities, run scripts, or use shared state
+// like ecs.Simulation.Rand shouldn't declare access, so that they run
+// serially.
+//
+// So far only a few controllers declare their access (e.g. Body, whose Frame
+// does nothing, InventoryCarrier and Underwater), so in practice most groups
+// still run serially. Frames only get faster as more controllers declare it.
+type ControllerAccess struct {
+	Reads  []ComponentID
+	Writes []ComponentID
+}
+
+var (
+	// ParallelControllers enables concurrent scheduling of controllers that
+	// declare their access.
+	ParallelControllers = true
+	// DebugControllerAccess runs all controllers serially, and logs any
+	// access to components that a controller didn't declare.
+	DebugControllerAccess = false
+
+	debugAccessGroup   *controllerGroup
+	undeclaredAccesses = make(map[undeclaredAccess]struct{})
+	undeclaredLock     sync.Mutex
+)
+
+type undeclaredAccess struct {
+	group *controllerGroup
+	id    ComponentID
+}
+
+// scheduledGroup is a controller group with instances ready to run.
+type scheduledGroup struct {
+	*controllerGroup
+	active []groupedController
+}
+
+func (group *controllerGroup) String() string {
+	names := make([]string, len(group.Controllers))
+	for i, meta := range group.Controllers {
+		names[i] = meta.Type.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// addAccess merges a controller's declared access into the group. A single
+// undeclared controller makes the whole group serial.
+func (group *controllerGroup) addAccess(access *ControllerAccess) {
+	if access == nil {
+		group.Declared = false
+		return
+	}
+	for _, id := range access.Reads {
+		if !slices.Contains(group.Reads, id) {
+			group.Reads = append(group.Reads, id)
+		}
+	}
+	for _, id := range access.Writes {
+		if !slices.Contains(group.Writes, id) {
+			group.Writes = append(group.Writes, id)
+		}
+	}
+}
+
+func (group *controllerGroup) accessible(id ComponentID) bool {
+	return slices.Contains(group.Writes, id) || slices.Contains(group.Reads, id)
+}
+
+// conflicts checks whether two groups can't run at the same time.
+func (group *controllerGroup) conflicts(other *controllerGroup) bool {
+	if !group.Declared || !other.Declared {
+		return true
+	}
+	for _, id := range group.Writes {
+		if other.accessible(id) {
+			return true
+		}
+	}
+	for _, id := range other.Writes {
+		if slices.Contains(group.Reads, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// canJoin checks whether a group can run concurrently with a batch.
+func canJoin(batch []scheduledGroup, group *controllerGroup) bool {
+	if len(batch) == 0 || batch[0].Priority != group.Priority {
+		return false
+	}
+	for _, sg := range batch {
+		if sg.conflicts(group) {
+			return false
+		}
+	}
+	return true
+}
+
+// runBatch walks the arenas of a batch of non-conflicting groups, each one
+// in its own goroutine.
+func runBatch(batch []scheduledGroup, method ControllerMethod) {
+	if len(batch) == 1 {
+		actArena(batch[0], method)
+		return
+	}
+	var wg sync.WaitGroup
+	for _, sg := range batch {
+		wg.Go(func() { actArena(sg, method) })
+	}
+	wg.Wait()
+}
+
+// beginControllerAccess starts checking component access for a group, if
+// DebugControllerAccess is enabled. It returns the previous group, which
+// should be passed to endControllerAccess.
+func beginControllerAccess(group *controllerGroup, method ControllerMethod) *controllerGroup {
+	if !DebugControllerAccess {
+		return nil
+	}
+	prev := debugAccessGroup
+	if method == ControllerFrame {
+		debugAccessGroup = group
+	}
+	return prev
+}
+
+func endControllerAccess(prev *controllerGroup) {
+	if DebugControllerAccess {
+		debugAccessGroup = prev
+	}
+}
+
+// checkControllerAccess logs the first access by a controller group to each
+// component that the group didn't declare.
+func checkControllerAccess(id ComponentID) {
+	group := debugAccessGroup
+	if group == nil || !group.Declared || group.accessible(id) {
+		return
+	}
+	undeclaredLock.Lock()
+	defer undeclaredLock.Unlock()
+	key := undeclaredAccess{group: group, id: id}
+	if _, ok := undeclaredAccesses[key]; ok {
+		return
+	}
+	undeclaredAccesses[key] = struct{}{}
+	log.Printf("ecs.checkControllerAccess: %v accessed undeclared component %v", group, Types().ArenaPlaceholders[id])
+}
+
+// UndeclaredControllerAccesses returns a description of each undeclared
+// component access found with DebugControllerAccess enabled.
+func UndeclaredControllerAccesses() []string {
+	undeclaredLock.Lock()
+	defer undeclaredLock.Unlock()
+	result := make([]string, 0, len(undeclaredAccesses))
+	for key := range undeclaredAccesses {
+		result = append(result, fmt.Sprintf("%v: %v", key.group, Types().ArenaPlaceholders[key.id]))
+	}
+	slices.Sort(result)
+	return result
+}
@@ -0,0 +1,180 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+// These tests are most useful with the race detector:
+// go test -race ./ecs -run Controller
+
+type mockCounterA struct {
+	Attached
+	Count int
+}
+
+type mockCounterB struct {
+	Attached
+	Count int
+}
+
+var mockCounterACID, mockCounterBCID ComponentID
+
+func (*mockCounterA) ComponentID() ComponentID {
+	return mockCounterACID
+}
+
+func (*mockCounterB) ComponentID() ComponentID {
+	return mockCounterBCID
+}
+
+// counterController increments a counter, reading mockComponent. If
+// sneakyRead is set, it also reads a component it didn't declare.
+type counterController struct {
+	BaseController
+	cid       ComponentID
+	count     *int
+	sneakyCID ComponentID
+}
+
+var sneakyRead bool
+
+var counterAccess = ControllerAccess{}
+
+// registerMockCounters is called after mockCID is registered, see
+// ecs_test.go.
+func registerMockCounters() {
+	mockCounterACID = RegisterComponent(&Arena[mockCounterA, *mockCounterA]{})
+	mockCounterBCID = RegisterComponent(&Arena[mockCounterB, *mockCounterB]{})
+	counterAccess.Reads = []ComponentID{mockCID}
+	Types().RegisterController(func() Controller {
+		return &counterController{cid: mockCounterACID}
+	}, 90)
+	Types().RegisterController(func() Controller {
+		return &counterController{cid: mockCounterBCID, sneakyCID: mockCounterACID}
+	}, 90)
+}
+
+func (cc *counterController) ComponentID() ComponentID {
+	return cc.cid
+}
+
+func (cc *counterController) Methods() ControllerMethod {
+	return ControllerFrame
+}
+
+func (cc *counterController) Access() *ControllerAccess {
+	return &counterAccess
+}
+
+func (cc *counterController) Target(target Component, e Entity) bool {
+	cc.Entity = e
+	switch c := target.(type) {
+	case *mockCounterA:
+		cc.count = &c.Count
+	case *mockCounterB:
+		cc.count = &c.Count
+	}
+	return target.IsActive()
+}
+
+func (cc *counterController) Frame() {
+	if GetComponent(cc.Entity, mockCID) != nil {
+		*cc.count++
+	}
+	if sneakyRead && cc.sneakyCID != 0 {
+		GetComponent(cc.Entity, cc.sneakyCID)
+	}
+}
+
+func findControllerGroup(t *testing.T, cid ComponentID) *controllerGroup {
+	for i := range Types().ControllerGroups {
+		if Types().ControllerGroups[i].ComponentID == cid {
+			return &Types().ControllerGroups[i]
+		}
+	}
+	t.Fatalf("No controller group for component %v", cid)
+	return nil
+}
+
+func createMockCounters(n int) []Entity {
+	entities := make([]Entity, n)
+	for i := range entities {
+		entities[i] = NewEntity()
+		NewAttachedComponent(entities[i], mockCounterACID)
+		NewAttachedComponent(entities[i], mockCounterBCID)
+		NewAttachedComponent(entities[i], mockCID)
+	}
+	return entities
+}
+
+func deleteEntities(entities []Entity) {
+	for _, e := range entities {
+		Delete(e)
+	}
+}
+
+func TestControllerConflicts(t *testing.T) {
+	a := findControllerGroup(t, mockCounterACID)
+	b := findControllerGroup(t, mockCounterBCID)
+	if !a.Declared || !b.Declared {
+		t.Fatalf("Expected counter groups to be declared")
+	}
+	if a.conflicts(b) || b.conflicts(a) {
+		t.Errorf("Counter groups shouldn't conflict")
+	}
+	if mock := findControllerGroup(t, mockCID); mock.Declared || !mock.conflicts(a) {
+		t.Errorf("Undeclared groups should always conflict")
+	}
+	writer := &controllerGroup{Declared: true, Writes: []ComponentID{mockCID}}
+	if !writer.conflicts(a) || !a.conflicts(writer) {
+		t.Errorf("A group writing %v should conflict with groups reading it", mockCID)
+	}
+	batch := []scheduledGroup{{controllerGroup: a}}
+	if !canJoin(batch, b) {
+		t.Errorf("Counter groups should be scheduled together")
+	}
+	if canJoin(batch, &controllerGroup{Declared: true, Priority: 100}) {
+		t.Errorf("Groups with different priorities shouldn't be scheduled together")
+	}
+}
+
+func TestParallelControllers(t *testing.T) {
+	Initialize()
+	entities := createMockCounters(1000)
+	defer deleteEntities(entities)
+
+	for range 10 {
+		ActAllControllers(ControllerFrame)
+	}
+	for _, e := range entities {
+		a := GetComponent(e, mockCounterACID).(*mockCounterA)
+		b := GetComponent(e, mockCounterBCID).(*mockCounterB)
+		if a.Count != 10 || b.Count != 10 {
+			t.Fatalf("Expected counts of 10, got %v and %v", a.Count, b.Count)
+		}
+	}
+}
+
+func TestDebugControllerAccess(t *testing.T) {
+	Initialize()
+	entities := createMockCounters(10)
+	defer deleteEntities(entities)
+
+	DebugControllerAccess = true
+	sneakyRead = true
+	defer func() {
+		DebugControllerAccess = false
+		sneakyRead = false
+	}()
+	ActAllControllers(ControllerFrame)
+
+	b := findControllerGroup(t, mockCounterBCID)
+	expected := []string{b.String() + ": " + Types().ArenaPlaceholders[mockCounterACID].String()}
+	if actual := UndeclaredControllerAccesses(); !slices.Equal(actual, expected) {
+		t.Errorf("Expected undeclared accesses %v, got %v", expected, actual)
+	}
+}
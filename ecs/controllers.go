@@ -10,8 +10,62 @@ import (
 
 // TODO: We originally had way more different types of controllers. Now there
 // are only 2 methods. Can we make this simpler?
-// TODO: Multiple controllers acting on the same ID should only iterate over the
-// arena once.
+
+// controllerGroup is a set of controllers that act on the same component ID
+// with the same priority. They're run together, so that each arena only needs
+// to be walked once per method.
+type controllerGroup struct {
+	ComponentID ComponentID
+	Priority    int
+	Controllers []controllerMetadata
+	// Declared is true if every controller in the group declared its access,
+	// see ControllerAccess. Reads and Writes are the union of the declared
+	// access, and Writes always includes ComponentID.
+	Declared bool
+	Reads    []ComponentID
+	Writes   []ComponentID
+	// Controller instances for each method, created once and reused by the
+	// outermost ActAllControllers (see actAllDepth). bound has every
+	// controller in the group, instances the ones that run this frame.
+	// Indexed by methodIndex.
+	bound     [2][]groupedController
+	instances [2][]groupedController
+}
+
+// methodIndex maps a single ControllerMethod to an index into
+// controllerGroup.instances.
+func methodIndex(method ControllerMethod) int {
+	if method == ControllerPrecompute {
+		return 1
+	}
+	return 0
+}
+
+var (
+	// actAllDepth is how many calls to ActAllControllers are running. The
+	// cached instances and batch are only reused by the outermost one, since
+	// controllers can call ActAllControllers themselves.
+	actAllDepth int
+	actAllBatch []scheduledGroup
+)
+
+// groupedController is a controller instance that's been checked for the
+// current method, see controllerGroup.instantiate.
+type groupedController struct {
+	Controller
+	f func()
+}
+
+func init() {
+	// Controllers can hold on to components between frames (e.g. generated
+	// ones), so the instances don't outlive the world.
+	OnInitialize(func() {
+		for i := range Types().ControllerGroups {
+			group := &Types().ControllerGroups[i]
+			group.bound = [2][]groupedController{}
+		}
+	})
+}
 
 func (types *typeMetadata) RegisterController(constructor func() Controller, priority int) {
 	types.lock.Lock()
@@ -21,33 +75,137 @@ func (types *typeMetadata) RegisterController(constructor func() Controller, pri
 	types.Controllers = append(types.Controllers, controllerMetadata{
 		Constructor: constructor,
 		Type:        instanceType,
-		Priority:    priority})
+		Priority:    priority,
+		ComponentID: instance.ComponentID(),
+		Access:      instance.Access()})
 	sort.Slice(types.Controllers, func(i, j int) bool {
 		return types.Controllers[i].Priority < types.Controllers[j].Priority
 	})
+	types.groupControllers()
 }
 
-// act calls a specific controller method on a component.
-// It checks if the controller's target conditions are met for the component and
-// its associated entities.
-func act(controller Controller, component Component, f func()) {
-	if component.Base().Attachments == 1 {
-		// If the component is attached to only one entity, check the target condition for that entity.
-		if controller.Target(component, component.Base().Entity) {
-			f()
+// groupControllers rebuilds the controller groups. The order of groups follows
+// the sorted controllers: the order between different priorities is
+// preserved, and within the same priority, controllers for the same component
+// ID are merged into the first group for that ID.
+func (types *typeMetadata) groupControllers() {
+	types.ControllerGroups = types.ControllerGroups[:0]
+	for _, meta := range types.Controllers {
+		found := false
+		for i := len(types.ControllerGroups) - 1; i >= 0; i-- {
+			group := &types.ControllerGroups[i]
+			if group.Priority != meta.Priority {
+				break
+			}
+			if group.ComponentID == meta.ComponentID {
+				group.Controllers = append(group.Controllers, meta)
+				group.addAccess(meta.Access)
+				found = true
+				break
+			}
+		}
+		if !found {
+			group := controllerGroup{
+				ComponentID: meta.ComponentID,
+				Priority:    meta.Priority,
+				Controllers: []controllerMetadata{meta},
+				Declared:    true,
+				Writes:      []ComponentID{meta.ComponentID},
+			}
+			group.addAccess(meta.Access)
+			types.ControllerGroups = append(types.ControllerGroups, group)
+		}
+	}
+}
+
+// runs checks whether a controller handles a method, and if the editor is
+// paused, whether it should still run.
+func runs(controller Controller, method ControllerMethod) bool {
+	if controller.Methods()&method == 0 {
+		return false
+	}
+	return !Simulation.EditorPaused || controller.EditorPausedMethods()&method != 0
+}
+
+func bind(controller Controller, method ControllerMethod) groupedController {
+	gc := groupedController{Controller: controller}
+	switch method {
+	case ControllerFrame:
+		gc.f = controller.Frame
+	case ControllerPrecompute:
+		gc.f = controller.Precompute
+	}
+	return gc
+}
+
+// instantiate creates new instances of the controllers in this group that
+// should run for a given method, appending them to the provided slice.
+func (group *controllerGroup) instantiate(method ControllerMethod, active []groupedController) []groupedController {
+	for _, meta := range group.Controllers {
+		// Create a new instance of the controller.
+		controller := meta.Constructor()
+		if runs(controller, method) {
+			active = append(active, bind(controller, method))
+		}
+	}
+	return active
+}
+
+// cachedInstances is like instantiate, but the instances are only created
+// the first time, and the slice is reused.
+func (group *controllerGroup) cachedInstances(method ControllerMethod) []groupedController {
+	i := methodIndex(method)
+	if group.bound[i] == nil {
+		group.bound[i] = make([]groupedController, len(group.Controllers))
+		for j, meta := range group.Controllers {
+			group.bound[i][j] = bind(meta.Constructor(), method)
+		}
+	}
+	active := group.instances[i][:0]
+	for _, gc := range group.bound[i] {
+		if runs(gc.Controller, method) {
+			active = append(active, gc)
 		}
+	}
+	group.instances[i] = active
+	return active
+}
+
+// actEntity calls the method of each controller whose target conditions are
+// met for a component and entity. An earlier controller may have detached or
+// deleted the component, in which case the rest are skipped. Indirect
+// entities aren't in the component's Entities, so only deletion is checked
+// for those.
+func actEntity(active []groupedController, component Component, e Entity, indirect bool) {
+	base := component.Base()
+	for i, gc := range active {
+		if i > 0 && (base.Attachments == 0 || (!indirect && !base.Entities.Contains(e))) {
+			return
+		}
+		if gc.Target(component, e) {
+			gc.f()
+		}
+	}
+}
+
+// act calls a specific controller method on a component for a group of
+// controllers. It checks if each controller's target conditions are met for
+// the component and its associated entities.
+func act(active []groupedController, component Component) {
+	base := component.Base()
+	if base.Attachments == 1 {
+		// If the component is attached to only one entity, check the target condition for that entity.
+		actEntity(active, component, base.Entity, false)
 		return
 	}
 
 	// If the component is attached to multiple entities, iterate through them
 	// and check the target condition for each.
-	for _, e := range component.Base().Entities {
+	for _, e := range base.Entities {
 		if e == 0 {
 			continue
 		}
-		if controller.Target(component, e) {
-			f()
-		}
+		actEntity(active, component, e, false)
 	}
 
 	// if the component is attached with indirects, iterate through them
@@ -60,69 +218,94 @@ func act(controller Controller, component Component, f func()) {
 		if e == 0 {
 			continue
 		}
-		if controller.Target(component, e) {
-			f()
-		}
+		actEntity(active, component, e, true)
 	}
 }
 
 // Act runs controllers for a specific component, based on the provided component ID and method.
 func Act(component Component, id ComponentID, method ControllerMethod) {
-	var f func()
+	var active []groupedController
+	for i := range Types().ControllerGroups {
+		group := &Types().ControllerGroups[i]
+		// Check if the group's component ID matches the provided ID.
+		if group.ComponentID != id {
+			continue
+		}
+		if active = group.instantiate(method, active[:0]); len(active) == 0 {
+			continue
+		}
+		// Call the controllers' method on the component.
+		prev := beginControllerAccess(group, method)
+		act(active, component)
+		endControllerAccess(prev)
+	}
+}
 
-	for _, meta := range Types().Controllers {
-		// Create a new instance of the controller.
-		controller := meta.Constructor()
-		// Check if the controller handles the specified method.
-		if controller.Methods()&method == 0 {
+// ActAllControllers runs all controllers for all components that have the
+// specified method. Controllers in the same group share a single pass over
+// their arena. For ControllerFrame, groups with the same priority that don't
+// conflict are run concurrently, see ControllerAccess.
+func ActAllControllers(method ControllerMethod) {
+	parallel := method == ControllerFrame && ParallelControllers && !DebugControllerAccess
+	actAllDepth++
+	defer func() { actAllDepth-- }()
+	outermost := actAllDepth == 1
+	if method == ControllerPrecompute || outermost {
+		// Either everything is about to be precomputed, or the changes since
+		// the last frame have been consumed by PrecomputeChanged if they're
+		// going to be, so they can be dropped.
+		clearChanged()
+	}
+	var batch []scheduledGroup
+	if outermost {
+		batch = actAllBatch[:0]
+		defer func() { actAllBatch = batch[:0] }()
+	}
+	for i := range Types().ControllerGroups {
+		group := &Types().ControllerGroups[i]
+		// Get the arena for the group's component type.
+		if arenas[group.ComponentID].Len() == 0 {
 			continue
 		}
-		// Check if the controller should be active based on the editor's pause state.
-		if (Simulation.EditorPaused && controller.EditorPausedMethods()&method == 0) ||
-			(!Simulation.EditorPaused && controller.Methods()&method == 0) {
+		sg := scheduledGroup{controllerGroup: group}
+		if outermost {
+			sg.active = group.cachedInstances(method)
+		} else {
+			sg.active = group.instantiate(method, nil)
+		}
+		if len(sg.active) == 0 {
 			continue
 		}
-		// Check if the controller's component ID matches the provided ID.
-		if controller.ComponentID() != id {
+		if parallel && canJoin(batch, group) {
+			batch = append(batch, sg)
 			continue
 		}
-		switch method {
-		case ControllerFrame:
-			f = controller.Frame
-		case ControllerPrecompute:
-			f = controller.Precompute
+		if len(batch) > 0 {
+			runBatch(batch, method)
+			batch = batch[:0]
 		}
-		// Call the controller's method on the component.
-		act(controller, component, f)
+		if parallel && group.Declared {
+			batch = append(batch, sg)
+		} else {
+			actArena(sg, method)
+		}
+	}
+	if len(batch) > 0 {
+		runBatch(batch, method)
 	}
 }
 
-// ActAllControllers runs all controllers for all components that have the specified method.
-func ActAllControllers(method ControllerMethod) {
-	var f func()
-	for _, meta := range Types().Controllers {
-		// Create a new instance of the controller.
-		controller := meta.Constructor()
-		// Check if the controller should be active based on the editor's pause state and if it handles the specified method.
-		if (Simulation.EditorPaused && controller.EditorPausedMethods()&method == 0) ||
-			(!Simulation.EditorPaused && controller.Methods()&method == 0) {
-			continue
-		}
-		switch method {
-		case ControllerFrame:
-			f = controller.Frame
-		case ControllerPrecompute:
-			f = controller.Precompute
-		}
-		// Get the arena for the controller's component type.
-		arena := arenas[controller.ComponentID()]
-		// Iterate through the components in the arena and call the controller's method on each active component.
-		for i := range arena.Cap() {
-			if component := arena.Component(i); component != nil {
-				act(controller, component, f)
-			}
+// actArena iterates through the components in a group's arena and calls the
+// controllers' method on each active component.
+func actArena(sg scheduledGroup, method ControllerMethod) {
+	prev := beginControllerAccess(sg.controllerGroup, method)
+	arena := arenas[sg.ComponentID]
+	for i := range arena.Cap() {
+		if component := arena.Component(i); component != nil {
+			act(sg.active, component)
 		}
 	}
+	endControllerAccess(prev)
 }
 
 // ActAllControllersOneEntity runs all controllers for a specific entity that have the specified method.
@@ -132,29 +315,19 @@ func ActAllControllersOneEntity(entity Entity, method ControllerMethod) {
 		return
 	}
 
-	for _, meta := range Types().Controllers {
-		// Create a new instance of the controller.
-		controller := meta.Constructor()
-		// Check if the controller should be active based on the editor's pause state and if it handles the specified method.
-		if (Simulation.EditorPaused && controller.EditorPausedMethods()&method == 0) ||
-			(!Simulation.EditorPaused && controller.Methods()&method == 0) {
+	var active []groupedController
+	for i := range Types().ControllerGroups {
+		group := &Types().ControllerGroups[i]
+		component := rows[sid][local].Get(group.ComponentID)
+		if component == nil {
 			continue
 		}
-		var f func()
-		switch method {
-		case ControllerFrame:
-			f = controller.Frame
-		case ControllerPrecompute:
-			f = controller.Precompute
-		}
-		// Iterate through the components attached to the entity.
-		for _, component := range rows[sid][local] {
-			if component == nil ||
-				component.ComponentID() != controller.ComponentID() {
-				continue
-			}
-			// Call the controller's method on the component.
-			act(controller, component, f)
+		if active = group.instantiate(method, active[:0]); len(active) == 0 {
+			continue
 		}
+		// Call the controllers' method on the component.
+		prev := beginControllerAccess(group, method)
+		act(active, component)
+		endControllerAccess(prev)
 	}
 }
@@ -0,0 +1,174 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+type mockController struct {
+	BaseController
+	index int
+}
+
+type mockCall struct {
+	Entity
+	index int
+}
+
+// mockCalls and mockPrecomputed record the controller calls when they're not
+// nil.
+var mockCalls []mockCall
+var mockPrecomputed []Entity
+
+// These change what the mock controllers do, see TestControllerGroups.
+var mockMethods = ControllerFrame | ControllerPrecompute
+var mockPausedMethods ControllerMethod
+var mockDetach Entity
+
+// registerMockControllers is called after mockCID is registered, see
+// ecs_test.go.
+func registerMockControllers() {
+	for index := range 3 {
+		Types().RegisterController(func() Controller { return &mockController{index: index} }, 100)
+	}
+}
+
+func (mc *mockController) ComponentID() ComponentID {
+	return mockCID
+}
+
+func (mc *mockController) Methods() ControllerMethod {
+	return mockMethods
+}
+
+func (mc *mockController) EditorPausedMethods() ControllerMethod {
+	return mockPausedMethods
+}
+
+func (mc *mockController) Target(target Component, e Entity) bool {
+	mc.Entity = e
+	return target.IsActive()
+}
+
+func (mc *mockController) Frame() {
+	if mockCalls != nil {
+		mockCalls = append(mockCalls, mockCall{mc.Entity, mc.index})
+	}
+	if mc.index == 0 && mc.Entity == mockDetach {
+		DetachComponent(mockCID, mc.Entity)
+	}
+}
+
+func (mc *mockController) Precompute() {
+	if mockPrecomputed != nil && mc.index == 0 {
+		mockPrecomputed = append(mockPrecomputed, mc.Entity)
+	}
+}
+
+func TestControllerGroups(t *testing.T) {
+	Initialize()
+	var group *controllerGroup
+	for i := range Types().ControllerGroups {
+		if Types().ControllerGroups[i].ComponentID == mockCID {
+			if group != nil {
+				t.Fatalf("Found more than one group for mock controllers")
+			}
+			group = &Types().ControllerGroups[i]
+		}
+	}
+	if group == nil || len(group.Controllers) != 3 {
+		t.Fatalf("Expected one group with 3 mock controllers, got %v", group)
+	}
+
+	e1, e2 := NewEntity(), NewEntity()
+	NewAttachedComponent(e1, mockCID)
+	NewAttachedComponent(e2, mockCID)
+	defer Delete(e1)
+	defer Delete(e2)
+	mockCalls = make([]mockCall, 0)
+	defer func() { mockCalls = nil }()
+	ActAllControllers(ControllerFrame)
+	// Each component should be visited once, by every controller in order.
+	expected := []mockCall{{e1, 0}, {e1, 1}, {e1, 2}, {e2, 0}, {e2, 1}, {e2, 2}}
+	if !slices.Equal(mockCalls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, mockCalls)
+	}
+
+	// The group's instances are reused by the next call.
+	instances := slices.Clone(group.instances[methodIndex(ControllerFrame)])
+	mockCalls = mockCalls[:0]
+	ActAllControllers(ControllerFrame)
+	reused := group.instances[methodIndex(ControllerFrame)]
+	if len(reused) != 3 {
+		t.Fatalf("Expected 3 instances, got %v", len(reused))
+	}
+	for i := range reused {
+		if reused[i].Controller != instances[i].Controller {
+			t.Errorf("Expected controller instance %v to be reused", i)
+		}
+	}
+	if !slices.Equal(mockCalls, expected) {
+		t.Errorf("Expected calls %v the second time, got %v", expected, mockCalls)
+	}
+
+	// Controllers that don't handle a method don't run it, even if they'd
+	// run it while paused.
+	mockMethods = ControllerPrecompute
+	mockPausedMethods = ControllerFrame
+	Simulation.EditorPaused = true
+	mockCalls = mockCalls[:0]
+	ActAllControllers(ControllerFrame)
+	mockMethods = ControllerFrame | ControllerPrecompute
+	mockPausedMethods = 0
+	Simulation.EditorPaused = false
+	if len(mockCalls) != 0 {
+		t.Errorf("Expected no calls while paused, got %v", mockCalls)
+	}
+
+	// Once a controller detaches the component, the rest of the group skips
+	// it.
+	mockDetach = e1
+	defer func() { mockDetach = 0 }()
+	mockCalls = mockCalls[:0]
+	ActAllControllers(ControllerFrame)
+	expected = []mockCall{{e1, 0}, {e2, 0}, {e2, 1}, {e2, 2}}
+	if !slices.Equal(mockCalls, expected) {
+		t.Errorf("Expected calls %v after detaching, got %v", expected, mockCalls)
+	}
+}
+
+func BenchmarkActAllControllers(b *testing.B) {
+	Initialize()
+	for range 10000 {
+		NewAttachedComponent(NewEntity(), mockCID)
+	}
+	grouped := Types().ControllerGroups
+	// Simulate the old behavior, where every controller walked the arena.
+	separate := make([]controllerGroup, 0)
+	for _, group := range grouped {
+		for _, meta := range group.Controllers {
+			separate = append(separate, controllerGroup{
+				ComponentID: group.ComponentID,
+				Priority:    group.Priority,
+				Controllers: []controllerMetadata{meta},
+			})
+		}
+	}
+	defer func() { Types().ControllerGroups = grouped }()
+
+	b.Run("grouped", func(b *testing.B) {
+		Types().ControllerGroups = grouped
+		for b.Loop() {
+			ActAllControllers(ControllerFrame)
+		}
+	})
+	b.Run("separate", func(b *testing.B) {
+		Types().ControllerGroups = separate
+		for b.Loop() {
+			ActAllControllers(ControllerFrame)
+		}
+	})
+}
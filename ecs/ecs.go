@@ -4,9 +4,9 @@
 package ecs
 
 import (
+	"fmt"
 	"html/template"
 	"log"
-	"os"
 	"reflect"
 	"strings"
 	"sync"
@@ -14,7 +14,6 @@ import (
 	"tlyakhov/gofoom/dynamic"
 
 	"github.com/kelindar/bitmap"
-	"sigs.k8s.io/yaml"
 )
 
 // The architecture is like this:
@@ -32,6 +31,8 @@ var (
 
 	rows   [EntitySourceIDBits][]ComponentTable
 	arenas []ComponentArena
+
+	initializers []func()
 )
 
 func Initialize() {
@@ -61,6 +62,8 @@ func Initialize() {
 	for i := range len(rows) {
 		rows[i] = nil
 	}
+	clearQueries()
+	clearChanged()
 	Entities = bitmap.Bitmap{}
 	// 0 is reserved and represents 'null' entity
 	Entities.Set(0)
@@ -76,9 +79,12 @@ func Initialize() {
 		sim.NewFrame = Simulation.NewFrame
 	}
 	Simulation = sim
+	dynamic.UnsubscribeTarget(0)
 
 	SourceFileNames = make(map[string]*SourceFile)
 	SourceFileIDs = make(map[EntitySourceID]*SourceFile)
+	prefabs = make(map[string]*Prefab)
+	resetEntityIndex()
 	FuncMap = template.FuncMap{}
 
 	// Initialize component arenas based on registered component types.
@@ -99,6 +105,17 @@ func Initialize() {
 	//for _, meta := range Types().Controllers {
 	//		log.Printf("%v, priority %v\n", meta.Type.String(), meta.Priority)
 	//	}
+
+	for _, f := range initializers {
+		f()
+	}
+}
+
+// OnInitialize registers a function that's called whenever the world is reset
+// by Initialize, for packages that keep their own state about entities. Should
+// be called during package initialization.
+func OnInitialize(f func()) {
+	initializers = append(initializers, f)
 }
 
 // Reserves an entity ID in the database (no components attached)
@@ -128,10 +145,16 @@ func NextFreeEntitySourceID() EntitySourceID {
 }
 
 func ArenaFor[T any, PT GenericAttachable[T]](id ComponentID) *Arena[T, PT] {
+	if DebugControllerAccess {
+		checkControllerAccess(id)
+	}
 	return arenas[id].(*Arena[T, PT])
 }
 
 func ArenaByID(id ComponentID) ComponentArena {
+	if DebugControllerAccess {
+		checkControllerAccess(id)
+	}
 	return arenas[id]
 }
 
@@ -160,6 +183,9 @@ func GetComponent(entity Entity, id ComponentID) Component {
 	if id == 0 {
 		return nil
 	}
+	if DebugControllerAccess {
+		checkControllerAccess(id)
+	}
 	if sid, local := localizeEntityAndCheckRange(entity); local != 0 {
 		return rows[sid][int(local)].Get(id)
 	}
@@ -283,7 +309,9 @@ func attach(entity Entity, component *Component, componentID ComponentID) {
 	a.Entity = entity
 	a.Attachments++
 	rows[sid][int(local)].Set(attachable)
+	updateQueries(entity, componentID)
 	attachable.OnAttach()
+	componentChanged(ChangeAttached, attachable, entity)
 }
 
 // Create a new component with the given index and attach it.
@@ -363,6 +391,8 @@ func detach(id ComponentID, entity Entity, checkForEmpty bool) {
 		ec.OnDelete()
 	}
 	rows[sid][int(local)].Delete(id)
+	updateQueries(entity, id)
+	componentChanged(ChangeDetached, ec, entity)
 
 	if checkForEmpty {
 		allNil := true
@@ -412,13 +442,15 @@ func Delete(entity Entity) {
 	}
 
 	Entities.Remove(uint32(entity))
+	dynamic.UnsubscribeTarget(uint64(entity))
 
 	sid, local := localizeEntityAndCheckRange(entity)
 	if local == 0 {
 		return
 	}
 
-	for _, c := range rows[sid][int(local)] {
+	table := rows[sid][int(local)]
+	for _, c := range table {
 		if c == nil {
 			continue
 		}
@@ -430,21 +462,35 @@ func Delete(entity Entity) {
 		}
 	}
 	rows[sid][int(local)] = nil
+	updateAllQueries(entity)
+	for _, c := range table {
+		if c != nil {
+			componentChanged(ChangeDetached, c, entity)
+		}
+	}
 }
 
-// TODO: Optimize this and add ability to wildcard search
+// GetEntityByName returns the entity with a given name. If there's more than
+// one, the lowest entity ID wins. See also EntitiesByName and FindEntities.
+//
+// Names changed without MarkModified (e.g. by scripts) are still found, by
+// checking the index against the actual names and scanning every Named
+// component if nothing matches.
 func GetEntityByName(name string) Entity {
-	arena := ArenaFor[Named](NamedCID)
-	for i := range arena.Cap() {
-		if named := arena.Value(i); named != nil && named.Name == name {
-			return named.Entity
+	var result Entity
+	found, _ := lookup(false, name, false)
+	for _, e := range found {
+		if e != 0 && (result == 0 || e < result) {
+			result = e
 		}
 	}
-
-	return 0
+	return result
 }
 
 func Load(filename string) error {
+	if err := dynamic.CheckSandbox("loading " + filename); err != nil {
+		return fmt.Errorf("ecs.Load: %w", err)
+	}
 	// Root file is attached to entity 1
 	file := NewAttachedComponent(1, SourceFileCID).(*SourceFile)
 	file.Source = filename
@@ -453,15 +499,13 @@ func Load(filename string) error {
 	return file.Load()
 }
 
+// Save writes the world to a file, in the binary format if the filename has
+// BinaryWorldExtension, or YAML otherwise.
 func Save(filename string) {
-	bytes, err := yaml.Marshal(SaveSnapshot(false))
-	//bytes, err := json.MarshalIndent(yamlECS, "", "  ")
-
-	if err != nil {
-		panic(err)
+	snapshot := append(Snapshot{WorldHeader()}, SaveSnapshot(false)...)
+	if err := WriteSnapshotFile(filename, snapshot); err != nil {
+		log.Printf("ecs.Save: %v", err)
 	}
-
-	os.WriteFile(filename, bytes, os.ModePerm)
 }
 
 // Returns true if a new one was created.
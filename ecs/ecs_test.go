@@ -19,6 +19,8 @@ var mockCID ComponentID
 
 func init() {
 	mockCID = RegisterComponent(&Arena[mockComponent, *mockComponent]{})
+	registerMockControllers()
+	registerMockCounters()
 }
 
 func GetMockComponent(e Entity) *mockComponent {
@@ -0,0 +1,318 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/kelindar/bitmap"
+)
+
+// The index of entities by name (see Named) and tag (see Tagged). Attaching,
+// detaching, and MarkModified only mark entities as pending, and the index is
+// brought up to date on the next lookup. This way, components can be
+// constructed after being attached (e.g. loading) without indexing their
+// defaults.
+//
+// Names and tags can also be changed without MarkModified (e.g. by scripts),
+// so lookups check their results against the actual names and tags, and scan
+// the components if nothing matches. Lookups can happen from concurrent
+// controllers, so all of this is guarded by indexLock.
+var (
+	indexLock sync.Mutex
+
+	entitiesByName map[string]EntityTable
+	entitiesByTag  map[string]EntityTable
+	// The names and tags each entity was indexed with, for removing them.
+	indexedNames map[Entity]string
+	indexedTags  map[Entity][]string
+	// Entities that need to be re-indexed.
+	indexPending bitmap.Bitmap
+)
+
+func resetEntityIndex() {
+	indexLock.Lock()
+	defer indexLock.Unlock()
+	entitiesByName = make(map[string]EntityTable)
+	entitiesByTag = make(map[string]EntityTable)
+	indexedNames = make(map[Entity]string)
+	indexedTags = make(map[Entity][]string)
+	indexPending.Clear()
+}
+
+func entityIndexChanged(_ ChangeKind, _ Component, entity Entity) {
+	indexLock.Lock()
+	indexPending.Set(uint32(entity))
+	indexLock.Unlock()
+}
+
+func indexRemove(index map[string]EntityTable, key string, entity Entity) {
+	table := index[key]
+	table.Delete(entity)
+	if table.Len() == 0 {
+		delete(index, key)
+	} else {
+		index[key] = table
+	}
+}
+
+func indexAdd(index map[string]EntityTable, key string, entity Entity) {
+	table := index[key]
+	table.Set(entity)
+	index[key] = table
+}
+
+func reindexEntity(entity Entity) {
+	if name, ok := indexedNames[entity]; ok {
+		indexRemove(entitiesByName, name, entity)
+		delete(indexedNames, entity)
+	}
+	for _, tag := range indexedTags[entity] {
+		indexRemove(entitiesByTag, tag, entity)
+	}
+	delete(indexedTags, entity)
+
+	if !Entities.Contains(uint32(entity)) {
+		return
+	}
+	if named := GetNamed(entity); named != nil {
+		indexAdd(entitiesByName, named.Name, entity)
+		indexedNames[entity] = named.Name
+	}
+	if tagged := GetTagged(entity); tagged != nil && len(tagged.Tags) > 0 {
+		for _, tag := range tagged.Tags {
+			indexAdd(entitiesByTag, tag, entity)
+		}
+		indexedTags[entity] = append([]string(nil), tagged.Tags...)
+	}
+}
+
+// updateEntityIndex re-indexes any entities that have changed. indexLock
+// should be held.
+func updateEntityIndex() {
+	if entitiesByName == nil {
+		resetEntityIndex()
+	}
+	if indexPending.Count() == 0 {
+		return
+	}
+	pending := indexPending.Clone(nil)
+	indexPending.Clear()
+	pending.Range(func(entity uint32) {
+		reindexEntity(Entity(entity))
+	})
+}
+
+// entityKeys returns the names or tags an entity actually has right now.
+func entityKeys(entity Entity, tags bool) []string {
+	if tags {
+		if tagged := GetTagged(entity); tagged != nil {
+			return tagged.Tags
+		}
+		return nil
+	}
+	if named := GetNamed(entity); named != nil {
+		return []string{named.Name}
+	}
+	return nil
+}
+
+// isIndexed checks whether an entity's names or tags match the index.
+func isIndexed(entity Entity, keys []string, tags bool) bool {
+	if tags {
+		return slices.Equal(indexedTags[entity], keys)
+	}
+	name, ok := indexedNames[entity]
+	return ok && len(keys) == 1 && keys[0] == name
+}
+
+// scanEntities finds entities with a name or tag that matches by checking
+// every Named or Tagged component. Any entity whose names or tags don't match
+// the index is re-indexed on the next lookup. indexLock should be held.
+func scanEntities(tags bool, match func(key string) bool) EntityTable {
+	var result EntityTable
+	cid := NamedCID
+	if tags {
+		cid = TaggedCID
+	}
+	arena := arenas[cid]
+	for i := range arena.Cap() {
+		c := arena.Component(i)
+		if c == nil {
+			continue
+		}
+		for _, e := range c.Base().Entities {
+			if e == 0 {
+				continue
+			}
+			keys := entityKeys(e, tags)
+			if !isIndexed(e, keys, tags) {
+				indexPending.Set(uint32(e))
+			}
+			if slices.ContainsFunc(keys, match) {
+				result.Set(e)
+			}
+		}
+	}
+	return result
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+// lookup finds entities with names or tags matching a pattern, which is
+// either exact or a glob (see path.Match). Entities whose names or tags
+// changed without MarkModified are still found, see entitiesByName.
+func lookup(tags bool, pattern string, glob bool) (EntityTable, error) {
+	match := func(key string) bool { return key == pattern }
+	if glob {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+		match = func(key string) bool {
+			matched, _ := path.Match(pattern, key)
+			return matched
+		}
+	}
+
+	indexLock.Lock()
+	defer indexLock.Unlock()
+	updateEntityIndex()
+	index := entitiesByName
+	if tags {
+		index = entitiesByTag
+	}
+	var result EntityTable
+	check := func(table EntityTable) {
+		for _, e := range table {
+			if e == 0 {
+				continue
+			}
+			if !slices.ContainsFunc(entityKeys(e, tags), match) {
+				indexPending.Set(uint32(e))
+				continue
+			}
+			result.Set(e)
+		}
+	}
+	if glob {
+		for key, table := range index {
+			if match(key) {
+				check(table)
+			}
+		}
+	} else {
+		check(index[pattern])
+	}
+	if result.Len() == 0 {
+		result = scanEntities(tags, match)
+	}
+	return result, nil
+}
+
+// EntitiesByName returns all the entities with names matching a glob pattern
+// (e.g. "light*"), see path.Match. Invalid patterns don't match anything.
+func EntitiesByName(pattern string) EntityTable {
+	result, _ := lookup(false, pattern, isGlob(pattern))
+	return result
+}
+
+// EntitiesByTag returns all the entities with a tag matching a glob pattern,
+// see EntitiesByName.
+func EntitiesByTag(pattern string) EntityTable {
+	result, _ := lookup(true, pattern, isGlob(pattern))
+	return result
+}
+
+// EntitiesByComponent returns all the entities with a component whose type
+// matches a glob pattern, e.g. "core.Light", or just "Light".
+func EntitiesByComponent(pattern string) (EntityTable, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	var result EntityTable
+	for name, cid := range Types().IDs {
+		short := name[strings.LastIndex(name, ".")+1:]
+		matched, _ := path.Match(pattern, name)
+		if shortMatched, _ := path.Match(pattern, short); !matched && !shortMatched {
+			continue
+		}
+		arena := arenas[cid]
+		for i := range arena.Cap() {
+			c := arena.Component(i)
+			if c == nil {
+				continue
+			}
+			for _, e := range c.Base().Entities {
+				if e != 0 {
+					result.Set(e)
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// FindEntities returns the entities matching every term in a query. Terms are
+// separated by spaces and can be glob patterns (see path.Match):
+//   - name:<pattern> matches entity names.
+//   - tag:<pattern> matches tags.
+//   - component:<pattern> matches component types, e.g. component:Light.
+//   - Anything else matches either names or tags.
+//
+// For example, "tag:reactor component:Light" finds all the lights tagged with
+// "reactor".
+func FindEntities(query string) (EntityTable, error) {
+	var result EntityTable
+	first := true
+	for term := range strings.FieldsSeq(query) {
+		var matched EntityTable
+		var err error
+		kind, pattern, found := strings.Cut(term, ":")
+		if !found {
+			kind, pattern = "", term
+		}
+		switch kind {
+		case "name":
+			matched, err = lookup(false, pattern, isGlob(pattern))
+		case "tag":
+			matched, err = lookup(true, pattern, isGlob(pattern))
+		case "component":
+			matched, err = EntitiesByComponent(pattern)
+		case "":
+			if matched, err = lookup(false, pattern, isGlob(pattern)); err == nil {
+				var tagged EntityTable
+				tagged, err = lookup(true, pattern, isGlob(pattern))
+				for _, e := range tagged {
+					if e != 0 {
+						matched.Set(e)
+					}
+				}
+			}
+		default:
+			err = fmt.Errorf("unknown term %q, expected name:, tag:, or component:", kind)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ecs.FindEntities: %v: %w", term, err)
+		}
+		if first {
+			result = matched
+			first = false
+			continue
+		}
+		var intersection EntityTable
+		for _, e := range result {
+			if e != 0 && matched.Contains(e) {
+				intersection.Set(e)
+			}
+		}
+		result = intersection
+	}
+	return result, nil
+}
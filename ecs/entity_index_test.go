@@ -0,0 +1,133 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func newTestTaggedEntity(name string, tags ...string) Entity {
+	e := NewEntity()
+	named := NewAttachedComponent(e, NamedCID).(*Named)
+	named.Name = name
+	if len(tags) > 0 {
+		tagged := NewAttachedComponent(e, TaggedCID).(*Tagged)
+		tagged.Tags = tags
+	}
+	return e
+}
+
+func TestEntityIndex(t *testing.T) {
+	Initialize()
+	light1 := newTestTaggedEntity("light1", "reactor", "lights")
+	light2 := newTestTaggedEntity("light2", "lights")
+	door := newTestTaggedEntity("door", "reactor")
+	NewAttachedComponent(light1, mockCID)
+	NewAttachedComponent(light2, mockCID)
+
+	if e := GetEntityByName("door"); e != door {
+		t.Errorf("Expected door %v, got %v", door, e)
+	}
+	if found := EntitiesByName("light*"); found.Len() != 2 || !found.Contains(light1) || !found.Contains(light2) {
+		t.Errorf("Expected both lights, got %v", found)
+	}
+	if found := EntitiesByTag("reactor"); found.Len() != 2 || !found.Contains(door) {
+		t.Errorf("Expected light1 and door, got %v", found)
+	}
+	found, err := FindEntities("tag:reactor component:mockComponent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Len() != 1 || !found.Contains(light1) {
+		t.Errorf("Expected light1, got %v", found)
+	}
+	if found, _ := FindEntities("lights"); found.Len() != 2 {
+		t.Errorf("Expected bare term to match tags, got %v", found)
+	}
+	if _, err := FindEntities("colour:red"); err == nil {
+		t.Errorf("Expected error for unknown term")
+	}
+
+	// Renaming and retagging
+	GetNamed(door).Name = "hatch"
+	MarkModified(GetNamed(door), door)
+	GetTagged(light1).Remove("reactor")
+	MarkModified(GetTagged(light1), light1)
+	if GetEntityByName("door") != 0 || GetEntityByName("hatch") != door {
+		t.Errorf("Expected door to be renamed to hatch")
+	}
+	if found := EntitiesByTag("reactor"); found.Len() != 1 || !found.Contains(door) {
+		t.Errorf("Expected only door to be tagged, got %v", found)
+	}
+
+	// Renaming without MarkModified (e.g. from a script)
+	GetNamed(door).Name = "airlock"
+	if GetEntityByName("hatch") != 0 || GetEntityByName("airlock") != door {
+		t.Errorf("Expected door to be found by its new name without MarkModified")
+	}
+	if found := EntitiesByName("airlock"); found.Len() != 1 || !found.Contains(door) {
+		t.Errorf("Expected index to be updated after the lookup, got %v", found)
+	}
+	// The setters update the index, so even non-exact lookups find them.
+	GetNamed(door).SetName("lightish")
+	GetTagged(door).Remove("reactor")
+	GetTagged(door).Add("lights")
+	if found := EntitiesByName("light*"); found.Len() != 3 || !found.Contains(door) {
+		t.Errorf("Expected glob to find the renamed door, got %v", found)
+	}
+	if found := EntitiesByTag("reactor"); found.Len() != 0 {
+		t.Errorf("Expected no entities tagged reactor after retagging, got %v", found)
+	}
+	if found, _ := FindEntities("name:airlock"); found.Len() != 0 {
+		t.Errorf("Expected the old name not to match, got %v", found)
+	}
+	if found, _ := FindEntities("tag:lights name:lightish"); found.Len() != 1 || !found.Contains(door) {
+		t.Errorf("Expected the retagged door, got %v", found)
+	}
+
+	Delete(light2)
+	if found := EntitiesByTag("lights"); found.Len() != 2 || found.Contains(light2) {
+		t.Errorf("Expected deleted entity to be removed, got %v", found)
+	}
+}
+
+func TestEntityIndexConcurrent(t *testing.T) {
+	Initialize()
+	entities := make([]Entity, 8)
+	for i := range entities {
+		entities[i] = newTestTaggedEntity(fmt.Sprintf("thing%v", i), "things")
+	}
+	var wg sync.WaitGroup
+	for i, e := range entities {
+		wg.Go(func() {
+			for range 100 {
+				if found := GetEntityByName(fmt.Sprintf("thing%v", i)); found != e {
+					t.Errorf("Expected %v, got %v", e, found)
+					return
+				}
+				MarkModified(GetNamed(e), e)
+				EntitiesByTag("things")
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestEntityIndexLoad(t *testing.T) {
+	Initialize()
+	e := newTestTaggedEntity("thing", "a", "b")
+	snapshot := SaveSnapshot(false)
+	if err := LoadSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	// Names should be indexed after construction, not with their defaults.
+	if GetEntityByName("thing") != e {
+		t.Errorf("Expected %v to be found after loading", e)
+	}
+	if found := EntitiesByTag("b"); !found.Contains(e) {
+		t.Errorf("Expected %v to be tagged after loading, got %v", e, found)
+	}
+}
@@ -3,6 +3,8 @@
 
 package ecs
 
+import "slices"
+
 /*
 EntityTable is a closed hash table, indexed directly by entities.
 
@@ -134,14 +136,20 @@ func (table *EntityTable) Delete(entity Entity) bool {
 	return true
 }
 
-// Serialize converts the EntityTable to a slice of strings, serializing each entity.
+// Serialize converts the EntityTable to a slice of strings, serializing each
+// entity. They're sorted, since the order in the table depends on how it was
+// built, and saving the same table twice should give the same result.
 func (table EntityTable) Serialize() []string {
-	result := make([]string, 0)
+	entities := make([]Entity, 0, len(table))
 	for _, e := range table {
-		if e == 0 {
-			continue
+		if e != 0 {
+			entities = append(entities, e)
 		}
-		result = append(result, e.Serialize())
+	}
+	slices.Sort(entities)
+	result := make([]string, len(entities))
+	for i, e := range entities {
+		result[i] = e.Serialize()
 	}
 	return result
 }
@@ -0,0 +1,53 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"tlyakhov/gofoom/dynamic"
+)
+
+// EventType is an event class with a typed payload. Events can target an
+// entity, in which case subscribers for that entity are notified as well as
+// the ones for all events of the type. Targeted subscriptions are removed when
+// the entity is deleted.
+type EventType[T any] struct {
+	ID dynamic.EventID
+}
+
+// NewEventType registers an event class for payloads of type *T. These are
+// meant to be published by the simulation (controllers, scripts), so they're
+// internal and not recorded by ledgers. Should be called during package
+// initialization, since the ID depends on registration order.
+func NewEventType[T any](name string) *EventType[T] {
+	dynamic.RegisterEventData(new(T))
+	return &EventType[T]{
+		ID: dynamic.RegisterEventClass(&dynamic.EventClass{Name: name, Internal: true}),
+	}
+}
+
+// Publish queues an event. It's delivered at the end of the current
+// simulation step.
+func (et *EventType[T]) Publish(target Entity, data *T) {
+	Simulation.NewTargetedEvent(et.ID, uint64(target), data)
+}
+
+func (et *EventType[T]) consumer(fn func(evt *dynamic.Event, data *T) bool) dynamic.EventConsumer {
+	return func(evt *dynamic.Event) bool {
+		if data, ok := evt.Data.(*T); ok {
+			return fn(evt, data)
+		}
+		return false
+	}
+}
+
+// Subscribe calls fn for every event of this type. Returning true cancels the
+// event for subscribers with a higher priority value.
+func (et *EventType[T]) Subscribe(priority int, fn func(evt *dynamic.Event, data *T) bool) *dynamic.EventSubscription {
+	return dynamic.SubscribeToEventWithPriority(et.ID, priority, et.consumer(fn))
+}
+
+// SubscribeEntity calls fn for events of this type that target an entity.
+func (et *EventType[T]) SubscribeEntity(e Entity, priority int, fn func(evt *dynamic.Event, data *T) bool) *dynamic.EventSubscription {
+	return dynamic.SubscribeToTarget(et.ID, uint64(e), priority, et.consumer(fn))
+}
@@ -0,0 +1,149 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"fmt"
+	"log"
+	"tlyakhov/gofoom/dynamic"
+)
+
+// Import loads the entities from a world file into the current world, see
+// ImportSnapshot.
+func Import(filename string) (map[Entity]Entity, error) {
+	if err := dynamic.CheckSandbox("importing " + filename); err != nil {
+		return nil, fmt.Errorf("ecs.Import: %w", err)
+	}
+	snapshot, err := readWorldFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("ecs.Import: %w", err)
+	}
+	return ImportSnapshot(snapshot)
+}
+
+// ImportSnapshot merges the entities from another world into the current one.
+// Every imported entity gets a new ID, and relations between imported
+// entities are rewritten to match. References to anything outside of the
+// snapshot are kept as they are. Source files aren't imported, nor are
+// singletons the current world already has.
+//
+// Returns a map of entities in the snapshot to the new ones. Callers should
+// precompute afterwards. If there's an error, anything that was imported is
+// deleted again, leaving the world as it was.
+func ImportSnapshot(snapshot Snapshot) (map[Entity]Entity, error) {
+	imported := make(map[Entity]Entity)
+	result, err := importSnapshot(snapshot, imported)
+	if err != nil {
+		for _, e := range imported {
+			Delete(e)
+		}
+	}
+	return result, err
+}
+
+func importSnapshot(snapshot Snapshot, imported map[Entity]Entity) (map[Entity]Entity, error) {
+	// First, reserve new IDs for everything so that references can be mapped
+	// regardless of order.
+	err := rangeSnapshot(snapshot, func(entity Entity, data map[string]any) error {
+		if entity.IsExternal() {
+			log.Printf("ecs.ImportSnapshot: skipping external entity %v", entity)
+			return nil
+		}
+		if _, ok := imported[entity]; ok {
+			return fmt.Errorf("ecs.ImportSnapshot: duplicate entity %v", entity)
+		}
+		imported[entity] = NewEntity()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mapEntity := func(_ *Relation, e Entity) Entity {
+		if mapped, ok := imported[e]; ok {
+			return mapped
+		}
+		return e
+	}
+
+	err = rangeSnapshot(snapshot, func(entity Entity, data map[string]any) error {
+		pasted, ok := imported[entity]
+		if !ok {
+			return nil
+		}
+		for name, cid := range Types().IDs {
+			componentData := data[name]
+			if componentData == nil || cid == SourceFileCID {
+				continue
+			}
+			if Types().ArenaPlaceholders[cid].Singleton() && Singleton(cid) != nil {
+				log.Printf("ecs.ImportSnapshot: %v already has a %v, skipping", entity, name)
+				continue
+			}
+			var c Component
+			switch typed := componentData.(type) {
+			case string:
+				// A reference to a component on another entity.
+				linked, err := ParseEntity(typed)
+				if err != nil {
+					return fmt.Errorf("ecs.ImportSnapshot: %v %v: %w", entity, name, err)
+				}
+				if c = GetComponent(mapEntity(nil, linked), cid); c == nil {
+					// The other entity may not be loaded yet, see below.
+					continue
+				}
+			case map[string]any:
+				c = LoadComponentWithoutAttaching(cid, typed)
+				ModifyComponentRelationEntities(c, mapEntity)
+			default:
+				return fmt.Errorf("ecs.ImportSnapshot: %v %v: unexpected data %v", entity, name, componentData)
+			}
+			attach(pasted, &c, cid)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Linked components can refer to entities that come later in the
+	// snapshot, take another pass.
+	err = rangeSnapshot(snapshot, func(entity Entity, data map[string]any) error {
+		pasted, ok := imported[entity]
+		if !ok {
+			return nil
+		}
+		for name, cid := range Types().IDs {
+			serialized, ok := data[name].(string)
+			if !ok || GetComponent(pasted, cid) != nil {
+				continue
+			}
+			linked, _ := ParseEntity(serialized)
+			if c := GetComponent(mapEntity(nil, linked), cid); c != nil {
+				attach(pasted, &c, cid)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Clean up anything that ended up empty (e.g. the root entity with the
+	// source file).
+	for original, pasted := range imported {
+		empty := true
+		for _, c := range AllComponents(pasted) {
+			if c != nil {
+				empty = false
+				break
+			}
+		}
+		if empty {
+			Delete(pasted)
+			delete(imported, original)
+		}
+	}
+	return imported, nil
+}
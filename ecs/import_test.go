@@ -0,0 +1,85 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+const importTestWorld = `
+- Entity: ∈⋮1
+  ecs.SourceFile:
+    Source: imported.yaml
+- Entity: ∈⋮2
+  ecs.Named:
+    Name: first
+  ecs.Linked:
+    Sources: [∈⋮3, ∈⋮99]
+- Entity: ∈⋮3
+  ecs.Named:
+    Name: second
+`
+
+func TestImportSnapshot(t *testing.T) {
+	Initialize()
+	existing := NewEntity()
+	NewAttachedComponent(existing, NamedCID).(*Named).Name = "existing"
+
+	var snapshot Snapshot
+	if err := yaml.Unmarshal([]byte(importTestWorld), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := ImportSnapshot(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 2 {
+		t.Fatalf("Expected 2 imported entities (no source file), got %v", imported)
+	}
+	if named := GetNamed(existing); named == nil || named.Name != "existing" {
+		t.Errorf("Expected existing entity to be untouched, got %v", named)
+	}
+	for original, e := range imported {
+		if e == existing || e == original {
+			t.Errorf("Expected %v to get a new ID, got %v", original, e)
+		}
+	}
+	first, second := imported[2], imported[3]
+	if named := GetNamed(first); named == nil || named.Name != "first" {
+		t.Errorf("Expected %v to be named first, got %v", first, named)
+	}
+	linked := GetLinked(first)
+	if linked == nil || len(linked.Sources) != 2 {
+		t.Fatalf("Expected Linked component with 2 sources, got %v", linked)
+	}
+	if linked.Sources[0] != second {
+		t.Errorf("Expected relation to be remapped to %v, got %v", second, linked.Sources[0])
+	}
+	if linked.Sources[1] != 99 {
+		t.Errorf("Expected reference outside the snapshot to be kept, got %v", linked.Sources[1])
+	}
+}
+
+func TestImportSnapshotError(t *testing.T) {
+	Initialize()
+	existing := NewEntity()
+	NewAttachedComponent(existing, NamedCID).(*Named).Name = "existing"
+	count := Entities.Count()
+
+	var snapshot Snapshot
+	if err := yaml.Unmarshal([]byte(importTestWorld+"- Entity: ∈⋮4\n  ecs.Named: 5\n"), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImportSnapshot(snapshot); err == nil {
+		t.Fatalf("Expected an error for invalid component data")
+	}
+	if Entities.Count() != count || GetEntityByName("first") != 0 {
+		t.Errorf("Expected a failed import to be rolled back, got %v entities", Entities.Count())
+	}
+	if named := GetNamed(existing); named == nil || named.Name != "existing" {
+		t.Errorf("Expected existing entity to be untouched, got %v", named)
+	}
+}
@@ -20,6 +20,17 @@ type Serializable interface {
 	Serialize() map[string]any
 }
 
+// RuntimeSerializable is an interface for types with state that evolves during
+// a simulation and isn't covered by Serialize (e.g. timestamps, caches of
+// other entities). This is only used for save games, see SaveGame.
+type RuntimeSerializable interface {
+	// SerializeRuntime returns a map of runtime state. Values must be
+	// representable in YAML.
+	SerializeRuntime() map[string]any
+	// ConstructRuntime restores runtime state. It's called after Construct.
+	ConstructRuntime(data map[string]any)
+}
+
 // Component is an interface for components that can be attached to entities in the ECS.
 type Component interface {
 	Attachable
@@ -72,6 +83,10 @@ type ComponentArena interface {
 	String() string
 	// Singleton returns whether there should only be one element in this arena
 	Singleton() bool
+	// Compact releases the memory of empty chunks in this arena.
+	Compact() int
+	// Stats reports the number of components and memory used by this arena.
+	Stats() ArenaStats
 }
 
 // GenericAttachable is a generic interface constraint for types that can be attached as components.
@@ -99,6 +114,9 @@ type Controller interface {
 	Methods() ControllerMethod
 	// EditorPausedMethods returns a bitmask of the controller methods that this controller implements when the editor is paused.
 	EditorPausedMethods() ControllerMethod
+	// Access returns the components this controller reads and writes during
+	// ControllerFrame, or nil if it should always run serially.
+	Access() *ControllerAccess
 	// Target determines whether the controller should act on a specific entity and component.
 	// Return false if controller shouldn't run for this entity
 	Target(Component, Entity) bool
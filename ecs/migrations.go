@@ -0,0 +1,172 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/spf13/cast"
+)
+
+// WorldFormatVersion should be incremented whenever the layout of world files
+// themselves changes (as opposed to the layout of component data, see
+// RegisterMigration).
+const WorldFormatVersion = 1
+
+// The first element of a world file is a header with the format version and
+// the version of each component's data. Files without a header are treated as
+// version 0 of everything.
+const worldHeaderKey = "WorldHeader"
+
+// Migration upgrades the serialized data of a component from one version to
+// the next, before it's passed to Construct.
+type Migration struct {
+	// Component is the type name used in world files (e.g. "core.Body")
+	Component string
+	// From is the version this migration upgrades from. The result is
+	// version From+1.
+	From        int
+	Description string
+	// Lossy migrations throw away data that can't be represented in the new
+	// layout. tools/worlds won't apply them unless asked to.
+	Lossy   bool
+	Upgrade func(data map[string]any) error
+}
+
+// AppliedMigration is a record of a migration that was applied to an entity.
+type AppliedMigration struct {
+	*Migration
+	Entity Entity
+}
+
+func (am AppliedMigration) String() string {
+	lossy := ""
+	if am.Lossy {
+		lossy = " (lossy)"
+	}
+	return fmt.Sprintf("%v %v v%v->v%v: %v%v", am.Entity, am.Component, am.From, am.From+1, am.Description, lossy)
+}
+
+// MigrationReport lists the migrations applied to a world file.
+type MigrationReport struct {
+	Applied []AppliedMigration
+}
+
+// Lossy checks whether any of the applied migrations were lossy.
+func (report *MigrationReport) Lossy() bool {
+	return slices.ContainsFunc(report.Applied, func(am AppliedMigration) bool { return am.Lossy })
+}
+
+// RegisterMigration registers an upgrade for a component's serialized data.
+// Migrations have to be registered in order, starting with version 0, which
+// is any data saved before the component had migrations. Like controllers,
+// these should be registered in init(). For example:
+//
+//	ecs.RegisterMigration[Body](0, "Rename Height to Size", false, func(data map[string]any) error {
+//		data["Size"] = data["Height"]
+//		delete(data, "Height")
+//		return nil
+//	})
+func RegisterMigration[T any](from int, description string, lossy bool, upgrade func(data map[string]any) error) {
+	name := reflect.TypeFor[T]().String()
+	types := Types()
+	types.lock.Lock()
+	defer types.lock.Unlock()
+	if types.Migrations == nil {
+		types.Migrations = make(map[string][]Migration)
+	}
+	if from != len(types.Migrations[name]) {
+		panic(fmt.Sprintf("ecs.RegisterMigration: %v migration from version %v registered out of order, expected %v", name, from, len(types.Migrations[name])))
+	}
+	types.Migrations[name] = append(types.Migrations[name], Migration{
+		Component:   name,
+		From:        from,
+		Description: description,
+		Lossy:       lossy,
+		Upgrade:     upgrade,
+	})
+}
+
+// ComponentVersion returns the current version of a component's serialized
+// data.
+func ComponentVersion(name string) int {
+	return len(Types().Migrations[name])
+}
+
+// WorldHeader returns the header for a world file saved by this build.
+func WorldHeader() map[string]any {
+	versions := make(map[string]any)
+	for name, list := range Types().Migrations {
+		versions[name] = len(list)
+	}
+	return map[string]any{worldHeaderKey: map[string]any{
+		"Version":    WorldFormatVersion,
+		"Components": versions,
+	}}
+}
+
+func isWorldHeader(element any) bool {
+	m, ok := element.(map[string]any)
+	if !ok {
+		return false
+	}
+	_, ok = m[worldHeaderKey]
+	return ok
+}
+
+// MigrateSnapshot upgrades the component data in a world file to the current
+// versions. The header is removed from the result. The data is modified in
+// place.
+func MigrateSnapshot(snapshot Snapshot) (Snapshot, *MigrationReport, error) {
+	report := &MigrationReport{}
+	versions := make(map[string]int)
+
+	if len(snapshot) > 0 && isWorldHeader(snapshot[0]) {
+		header, _ := snapshot[0].(map[string]any)[worldHeaderKey].(map[string]any)
+		snapshot = snapshot[1:]
+		if v := cast.ToInt(header["Version"]); v > WorldFormatVersion {
+			return nil, nil, fmt.Errorf("ecs.MigrateSnapshot: world format version %v is newer than supported (%v)", v, WorldFormatVersion)
+		}
+		if components, ok := header["Components"].(map[string]any); ok {
+			for name, v := range components {
+				versions[name] = cast.ToInt(v)
+			}
+		}
+	}
+
+	migrations := Types().Migrations
+	names := slices.Sorted(maps.Keys(migrations))
+	for name, version := range versions {
+		if version > len(migrations[name]) {
+			return nil, nil, fmt.Errorf("ecs.MigrateSnapshot: %v data version %v is newer than supported (%v)", name, version, len(migrations[name]))
+		}
+	}
+
+	err := rangeSnapshot(snapshot, func(entity Entity, data map[string]any) error {
+		for _, name := range names {
+			list := migrations[name]
+			// Linked components are strings, and get migrated wherever
+			// they're defined.
+			componentData, ok := data[name].(map[string]any)
+			if !ok {
+				continue
+			}
+			for i := versions[name]; i < len(list); i++ {
+				m := &list[i]
+				if err := m.Upgrade(componentData); err != nil {
+					return fmt.Errorf("ecs.MigrateSnapshot: %v: %v: %w", entity, m.Description, err)
+				}
+				report.Applied = append(report.Applied, AppliedMigration{Migration: m, Entity: entity})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return snapshot, report, nil
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"testing"
+
+	"github.com/spf13/cast"
+)
+
+// Migrations only care about type names, so this doesn't need to be a
+// component.
+type migratedThing struct{}
+
+const migratedThingName = "ecs.migratedThing"
+
+func init() {
+	RegisterMigration[migratedThing](0, "Rename Height to Size", false, func(data map[string]any) error {
+		data["Size"] = data["Height"]
+		delete(data, "Height")
+		return nil
+	})
+	RegisterMigration[migratedThing](1, "Drop Color", true, func(data map[string]any) error {
+		delete(data, "Color")
+		return nil
+	})
+}
+
+func migrationTestSnapshot(version int) Snapshot {
+	snapshot := Snapshot{
+		map[string]any{
+			"Entity":          "∈⋮2",
+			migratedThingName: map[string]any{"Height": 5.0, "Color": "red"},
+		},
+		map[string]any{
+			"Entity": "∈⋮3",
+			// Linked components aren't migrated
+			migratedThingName: "∈⋮2",
+		},
+	}
+	if version >= 0 {
+		header := WorldHeader()
+		header[worldHeaderKey].(map[string]any)["Components"] = map[string]any{migratedThingName: version}
+		snapshot = append(Snapshot{header}, snapshot...)
+	}
+	return snapshot
+}
+
+func TestMigrateSnapshot(t *testing.T) {
+	// -1 is a file without a header
+	for _, version := range []int{-1, 0, 1, 2} {
+		migrated, report, err := MigrateSnapshot(migrationTestSnapshot(version))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(migrated) != 2 {
+			t.Errorf("v%v: expected header to be removed, got %v elements", version, len(migrated))
+		}
+		data := migrated[0].(map[string]any)[migratedThingName].(map[string]any)
+		expected := 2 - max(version, 0)
+		if len(report.Applied) != expected {
+			t.Errorf("v%v: expected %v migrations, got %v", version, expected, report.Applied)
+		}
+		if report.Lossy() != (expected > 0) {
+			t.Errorf("v%v: expected lossy to be %v", version, expected > 0)
+		}
+		if version < 1 && cast.ToFloat64(data["Size"]) != 5 {
+			t.Errorf("v%v: expected Height to be renamed, got %v", version, data)
+		}
+		if version < 2 && data["Color"] != nil {
+			t.Errorf("v%v: expected Color to be removed, got %v", version, data)
+		}
+	}
+}
+
+func TestMigrateSnapshotNewer(t *testing.T) {
+	if _, _, err := MigrateSnapshot(migrationTestSnapshot(3)); err == nil {
+		t.Errorf("Expected error for component data from a newer version")
+	}
+	snapshot := migrationTestSnapshot(2)
+	snapshot[0].(map[string]any)[worldHeaderKey].(map[string]any)["Version"] = WorldFormatVersion + 1
+	if _, _, err := MigrateSnapshot(snapshot); err == nil {
+		t.Errorf("Expected error for a newer world format")
+	}
+}
+
+func TestWorldHeader(t *testing.T) {
+	if v := ComponentVersion(migratedThingName); v != 2 {
+		t.Errorf("Expected version 2, got %v", v)
+	}
+	header := WorldHeader()[worldHeaderKey].(map[string]any)
+	if v := header["Components"].(map[string]any)[migratedThingName]; v != 2 {
+		t.Errorf("Expected header to have version 2, got %v", v)
+	}
+	// Everything else should skip the header.
+	visited := 0
+	rangeSnapshot(migrationTestSnapshot(2), func(entity Entity, data map[string]any) error {
+		visited++
+		return nil
+	})
+	if visited != 2 {
+		t.Errorf("Expected to visit 2 entities, got %v", visited)
+	}
+}
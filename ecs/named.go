@@ -21,6 +21,7 @@ var NamedCID ComponentID
 
 func init() {
 	NamedCID = RegisterComponent(&Arena[Named, *Named]{})
+	Types().Subscribe(NamedCID, ChangeAttached|ChangeDetached|ChangeModified, entityIndexChanged)
 }
 
 func (*Named) ComponentID() ComponentID {
@@ -38,6 +39,16 @@ func (n *Named) String() string {
 	return n.Name
 }
 
+// SetName renames the entity and updates the index. If Name is set directly
+// instead, MarkModified should be called, otherwise only GetEntityByName will
+// find the entity by its new name.
+func (n *Named) SetName(name string) {
+	if n.Name != name {
+		n.Name = name
+		markEntitiesModified(n)
+	}
+}
+
 func (n *Named) Construct(data map[string]any) {
 	n.Attached.Construct(data)
 
@@ -0,0 +1,563 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/dynamic"
+)
+
+/*
+A prefab is a world file used as a template for a set of entities (e.g. an NPC
+along with its inventory slots and weapons). The first entity in the file is
+the root of the prefab.
+
+Adding a PrefabInstance component to an entity creates a copy of the prefab:
+the root's components are attached to that entity, and every other entity in
+the prefab gets a new entity in the world. References between entities in the
+prefab are mapped to the new entities.
+
+Components created from a prefab aren't saved with the world. Instead, the
+PrefabInstance saves any differences between the instance and the prefab
+(overrides), field by field. Overrides are worked out whenever a snapshot of
+the world is taken, see UpdatePrefabOverrides. This way, changes to the prefab show up in every
+instance the next time the world is loaded, unless that instance overrides
+them. Overrides can also be applied back to the prefab, see ApplyOverrides.
+*/
+
+// Prefab is the parsed contents of a prefab file. Entities are in the
+// prefab's own ID space (i.e. what's written in the file).
+type Prefab struct {
+	Path     string
+	Entities []Entity
+	Data     map[Entity]map[string]any
+}
+
+// Root returns the entity instances are attached to.
+func (p *Prefab) Root() Entity {
+	return p.Entities[0]
+}
+
+// Prefabs are cached by path until the ECS is initialized again.
+var prefabs = make(map[string]*Prefab)
+
+// LoadPrefab reads a prefab file, or returns it from the cache.
+func LoadPrefab(path string) (*Prefab, error) {
+	if p, ok := prefabs[path]; ok {
+		return p, nil
+	}
+	if err := dynamic.CheckSandbox("loading " + path); err != nil {
+		return nil, fmt.Errorf("ecs.LoadPrefab: %w", err)
+	}
+	snapshot, err := readWorldFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ecs.LoadPrefab: %w", err)
+	}
+	p := &Prefab{Path: path, Data: make(map[Entity]map[string]any)}
+	err = rangeSnapshot(snapshot, func(entity Entity, data map[string]any) error {
+		if entity.IsExternal() {
+			return fmt.Errorf("ecs.LoadPrefab: entity %v in %v is external", entity, path)
+		}
+		p.Entities = append(p.Entities, entity)
+		p.Data[entity] = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Entities) == 0 {
+		return nil, fmt.Errorf("ecs.LoadPrefab: %v has no entities", path)
+	}
+	prefabs[path] = p
+	return p, nil
+}
+
+// Save writes the prefab back to its file.
+func (p *Prefab) Save() error {
+	snapshot := Snapshot{WorldHeader()}
+	for _, e := range p.Entities {
+		snapshot = append(snapshot, p.Data[e])
+	}
+	if err := WriteSnapshotFile(p.Path, snapshot); err != nil {
+		return fmt.Errorf("ecs.Prefab.Save: %w", err)
+	}
+	return nil
+}
+
+// genericValue converts serialized data to the same form it would have after
+// being written to a file and read back (e.g. numbers are float64), so that it
+// can be compared.
+func genericValue(v any) any {
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("ecs.genericValue: %v", err)
+		return nil
+	}
+	var result any
+	if err = json.Unmarshal(bytes, &result); err != nil {
+		log.Printf("ecs.genericValue: %v", err)
+		return nil
+	}
+	return result
+}
+
+// remapEntityStrings returns a copy of generic serialized data with entity
+// references replaced.
+func remapEntityStrings(v any, mapping func(e Entity) (string, bool)) any {
+	switch typed := v.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(typed))
+		for key, value := range typed {
+			result[key] = remapEntityStrings(value, mapping)
+		}
+		return result
+	case []any:
+		result := make([]any, len(typed))
+		for i, value := range typed {
+			result[i] = remapEntityStrings(value, mapping)
+		}
+		return result
+	case string:
+		if !strings.HasPrefix(typed, EntityDelimiter) {
+			return typed
+		}
+		if e, err := ParseEntity(typed); err == nil && !e.IsExternal() {
+			if mapped, ok := mapping(e); ok {
+				return mapped
+			}
+		}
+	}
+	return v
+}
+
+// PrefabInstance creates the entities from a prefab, see Prefab.
+type PrefabInstance struct {
+	Attached `editable:"^"`
+
+	Source string `editable:"Prefab" edit_type:"file"`
+
+	// Overrides are differences from the prefab. The keys are prefab entities
+	// (e.g. ∈⋮2), then component types, then fields. A nil component or field
+	// means it was removed. Values use the instance's entities.
+	Overrides map[string]any
+	// Members maps entities in the prefab to entities in the world, other than
+	// the root. These are saved so that entity IDs are stable. The keys aren't
+	// relations, since they're in the prefab's ID space.
+	Members map[Entity]Entity `ecs:"non-traversable"`
+
+	instantiated bool
+	// created are the components Instantiate attached to each entity, as
+	// opposed to ones added separately.
+	created map[Entity][]Component
+}
+
+var PrefabInstanceCID ComponentID
+
+func init() {
+	PrefabInstanceCID = RegisterComponent(&Arena[PrefabInstance, *PrefabInstance]{})
+}
+
+func GetPrefabInstance(e Entity) *PrefabInstance {
+	if asserted, ok := GetComponent(e, PrefabInstanceCID).(*PrefabInstance); ok {
+		return asserted
+	}
+	return nil
+}
+
+func (*PrefabInstance) ComponentID() ComponentID {
+	return PrefabInstanceCID
+}
+
+func (pi *PrefabInstance) String() string {
+	return "Prefab: " + pi.Source
+}
+
+// Path returns the prefab's path, relative to the working directory of the
+// world the instance is in.
+func (pi *PrefabInstance) Path() string {
+	if filepath.IsAbs(pi.Source) {
+		return pi.Source
+	}
+	return filepath.Join(WorkingDirForEntity(pi.Entity), pi.Source)
+}
+
+// Prefab returns the prefab this is an instance of.
+func (pi *PrefabInstance) Prefab() (*Prefab, error) {
+	if pi.Source == "" {
+		return nil, fmt.Errorf("ecs.PrefabInstance: %v has no source", pi.Entity)
+	}
+	return LoadPrefab(pi.Path())
+}
+
+// IsInstantiated checks whether the prefab's entities have been created.
+func (pi *PrefabInstance) IsInstantiated() bool {
+	return pi.instantiated
+}
+
+func (pi *PrefabInstance) member(pe Entity, p *Prefab) Entity {
+	if pe == p.Root() {
+		return pi.Entity
+	}
+	return pi.Members[pe]
+}
+
+// toInstance maps references to prefab entities to the instance's entities.
+func (pi *PrefabInstance) toInstance(p *Prefab) func(e Entity) (string, bool) {
+	return func(e Entity) (string, bool) {
+		if _, ok := p.Data[e]; !ok {
+			return "", false
+		}
+		if ie := pi.member(e, p); ie != 0 {
+			return ie.Serialize(), true
+		}
+		return "", false
+	}
+}
+
+// toPrefab maps references to the instance's entities back to the prefab.
+func (pi *PrefabInstance) toPrefab(p *Prefab) func(e Entity) (string, bool) {
+	return func(e Entity) (string, bool) {
+		for _, pe := range p.Entities {
+			if pi.member(pe, p) == e {
+				return pe.String(), true
+			}
+		}
+		return "", false
+	}
+}
+
+// baseline returns the serialized components for an instance entity without
+// any overrides.
+func (pi *PrefabInstance) baseline(p *Prefab, pe Entity) map[string]any {
+	result := make(map[string]any)
+	for name, data := range p.Data[pe] {
+		if name == "Entity" {
+			continue
+		}
+		result[name] = remapEntityStrings(data, pi.toInstance(p))
+	}
+	return result
+}
+
+func applyOverrides(data map[string]any, overrides map[string]any) {
+	for name, componentOverrides := range overrides {
+		fields, ok := componentOverrides.(map[string]any)
+		if !ok {
+			// Removed, or a linked component
+			if componentOverrides == nil {
+				delete(data, name)
+			} else {
+				data[name] = componentOverrides
+			}
+			continue
+		}
+		existing, ok := data[name].(map[string]any)
+		if !ok {
+			existing = make(map[string]any)
+			data[name] = existing
+		}
+		for field, value := range fields {
+			if value == nil {
+				delete(existing, field)
+			} else {
+				existing[field] = value
+			}
+		}
+	}
+}
+
+// Instantiate creates the prefab's entities and components. Components that
+// already exist (e.g. attached directly to the instance) are kept.
+func (pi *PrefabInstance) Instantiate() error {
+	p, err := pi.Prefab()
+	if err != nil {
+		return err
+	}
+
+	// Map prefab entities to world entities, reusing saved IDs where we can.
+	members := make(map[Entity]Entity)
+	for _, pe := range p.Entities[1:] {
+		if e := pi.Members[pe]; e != 0 {
+			CreateEntity(e)
+			members[pe] = e
+		} else {
+			members[pe] = NewEntity()
+		}
+	}
+	pi.Members = members
+	pi.created = make(map[Entity][]Component)
+
+	for _, pe := range p.Entities {
+		ie := pi.member(pe, p)
+		data := pi.baseline(p, pe)
+		if overrides, ok := pi.Overrides[pe.String()].(map[string]any); ok {
+			applyOverrides(data, overrides)
+		}
+		for name, componentData := range data {
+			cid, ok := Types().IDs[name]
+			if !ok || cid == PrefabInstanceCID || cid == SourceFileCID {
+				continue
+			}
+			if GetComponent(ie, cid) != nil {
+				continue
+			}
+			if link, ok := componentData.(string); ok {
+				linked, _ := ParseEntity(link)
+				if c := GetComponent(linked, cid); c != nil {
+					attach(ie, &c, cid)
+				}
+				continue
+			}
+			fields, ok := componentData.(map[string]any)
+			if !ok {
+				continue
+			}
+			c := LoadComponentWithoutAttaching(cid, fields)
+			// These are saved as overrides instead.
+			c.Base().Flags |= ComponentNoSave
+			attach(ie, &c, cid)
+			pi.created[ie] = append(pi.created[ie], c)
+		}
+	}
+	pi.instantiated = true
+	return nil
+}
+
+// isFromPrefab checks whether a component on an entity was created by
+// Instantiate.
+func (pi *PrefabInstance) isFromPrefab(e Entity, c Component) bool {
+	return c != nil && slices.Contains(pi.created[e], c)
+}
+
+// Uninstantiate removes the components created by Instantiate, as well as
+// any member entities that end up empty.
+func (pi *PrefabInstance) Uninstantiate() {
+	if !pi.instantiated {
+		return
+	}
+	p, err := pi.Prefab()
+	if err != nil {
+		return
+	}
+	for _, pe := range p.Entities {
+		ie := pi.member(pe, p)
+		if ie == 0 || !Entities.Contains(uint32(ie)) {
+			// If the root is being deleted, Delete takes care of the rest.
+			continue
+		}
+		// Detaching modifies the table, so collect these first.
+		cids := make([]ComponentID, 0)
+		for _, c := range AllComponents(ie) {
+			if pi.isFromPrefab(ie, c) {
+				cids = append(cids, c.ComponentID())
+			}
+		}
+		for _, cid := range cids {
+			detach(cid, ie, ie != pi.Entity)
+		}
+	}
+	pi.created = nil
+	pi.instantiated = false
+}
+
+// serializeFromPrefab serializes a component in the same form as the
+// prefab's data, for comparison. The ComponentNoSave flag that Instantiate
+// adds is left out.
+func serializeFromPrefab(c Component) map[string]any {
+	data, _ := genericValue(c.Serialize()).(map[string]any)
+	delete(data, "Entities")
+	delete(data, "_Flags")
+	if flags := c.Base().Flags &^ ComponentNoSave; flags != ComponentActive {
+		data["_Flags"] = concepts.SerializeFlags(flags, ComponentFlagsValues())
+	}
+	return data
+}
+
+// diff compares an instance's components with the prefab and returns the
+// overrides.
+func (pi *PrefabInstance) diff(p *Prefab) map[string]any {
+	result := make(map[string]any)
+	for _, pe := range p.Entities {
+		ie := pi.member(pe, p)
+		if ie == 0 {
+			continue
+		}
+		entityOverrides := make(map[string]any)
+		for name, data := range pi.baseline(p, pe) {
+			cid, ok := Types().IDs[name]
+			if !ok || cid == PrefabInstanceCID {
+				continue
+			}
+			fields, ok := data.(map[string]any)
+			if !ok {
+				continue
+			}
+			c := GetComponent(ie, cid)
+			if c == nil {
+				entityOverrides[name] = nil
+				continue
+			}
+			if !pi.isFromPrefab(ie, c) {
+				// This is saved separately.
+				continue
+			}
+			expected := serializeFromPrefab(LoadComponentWithoutAttaching(cid, fields))
+			actual := serializeFromPrefab(c)
+			componentOverrides := make(map[string]any)
+			for _, field := range slices.Collect(maps.Keys(expected)) {
+				if _, ok := actual[field]; !ok {
+					componentOverrides[field] = nil
+				}
+			}
+			for field, value := range actual {
+				if !reflect.DeepEqual(expected[field], value) {
+					componentOverrides[field] = value
+				}
+			}
+			if len(componentOverrides) > 0 {
+				entityOverrides[name] = componentOverrides
+			}
+		}
+		if len(entityOverrides) > 0 {
+			result[pe.String()] = entityOverrides
+		}
+	}
+	return result
+}
+
+// UpdateOverrides records the differences between the instance and the
+// prefab in Overrides. This happens for every instance before the world is
+// saved, see UpdatePrefabOverrides.
+func (pi *PrefabInstance) UpdateOverrides() {
+	if !pi.instantiated {
+		return
+	}
+	if p, err := pi.Prefab(); err == nil {
+		pi.Overrides = pi.diff(p)
+	}
+}
+
+// UpdatePrefabOverrides updates the overrides of every instantiated prefab,
+// before saving the world.
+func UpdatePrefabOverrides() {
+	arena := ArenaFor[PrefabInstance](PrefabInstanceCID)
+	for i := range arena.Cap() {
+		if pi := arena.Value(i); pi != nil {
+			pi.UpdateOverrides()
+		}
+	}
+}
+
+// ApplyOverrides changes the prefab to match this instance, and saves it.
+// Other instances of the same prefab are updated, keeping their own
+// overrides.
+func (pi *PrefabInstance) ApplyOverrides() error {
+	if err := dynamic.CheckSandbox("applying overrides to " + pi.Path()); err != nil {
+		return fmt.Errorf("ecs.PrefabInstance.ApplyOverrides: %w", err)
+	}
+	p, err := pi.Prefab()
+	if err != nil {
+		return err
+	}
+	pi.UpdateOverrides()
+
+	// Other instances need to be diffed against the old prefab.
+	others := make([]*PrefabInstance, 0)
+	arena := ArenaFor[PrefabInstance](PrefabInstanceCID)
+	for i := range arena.Cap() {
+		other := arena.Value(i)
+		if other == nil || other == pi || !other.instantiated {
+			continue
+		}
+		if op, err := other.Prefab(); err == nil && op == p {
+			other.UpdateOverrides()
+			others = append(others, other)
+		}
+	}
+
+	for key, overrides := range pi.Overrides {
+		pe, err := ParseEntity(key)
+		if err != nil || p.Data[pe] == nil {
+			continue
+		}
+		overrides = remapEntityStrings(overrides, pi.toPrefab(p))
+		applyOverrides(p.Data[pe], overrides.(map[string]any))
+	}
+	pi.Overrides = nil
+	if err = p.Save(); err != nil {
+		return err
+	}
+
+	for _, other := range append(others, pi) {
+		other.Uninstantiate()
+		if err = other.Instantiate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pi *PrefabInstance) OnDetach(e Entity) {
+	defer pi.Attached.OnDetach(e)
+	if !pi.IsAttached() {
+		return
+	}
+	pi.Uninstantiate()
+}
+
+func (pi *PrefabInstance) Construct(data map[string]any) {
+	pi.Attached.Construct(data)
+	pi.Source = ""
+	pi.Overrides = nil
+	pi.Members = nil
+	pi.instantiated = false
+	pi.created = nil
+
+	if data == nil {
+		return
+	}
+
+	if v, ok := data["Source"]; ok {
+		pi.Source = v.(string)
+	}
+	if v, ok := data["Overrides"].(map[string]any); ok {
+		pi.Overrides = v
+	}
+	if v, ok := data["Members"].(map[string]any); ok {
+		pi.Members = make(map[Entity]Entity, len(v))
+		for key, value := range v {
+			pe, err := ParseEntity(key)
+			if err != nil {
+				continue
+			}
+			if s, ok := value.(string); ok {
+				pi.Members[pe], _ = ParseEntity(s)
+			}
+		}
+	}
+}
+
+func (pi *PrefabInstance) Serialize() map[string]any {
+	result := pi.Attached.Serialize()
+	result["Source"] = pi.Source
+	if len(pi.Overrides) > 0 {
+		result["Overrides"] = pi.Overrides
+	}
+	if len(pi.Members) > 0 {
+		members := make(map[string]any, len(pi.Members))
+		for pe, e := range pi.Members {
+			members[pe.String()] = e.Serialize()
+		}
+		result["Members"] = members
+	}
+	return result
+}
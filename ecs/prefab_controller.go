@@ -0,0 +1,36 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import "log"
+
+type PrefabInstanceController struct {
+	BaseController
+	*PrefabInstance
+}
+
+func init() {
+	// Should run before LinkedController, so that prefabs can use Linked.
+	Types().RegisterController(func() Controller { return &PrefabInstanceController{} }, -1)
+}
+
+func (pc *PrefabInstanceController) ComponentID() ComponentID {
+	return PrefabInstanceCID
+}
+
+func (pc *PrefabInstanceController) Methods() ControllerMethod {
+	return ControllerPrecompute
+}
+
+func (pc *PrefabInstanceController) Target(target Component, e Entity) bool {
+	pc.Entity = e
+	pc.PrefabInstance = target.(*PrefabInstance)
+	return pc.PrefabInstance.IsActive() && !pc.PrefabInstance.IsInstantiated()
+}
+
+func (pc *PrefabInstanceController) Precompute() {
+	if err := pc.Instantiate(); err != nil {
+		log.Printf("ecs.PrefabInstanceController: %v: %v", pc.Entity, err)
+	}
+}
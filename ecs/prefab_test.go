@@ -0,0 +1,168 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const prefabTestFile = `
+- Entity: ∈⋮1
+  ecs.Named:
+    Name: npc
+  ecs.Linked:
+    Sources: [∈⋮2]
+- Entity: ∈⋮2
+  ecs.Named:
+    Name: weapon
+`
+
+func newPrefabTestInstance(t *testing.T) (string, *PrefabInstance) {
+	path := filepath.Join(t.TempDir(), "npc.yaml")
+	if err := os.WriteFile(path, []byte(prefabTestFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	Initialize()
+	e := NewEntity()
+	pi := NewAttachedComponent(e, PrefabInstanceCID).(*PrefabInstance)
+	pi.Source = path
+	if err := pi.Instantiate(); err != nil {
+		t.Fatal(err)
+	}
+	return path, pi
+}
+
+func TestPrefabInstantiate(t *testing.T) {
+	_, pi := newPrefabTestInstance(t)
+	member := pi.Members[2]
+	if member == 0 || member == pi.Entity {
+		t.Fatalf("Expected a new entity for the prefab's second entity, got %v", member)
+	}
+	if named := GetNamed(pi.Entity); named == nil || named.Name != "npc" {
+		t.Errorf("Expected root to be named npc, got %v", named)
+	}
+	if named := GetNamed(member); named == nil || named.Name != "weapon" {
+		t.Errorf("Expected member to be named weapon, got %v", named)
+	}
+	if linked := GetLinked(pi.Entity); linked == nil || len(linked.Sources) != 1 || linked.Sources[0] != member {
+		t.Errorf("Expected reference to be mapped to %v, got %v", member, linked)
+	}
+	if len(SaveSnapshot(false)) != 1 {
+		t.Errorf("Expected only the PrefabInstance to be saved")
+	}
+	if pi.Serialize()["Overrides"] != nil {
+		t.Errorf("Expected no overrides, got %v", pi.Overrides)
+	}
+
+	// Serializing doesn't work out the overrides, saving does.
+	GetNamed(member).Name = "custom"
+	if pi.Serialize()["Overrides"] != nil || len(pi.Overrides) != 0 {
+		t.Errorf("Expected Serialize not to change the overrides, got %v", pi.Overrides)
+	}
+	SaveSnapshot(false)
+	if pi.Overrides["∈⋮2"] == nil {
+		t.Errorf("Expected overrides after saving, got %v", pi.Overrides)
+	}
+
+	// Components that didn't come from the prefab stay, even if they aren't
+	// saved.
+	extra := NewAttachedComponent(member, TaggedCID)
+	extra.Base().Flags |= ComponentNoSave
+	pi.Uninstantiate()
+	if GetTagged(member) != extra {
+		t.Errorf("Expected a component added to a member to stay after uninstantiating")
+	}
+	if GetNamed(member) != nil {
+		t.Errorf("Expected components from the prefab to be removed")
+	}
+}
+
+func TestPrefabOverrides(t *testing.T) {
+	path, pi := newPrefabTestInstance(t)
+	root, member := pi.Entity, pi.Members[2]
+	GetNamed(member).Name = "custom"
+	DetachComponent(LinkedCID, pi.Entity)
+	pi.UpdateOverrides()
+	overrides, _ := pi.Overrides["∈⋮2"].(map[string]any)
+	if named, _ := overrides["ecs.Named"].(map[string]any); named == nil || named["Name"] != "custom" {
+		t.Errorf("Expected Name override, got %v", pi.Overrides)
+	}
+	overrides, _ = pi.Overrides["∈⋮1"].(map[string]any)
+	if linked, ok := overrides["ecs.Linked"]; !ok || linked != nil {
+		t.Errorf("Expected removed component override, got %v", pi.Overrides)
+	}
+
+	// Changes to the prefab should show up, unless overridden
+	snapshot := SaveSnapshot(false)
+	changed := `
+- Entity: ∈⋮1
+  ecs.Named:
+    Name: changed npc
+  ecs.Linked:
+    Sources: [∈⋮2]
+- Entity: ∈⋮2
+  ecs.Named:
+    Name: changed weapon
+`
+	if err := os.WriteFile(path, []byte(changed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	pi = GetPrefabInstance(root)
+	if pi == nil || !pi.IsInstantiated() {
+		t.Fatalf("Expected prefab to be instantiated on load")
+	}
+	if pi.Members[2] != member {
+		t.Errorf("Expected member entity to stay %v, got %v", member, pi.Members[2])
+	}
+	if named := GetNamed(root); named == nil || named.Name != "changed npc" {
+		t.Errorf("Expected prefab change to propagate, got %v", named)
+	}
+	if named := GetNamed(member); named == nil || named.Name != "custom" {
+		t.Errorf("Expected override to be kept, got %v", named)
+	}
+	if GetLinked(root) != nil {
+		t.Errorf("Expected removed component to stay removed")
+	}
+}
+
+func TestPrefabApplyOverrides(t *testing.T) {
+	path, pi := newPrefabTestInstance(t)
+	other := NewAttachedComponent(NewEntity(), PrefabInstanceCID).(*PrefabInstance)
+	other.Source = path
+	if err := other.Instantiate(); err != nil {
+		t.Fatal(err)
+	}
+	GetNamed(other.Entity).Name = "other npc"
+
+	GetNamed(pi.Members[2]).Name = "custom"
+	if err := pi.ApplyOverrides(); err != nil {
+		t.Fatal(err)
+	}
+	if len(pi.Overrides) != 0 {
+		t.Errorf("Expected no overrides after applying, got %v", pi.Overrides)
+	}
+
+	delete(prefabs, path)
+	p, err := LoadPrefab(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := p.Data[2]["ecs.Named"].(map[string]any)["Name"]; name != "custom" {
+		t.Errorf("Expected prefab file to be updated, got %v", name)
+	}
+	if sources := p.Data[1]["ecs.Linked"].(map[string]any)["Sources"].([]any); len(sources) != 1 || sources[0] != "∈⋮2" {
+		t.Errorf("Expected references in prefab file to stay in prefab space, got %v", sources)
+	}
+	if named := GetNamed(other.Members[2]); named == nil || named.Name != "custom" {
+		t.Errorf("Expected other instance to be updated, got %v", named)
+	}
+	if named := GetNamed(other.Entity); named == nil || named.Name != "other npc" {
+		t.Errorf("Expected other instance to keep its overrides, got %v", named)
+	}
+}
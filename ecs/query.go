@@ -0,0 +1,221 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"math/bits"
+	"slices"
+
+	"github.com/kelindar/bitmap"
+)
+
+// Query matches entities that have all of the With components and none of the
+// Without components. Membership is kept in a bitmap that's updated whenever
+// components are attached or detached, so iterating a query never walks any
+// arenas. Queries are cached: creating a query with the same filters as an
+// existing one returns the existing query.
+//
+// Queries only track which components are attached, not whether they're
+// active.
+type Query struct {
+	With    []ComponentID
+	Without []ComponentID
+
+	members bitmap.Bitmap
+}
+
+var (
+	queries []*Query
+	// Indexed by component ID, the queries that need to be updated when a
+	// component with that ID is attached or detached.
+	queriesByComponent [][]*Query
+)
+
+// NewQuery returns a query for entities that have all of the components in
+// with and none of the components in without.
+func NewQuery(with []ComponentID, without []ComponentID) *Query {
+	with = slices.Sorted(slices.Values(with))
+	without = slices.Sorted(slices.Values(without))
+	for _, q := range queries {
+		if slices.Equal(q.With, with) && slices.Equal(q.Without, without) {
+			return q
+		}
+	}
+
+	q := &Query{With: with, Without: without}
+	queries = append(queries, q)
+	for _, ids := range [][]ComponentID{with, without} {
+		for _, id := range ids {
+			for len(queriesByComponent) <= int(id) {
+				queriesByComponent = append(queriesByComponent, nil)
+			}
+			queriesByComponent[id] = append(queriesByComponent[id], q)
+		}
+	}
+	q.rebuild()
+	return q
+}
+
+func (q *Query) matches(entity Entity) bool {
+	sid, local := localizeEntityAndCheckRange(entity)
+	if local == 0 || !Entities.Contains(uint32(entity)) {
+		return false
+	}
+	table := rows[sid][int(local)]
+	for _, id := range q.With {
+		if table.Get(id) == nil {
+			return false
+		}
+	}
+	for _, id := range q.Without {
+		if table.Get(id) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *Query) update(entity Entity) {
+	if q.matches(entity) {
+		q.members.Set(uint32(entity))
+	} else {
+		q.members.Remove(uint32(entity))
+	}
+}
+
+func (q *Query) rebuild() {
+	q.members.Clear()
+	Entities.Range(func(entity uint32) {
+		q.update(Entity(entity))
+	})
+}
+
+// updateQueries is called when a component is attached or detached.
+func updateQueries(entity Entity, id ComponentID) {
+	if int(id) >= len(queriesByComponent) {
+		return
+	}
+	for _, q := range queriesByComponent[id] {
+		q.update(entity)
+	}
+}
+
+// updateAllQueries is called when an entity is deleted or moved.
+func updateAllQueries(entity Entity) {
+	for _, q := range queries {
+		q.update(entity)
+	}
+}
+
+func clearQueries() {
+	for _, q := range queries {
+		q.members.Clear()
+	}
+}
+
+// Contains checks whether an entity matches the query.
+func (q *Query) Contains(entity Entity) bool {
+	return q.members.Contains(uint32(entity))
+}
+
+// Count returns the number of matching entities.
+func (q *Query) Count() int {
+	return q.members.Count()
+}
+
+// Each calls f for every matching entity, in ascending order, until f returns
+// false. Entities that start or stop matching during iteration may or may not
+// be visited.
+func (q *Query) Each(f func(entity Entity) bool) {
+	for i := 0; i < len(q.members); i++ {
+		block := q.members[i]
+		for block != 0 {
+			bit := bits.TrailingZeros64(block)
+			block &= block - 1
+			if !f(Entity(i*64 + bit)) {
+				return
+			}
+		}
+	}
+}
+
+// First returns the matching entity with the lowest ID, or 0 if there are no
+// matches.
+func (q *Query) First() Entity {
+	if e, ok := q.members.Min(); ok {
+		return Entity(e)
+	}
+	return 0
+}
+
+func componentIDFor[T any, PT GenericAttachable[T]]() ComponentID {
+	return PT(new(T)).ComponentID()
+}
+
+// View1 is a query with typed access to a component.
+type View1[T any, PT GenericAttachable[T]] struct {
+	*Query
+	id ComponentID
+}
+
+// NewView1 returns a view of entities with a component of type T, and none of
+// the components in without. For example:
+//
+//	players := ecs.NewView1[character.Player](behaviors.SpawnerCID)
+func NewView1[T any, PT GenericAttachable[T]](without ...ComponentID) *View1[T, PT] {
+	id := componentIDFor[T, PT]()
+	return &View1[T, PT]{Query: NewQuery([]ComponentID{id}, without), id: id}
+}
+
+// Each calls f for every matching entity and its component, until f returns
+// false.
+func (v *View1[T, PT]) Each(f func(entity Entity, c PT) bool) {
+	v.Query.Each(func(entity Entity) bool {
+		return f(entity, GetComponent(entity, v.id).(PT))
+	})
+}
+
+// Get returns the component for an entity, or nil if it doesn't match.
+func (v *View1[T, PT]) Get(entity Entity) PT {
+	if !v.Contains(entity) {
+		return nil
+	}
+	return GetComponent(entity, v.id).(PT)
+}
+
+// View2 is a query with typed access to two components.
+type View2[T1, T2 any, PT1 GenericAttachable[T1], PT2 GenericAttachable[T2]] struct {
+	*Query
+	id1, id2 ComponentID
+}
+
+// NewView2 returns a view of entities with components of types T1 and T2, and
+// none of the components in without. For example:
+//
+//	mobiles := ecs.NewView2[core.Body, core.Mobile](behaviors.SpawnerCID)
+func NewView2[T1, T2 any, PT1 GenericAttachable[T1], PT2 GenericAttachable[T2]](without ...ComponentID) *View2[T1, T2, PT1, PT2] {
+	id1 := componentIDFor[T1, PT1]()
+	id2 := componentIDFor[T2, PT2]()
+	return &View2[T1, T2, PT1, PT2]{
+		Query: NewQuery([]ComponentID{id1, id2}, without),
+		id1:   id1,
+		id2:   id2,
+	}
+}
+
+// Each calls f for every matching entity and its components, until f returns
+// false.
+func (v *View2[T1, T2, PT1, PT2]) Each(f func(entity Entity, c1 PT1, c2 PT2) bool) {
+	v.Query.Each(func(entity Entity) bool {
+		return f(entity, GetComponent(entity, v.id1).(PT1), GetComponent(entity, v.id2).(PT2))
+	})
+}
+
+// Get returns the components for an entity, or nil if it doesn't match.
+func (v *View2[T1, T2, PT1, PT2]) Get(entity Entity) (PT1, PT2) {
+	if !v.Contains(entity) {
+		return nil, nil
+	}
+	return GetComponent(entity, v.id1).(PT1), GetComponent(entity, v.id2).(PT2)
+}
@@ -0,0 +1,104 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+func queryEntities(q *Query) []Entity {
+	result := make([]Entity, 0)
+	q.Each(func(e Entity) bool {
+		result = append(result, e)
+		return true
+	})
+	return result
+}
+
+func TestQueryMembership(t *testing.T) {
+	Initialize()
+	q := NewQuery([]ComponentID{mockCounterACID, mockCID}, []ComponentID{mockCounterBCID})
+	if q != NewQuery([]ComponentID{mockCID, mockCounterACID}, []ComponentID{mockCounterBCID}) {
+		t.Errorf("Expected queries with the same filters to be cached")
+	}
+
+	e1, e2, e3 := NewEntity(), NewEntity(), NewEntity()
+	defer deleteEntities([]Entity{e1, e2, e3})
+	for _, e := range []Entity{e1, e2, e3} {
+		NewAttachedComponent(e, mockCounterACID)
+	}
+	NewAttachedComponent(e1, mockCID)
+	NewAttachedComponent(e2, mockCID)
+	if actual := queryEntities(q); !slices.Equal(actual, []Entity{e1, e2}) {
+		t.Errorf("Expected %v, got %v", []Entity{e1, e2}, actual)
+	}
+
+	NewAttachedComponent(e2, mockCounterBCID)
+	DetachComponent(mockCID, e1)
+	NewAttachedComponent(e3, mockCID)
+	if actual := queryEntities(q); !slices.Equal(actual, []Entity{e3}) {
+		t.Errorf("Expected %v, got %v", []Entity{e3}, actual)
+	}
+
+	Delete(e3)
+	if q.Count() != 0 || q.First() != 0 {
+		t.Errorf("Expected no entities after delete, got %v", queryEntities(q))
+	}
+
+	// New queries should pick up existing entities.
+	q2 := NewQuery([]ComponentID{mockCounterBCID}, nil)
+	if !q2.Contains(e2) || q2.Count() != 1 {
+		t.Errorf("Expected %v to match a new query, got %v", e2, queryEntities(q2))
+	}
+	Initialize()
+	if q2.Count() != 0 {
+		t.Errorf("Expected no entities after Initialize, got %v", queryEntities(q2))
+	}
+}
+
+func TestQueryMove(t *testing.T) {
+	Initialize()
+	q := NewQuery([]ComponentID{mockCounterACID}, nil)
+	from := NewEntity()
+	NewAttachedComponent(from, mockCounterACID)
+	to := from + 100
+	MoveEntityComponents(from, to)
+	defer Delete(to)
+	if actual := queryEntities(q); !slices.Equal(actual, []Entity{to}) {
+		t.Errorf("Expected %v, got %v", []Entity{to}, actual)
+	}
+}
+
+func TestViews(t *testing.T) {
+	Initialize()
+	entities := createMockCounters(5)
+	defer deleteEntities(entities)
+	DetachComponent(mockCID, entities[1])
+
+	v1 := NewView1[mockCounterA](mockCID)
+	v1.Each(func(e Entity, a *mockCounterA) bool {
+		if e != entities[1] {
+			t.Errorf("Expected only %v in view, got %v", entities[1], e)
+		}
+		a.Count = 1
+		return true
+	})
+	if v1.Get(entities[1]).Count != 1 || v1.Get(entities[0]) != nil {
+		t.Errorf("View1.Get returned unexpected components")
+	}
+
+	v2 := NewView2[mockCounterA, mockCounterB]()
+	visited := 0
+	v2.Each(func(e Entity, a *mockCounterA, b *mockCounterB) bool {
+		if a.Entity != e || b.Entity != e {
+			t.Errorf("Components don't match entity %v", e)
+		}
+		visited++
+		return visited < 3
+	})
+	if visited != 3 {
+		t.Errorf("Expected Each to stop after 3 entities, visited %v", visited)
+	}
+}
@@ -381,6 +381,8 @@ func MoveEntityComponents(from Entity, to Entity) {
 	// Delete the source.
 	*tableFrom = nil
 	Entities.Remove(uint32(from))
+	updateAllQueries(from)
+	updateAllQueries(to)
 
 	// Wire up any relations.
 	FindReplaceRelations(from, to)
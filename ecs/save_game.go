@@ -0,0 +1,320 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/dynamic"
+
+	"github.com/spf13/cast"
+	"sigs.k8s.io/yaml"
+)
+
+// SaveGameVersion should be incremented whenever the save game format changes
+// in a way that older saves can't be read.
+const SaveGameVersion = 1
+
+// A save game is stored separately from the world it was played in. It has
+// every entity and component (including external and internal ones), plus any
+// runtime state that isn't part of Serialize(). Loading a save game loads the
+// original world first, so that included files get mapped to the same IDs, and
+// then replaces all the entities with the saved ones.
+type saveGame struct {
+	Version    int
+	World      string
+	Simulation map[string]any
+	Entities   Snapshot
+}
+
+var entityType = reflect.TypeFor[Entity]()
+
+// YAML numbers are parsed as float64, so anything larger than this gets
+// stored as a string.
+const maxExactInt = 1 << 53
+
+// Transient components (e.g. playing sounds) are tagged with `ecs:"transient"`
+// on the Attached field, and are never included in save games.
+func isTransient(cid ComponentID) bool {
+	t := Types().ArenaPlaceholders[cid].Type()
+	if sf, ok := t.FieldByName("Attached"); ok {
+		return sf.Tag.Get("ecs") == "transient"
+	}
+	return false
+}
+
+func runtimeValue(v reflect.Value) (any, bool) {
+	if v.Type() == entityType {
+		return Entity(v.Uint()).Serialize(), true
+	}
+	// Avoid v.Interface(), since enums marshal to strings.
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i := v.Int(); i > maxExactInt || i < -maxExactInt {
+			return strconv.FormatInt(i, 10), true
+		}
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := v.Uint(); u > maxExactInt {
+			return strconv.FormatUint(u, 10), true
+		}
+		return v.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return v.String(), true
+	case reflect.Array:
+		// Vectors, matrices, etc...
+		result := make([]any, v.Len())
+		for i := range v.Len() {
+			var ok bool
+			if result[i], ok = runtimeValue(v.Index(i)); !ok {
+				return nil, false
+			}
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+func setRuntimeValue(v reflect.Value, data any) {
+	if v.Type() == entityType {
+		e, _ := ParseEntity(cast.ToString(data))
+		v.SetUint(uint64(e))
+		return
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(cast.ToBool(data))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s, ok := data.(string); ok {
+			i, _ := strconv.ParseInt(s, 10, 64)
+			v.SetInt(i)
+		} else {
+			v.SetInt(cast.ToInt64(data))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s, ok := data.(string); ok {
+			u, _ := strconv.ParseUint(s, 10, 64)
+			v.SetUint(u)
+		} else {
+			v.SetUint(cast.ToUint64(data))
+		}
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(cast.ToFloat64(data))
+	case reflect.String:
+		v.SetString(cast.ToString(data))
+	case reflect.Array:
+		if slice, ok := data.([]any); ok {
+			for i := range min(v.Len(), len(slice)) {
+				setRuntimeValue(v.Index(i), slice[i])
+			}
+		}
+	}
+}
+
+// processRuntimeFields is similar to processNonSerializedFields, except that
+// it only stores values that can be written to a file. Non-editable fields
+// with simple types are handled automatically. Anything more complicated (e.g.
+// maps) needs to be handled by implementing RuntimeSerializable. Empty maps
+// and slices are omitted to keep save games readable.
+func processRuntimeFields(object any, serialized map[string]any, save bool) {
+	if object == nil || reflect.ValueOf(object).IsNil() {
+		return
+	}
+
+	objectValue := reflect.ValueOf(object).Elem()
+	objectType := objectValue.Type()
+
+	for i := range objectType.NumField() {
+		field := objectType.Field(i)
+
+		flags := FieldFlagsFromTag(field.Tag)
+		if !field.IsExported() || field.Type == attachedType ||
+			(flags&(FieldNonCacheable|FieldShallowCacheable)) != 0 {
+			continue
+		}
+		v := objectValue.Field(i)
+		name := "_runtime_" + field.Name
+
+		if rs, ok := v.Addr().Interface().(RuntimeSerializable); ok {
+			if save {
+				if data := rs.SerializeRuntime(); len(data) > 0 {
+					serialized[name] = data
+				}
+			} else if loaded, ok := serialized[name].(map[string]any); ok {
+				rs.ConstructRuntime(loaded)
+			}
+			continue
+		}
+
+		isSliceOfStruct := field.Type.Kind() == reflect.Slice && isStructOrPtrToStruct(field.Type.Elem())
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			childMap := make(map[string]any)
+			if !save {
+				if loaded, ok := serialized[name].(map[string]any); ok {
+					childMap = loaded
+				}
+			}
+			processRuntimeFields(v.Addr().Interface(), childMap, save)
+			if save && len(childMap) > 0 {
+				serialized[name] = childMap
+			}
+		case isSliceOfStruct:
+			// Only existing elements are restored, Construct should have
+			// created them.
+			if save {
+				childSlice := make([]any, v.Len())
+				empty := true
+				for i := range v.Len() {
+					childMap := make(map[string]any)
+					processRuntimeFields(ensurePointerToStruct(v.Index(i)), childMap, true)
+					childSlice[i] = childMap
+					empty = empty && len(childMap) == 0
+				}
+				if !empty {
+					serialized[name] = childSlice
+				}
+			} else if loaded, ok := serialized[name].([]any); ok {
+				for i := range min(v.Len(), len(loaded)) {
+					if childMap, ok := loaded[i].(map[string]any); ok {
+						processRuntimeFields(ensurePointerToStruct(v.Index(i)), childMap, false)
+					}
+				}
+			}
+		case field.Tag.Get("editable") != "":
+			// Editable fields are already serialized
+		case save:
+			if data, ok := runtimeValue(v); ok {
+				serialized[name] = data
+			}
+		default:
+			if loaded, ok := serialized[name]; ok {
+				setRuntimeValue(v, loaded)
+			}
+		}
+	}
+
+	if rs, ok := object.(RuntimeSerializable); ok {
+		if save {
+			if data := rs.SerializeRuntime(); len(data) > 0 {
+				serialized["_runtime"] = data
+			}
+		} else if loaded, ok := serialized["_runtime"].(map[string]any); ok {
+			rs.ConstructRuntime(loaded)
+		}
+	}
+}
+
+// SaveGame writes the complete state of the simulation to a file. The world
+// file itself isn't modified.
+//
+// Some state can't be saved, since it only exists as Go code:
+//   - Timers without a registered handler (see dynamic.RegisterTimerHandler).
+//     Timers with one are saved.
+//   - Events that were published but not delivered yet.
+//   - Event subscriptions made while playing (e.g. by scripts). The ones
+//     made during package initialization aren't affected.
+func SaveGame(filename string) error {
+	defer concepts.ExecutionDuration(concepts.ExecutionTrack("ecs.SaveGame"))
+	if err := dynamic.CheckSandbox("saving " + filename); err != nil {
+		return fmt.Errorf("ecs.SaveGame: %w", err)
+	}
+	Lock.Lock()
+	defer Lock.Unlock()
+
+	save := saveGame{
+		Version:    SaveGameVersion,
+		Simulation: Simulation.SerializeRuntime(),
+		Entities:   saveSnapshot(snapshotRuntime),
+	}
+	if root, ok := SourceFileIDs[0]; ok {
+		save.World = root.Source
+	}
+
+	bytes, err := yaml.Marshal(save)
+	if err != nil {
+		return fmt.Errorf("ecs.SaveGame: %w", err)
+	}
+	if err = os.WriteFile(filename, bytes, 0644); err != nil {
+		return fmt.Errorf("ecs.SaveGame: %w", err)
+	}
+	return nil
+}
+
+// LoadGame restores the state saved with SaveGame. The caller shouldn't
+// respawn anything afterwards.
+func LoadGame(filename string) error {
+	defer concepts.ExecutionDuration(concepts.ExecutionTrack("ecs.LoadGame"))
+	if err := dynamic.CheckSandbox("loading " + filename); err != nil {
+		return fmt.Errorf("ecs.LoadGame: %w", err)
+	}
+
+	bytes, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("ecs.LoadGame: reading file: %w", err)
+	}
+	var save saveGame
+	if err = yaml.Unmarshal(bytes, &save); err != nil {
+		return fmt.Errorf("ecs.LoadGame: yaml parsing: %w", err)
+	}
+	if save.Version != SaveGameVersion {
+		return fmt.Errorf("ecs.LoadGame: unsupported save game version %v (expected %v)", save.Version, SaveGameVersion)
+	}
+
+	Initialize()
+	if save.World != "" {
+		if err = Load(save.World); err != nil {
+			return fmt.Errorf("ecs.LoadGame: loading world %v: %w", save.World, err)
+		}
+		// Everything but the source files gets replaced by the saved state.
+		toDelete := make([]Entity, 0)
+		Entities.Range(func(entity uint32) {
+			e := Entity(entity)
+			if e != 0 && GetSourceFile(e) == nil {
+				toDelete = append(toDelete, e)
+			}
+		})
+		for _, e := range toDelete {
+			Delete(e)
+		}
+	}
+
+	err = rangeSnapshot(save.Entities, func(entity Entity, data map[string]any) error {
+		return loadSnapshotEntity(entity, data, snapshotRuntime)
+	})
+	if err != nil {
+		return fmt.Errorf("ecs.LoadGame: %w", err)
+	}
+	// Controllers rebuild caches, animations, etc... during precompute, so
+	// runtime state has to be restored afterwards to end up where we left off.
+	ActAllControllers(ControllerPrecompute)
+	err = rangeSnapshot(save.Entities, loadRuntimeEntity)
+	if err != nil {
+		return fmt.Errorf("ecs.LoadGame: %w", err)
+	}
+	Simulation.ConstructRuntime(save.Simulation)
+	return nil
+}
+
+func loadRuntimeEntity(entity Entity, data map[string]any) error {
+	for componentName, cid := range Types().IDs {
+		// Linked components are strings, their runtime state is restored
+		// with the entity they're linked to.
+		componentMap, ok := data[componentName].(map[string]any)
+		if !ok {
+			continue
+		}
+		if c := GetComponent(entity, cid); c != nil {
+			processRuntimeFields(c, componentMap, false)
+		}
+	}
+	return nil
+}
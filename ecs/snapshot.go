@@ -9,6 +9,9 @@ import (
 	"log"
 	"reflect"
 	"tlyakhov/gofoom/concepts"
+
+	"github.com/pierrec/xxHash/xxHash64"
+	"sigs.k8s.io/yaml"
 )
 
 type Snapshot []any
@@ -16,7 +19,10 @@ type funcFileVistor func(entity Entity, data map[string]any) error
 
 var attachedType = reflect.TypeFor[Attached]()
 
-// TODO: We need to be able to test this somehow
+// processNonSerializedFields saves or restores in-memory state that isn't part
+// of a component's Serialize/Construct (e.g. caches), for undo/redo. See
+// SerializeComponent and ConstructComponent, which are also how this is tested
+// across all component types.
 func processNonSerializedFields(object any, serialized map[string]any, save bool) {
 	if object == nil {
 		return
@@ -75,21 +81,65 @@ func processNonSerializedFields(object any, serialized map[string]any, save bool
 			if save {
 				serialized[name] = v.Interface()
 			} else if loaded, ok := serialized[name]; ok {
-				v.Set(reflect.ValueOf(loaded))
+				if loaded == nil {
+					// Nil interfaces don't have a type
+					v.SetZero()
+				} else {
+					v.Set(reflect.ValueOf(loaded))
+				}
 			}
 		}
 	}
 }
 
-func serializeEntity(entity Entity, visited map[uint64]Entity, includeNonSerialized bool) map[string]any {
+// SerializeComponent returns the serialized data for a component. If
+// includeNonSerialized is set, in-memory state is included too, like undo/redo
+// snapshots (see processNonSerializedFields).
+func SerializeComponent(c Component, includeNonSerialized bool) map[string]any {
+	result := c.Serialize()
+	if includeNonSerialized {
+		processNonSerializedFields(c, result, true)
+	}
+	return result
+}
+
+// ConstructComponent initializes a component from data returned by
+// SerializeComponent.
+func ConstructComponent(c Component, data map[string]any, includeNonSerialized bool) {
+	c.Construct(data)
+	if includeNonSerialized {
+		processNonSerializedFields(c, data, false)
+	}
+}
+
+// snapshotFlags control what gets included when serializing entities.
+type snapshotFlags int
+
+const (
+	// Include components that aren't normally saved, external entities, and
+	// in-memory state (see processNonSerializedFields). Used for undo/redo.
+	snapshotNonSerialized snapshotFlags = 1 << iota
+	// Include components that aren't normally saved, external entities, and
+	// runtime state (see processRuntimeFields). Used for save games.
+	snapshotRuntime
+)
+
+func serializeEntity(entity Entity, visited map[uint64]Entity, flags snapshotFlags) map[string]any {
 	serialized := make(map[string]any)
 	serialized["Entity"] = entity.Serialize()
 	sid, local := entity.SourceID(), entity.Local()
+	// Are we serializing everything, or just what would be saved to a world?
+	full := flags&(snapshotNonSerialized|snapshotRuntime) != 0
 	for _, component := range rows[sid][int(local)] {
-		if component == nil || (!includeNonSerialized && component.Base().Flags&ComponentNoSave != 0) {
+		if component == nil || (!full && component.Base().Flags&ComponentNoSave != 0) {
 			continue
 		}
 		cid := component.ComponentID()
+		if flags&snapshotRuntime != 0 && (cid == SourceFileCID || isTransient(cid)) {
+			// Source files are loaded from the world, and transient
+			// components will be recreated as needed.
+			continue
+		}
 		hash := (uint64(component.Base().indexInArena) << 16) | (uint64(cid) & 0xFFFF)
 		arena := Types().ArenaPlaceholders[cid]
 		snapshotID := arena.Type().String()
@@ -103,7 +153,7 @@ func serializeEntity(entity Entity, visited map[uint64]Entity, includeNonSeriali
 			}
 		}
 
-		if !includeNonSerialized && component.Base().IsExternal() {
+		if !full && component.Base().IsExternal() {
 			// Just pick one
 			// TODO: This has a code smell. Should there be a particular way
 			// to pick an entity ID to reference when saving?
@@ -111,9 +161,9 @@ func serializeEntity(entity Entity, visited map[uint64]Entity, includeNonSeriali
 			continue
 		}
 
-		componentMap := component.Serialize()
-		if includeNonSerialized {
-			processNonSerializedFields(component, componentMap, true)
+		componentMap := SerializeComponent(component, flags&snapshotNonSerialized != 0)
+		if flags&snapshotRuntime != 0 {
+			processRuntimeFields(component, componentMap, true)
 		}
 		delete(componentMap, "Entities")
 		serialized[snapshotID] = componentMap
@@ -150,6 +200,9 @@ func visitSnapshotEntity(snapshotMap map[string]any, fn funcFileVistor) error {
 
 func rangeSnapshot(snapshot Snapshot, fn funcFileVistor) error {
 	for _, snapshotMap := range snapshot {
+		if isWorldHeader(snapshotMap) {
+			continue
+		}
 		snapshotEntity := snapshotMap.(map[string]any)
 		if snapshotEntity == nil {
 			log.Printf("ecs.rangeSnapshot: snapshot array element should be a map")
@@ -162,8 +215,19 @@ func rangeSnapshot(snapshot Snapshot, fn funcFileVistor) error {
 	return nil
 }
 
+func snapshotFlagsFromBool(includeNonSerialized bool) snapshotFlags {
+	if includeNonSerialized {
+		return snapshotNonSerialized
+	}
+	return 0
+}
+
 func SerializeEntity(entity Entity, includeNonSerialized bool) map[string]any {
-	return serializeEntity(entity, nil, includeNonSerialized)
+	// Like saving the world, this brings prefab overrides up to date.
+	if pi := GetPrefabInstance(entity); pi != nil {
+		pi.UpdateOverrides()
+	}
+	return serializeEntity(entity, nil, snapshotFlagsFromBool(includeNonSerialized))
 }
 
 func SaveSnapshot(includeNonSerialized bool) Snapshot {
@@ -171,6 +235,11 @@ func SaveSnapshot(includeNonSerialized bool) Snapshot {
 	Lock.Lock()
 	defer Lock.Unlock()
 
+	return saveSnapshot(snapshotFlagsFromBool(includeNonSerialized))
+}
+
+func saveSnapshot(flags snapshotFlags) Snapshot {
+	UpdatePrefabOverrides()
 	snapshot := Snapshot{}
 	savedComponents := make(map[uint64]Entity)
 
@@ -179,10 +248,10 @@ func SaveSnapshot(includeNonSerialized bool) Snapshot {
 		if e == 0 {
 			return
 		}
-		if !includeNonSerialized && e.IsExternal() {
+		if flags == 0 && e.IsExternal() {
 			return
 		}
-		serialized := serializeEntity(e, savedComponents, includeNonSerialized)
+		serialized := serializeEntity(e, savedComponents, flags)
 		if len(serialized) == 0 {
 			return
 		}
@@ -191,45 +260,64 @@ func SaveSnapshot(includeNonSerialized bool) Snapshot {
 	return snapshot
 }
 
-func LoadSnapshot(snapshot Snapshot) error {
-	defer concepts.ExecutionDuration(concepts.ExecutionTrack("ecs.LoadSnapshot"))
+func loadSnapshotEntity(entity Entity, data map[string]any, flags snapshotFlags) error {
+	Entities.Set(uint32(entity))
 
-	Initialize()
-	err := rangeSnapshot(snapshot, func(entity Entity, data map[string]any) error {
-		Entities.Set(uint32(entity))
+	for componentName, cid := range Types().IDs {
+		componentData := data[componentName]
+		if componentData == nil {
+			continue
+		}
+		if flags&snapshotRuntime != 0 && cid == SourceFileCID {
+			// Save games don't include source files, but check just in case.
+			continue
+		}
 
-		for componentName, cid := range Types().IDs {
-			componentData := data[componentName]
-			if componentData == nil {
+		if linkedEntitySerialized, ok := componentData.(string); ok {
+			linkedEntity, _ := ParseEntity(linkedEntitySerialized)
+			if linkedEntity == 0 {
 				continue
 			}
-
-			if linkedEntitySerialized, ok := componentData.(string); ok {
-				linkedEntity, _ := ParseEntity(linkedEntitySerialized)
-				if linkedEntity == 0 {
-					continue
-				}
-				c := GetComponent(linkedEntity, cid)
-				if c != nil {
-					attach(entity, &c, cid)
-				}
-			} else {
-				componentMap := componentData.(map[string]any)
-				var attached Component
-				attach(entity, &attached, cid)
-				if attached.Base().Attachments == 1 {
-					attached.Construct(componentMap)
-					processNonSerializedFields(attached, componentMap, false)
-				}
-				if cid == SourceFileCID {
-					file := attached.(*SourceFile)
-					SourceFileNames[file.Source] = file
-					SourceFileIDs[file.ID] = file
-				}
+			c := GetComponent(linkedEntity, cid)
+			if c != nil {
+				attach(entity, &c, cid)
+			}
+		} else {
+			componentMap := componentData.(map[string]any)
+			var attached Component
+			attach(entity, &attached, cid)
+			if attached.Base().Attachments == 1 {
+				ConstructComponent(attached, componentMap, flags&snapshotNonSerialized != 0)
+				// Runtime fields are restored later, see LoadGame.
+			}
+			if cid == SourceFileCID {
+				file := attached.(*SourceFile)
+				SourceFileNames[file.Source] = file
+				SourceFileIDs[file.ID] = file
 			}
 		}
-		return nil
+	}
+	return nil
+}
+
+func LoadSnapshot(snapshot Snapshot) error {
+	defer concepts.ExecutionDuration(concepts.ExecutionTrack("ecs.LoadSnapshot"))
+
+	Initialize()
+	err := rangeSnapshot(snapshot, func(entity Entity, data map[string]any) error {
+		return loadSnapshotEntity(entity, data, snapshotNonSerialized)
 	})
 	ActAllControllers(ControllerPrecompute)
 	return err
 }
+
+// StateHash returns a hash of the serialized state of every entity. Useful for
+// checking whether two simulations ended up in the same place (e.g. demo
+// playback)
+func StateHash() (uint64, error) {
+	bytes, err := yaml.Marshal(SaveSnapshot(false))
+	if err != nil {
+		return 0, fmt.Errorf("ecs.StateHash: %w", err)
+	}
+	return xxHash64.Checksum(bytes, 0xCAFE), nil
+}
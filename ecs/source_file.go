@@ -12,10 +12,11 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"tlyakhov/gofoom/dynamic"
 
 	"github.com/pierrec/xxHash/xxHash32"
 	"github.com/spf13/cast"
-	"sigs.k8s.io/yaml"
 )
 
 type SourceFileHash uint32
@@ -28,12 +29,38 @@ type SourceFile struct {
 	Loaded     bool
 	References int
 
+	// Streamed files are only loaded when the player is within StreamDistance
+	// portals of one of the Anchors (sectors outside this file), and
+	// unloaded again once they move away. Entity IDs are stable, because the
+	// file keeps its ID while it's unloaded.
+	Streamed       bool        `editable:"Streamed"`
+	StreamDistance int         `editable:"Stream Distance"`
+	Anchors        EntityTable `editable:"Stream Anchors"`
+
 	serializedContents Snapshot
 	children           EntityTable
 	loadedHash         SourceFileHash
 	workingDir         string
+	// Where to read a streamed file from, and the result of a background
+	// read if there's one in progress.
+	streamPath string
+	streaming  *streamedRead
+}
+
+type streamedRead struct {
+	done     atomic.Bool
+	contents Snapshot
+	err      error
 }
 
+// StreamIncludes can be turned off to load streamed files right away (e.g.
+// in the editor).
+var StreamIncludes = true
+
+// StreamInBackground can be turned off to read streamed files on the calling
+// goroutine, which keeps headless simulations deterministic.
+var StreamInBackground = true
+
 var SourceFileCID ComponentID
 
 func init() {
@@ -73,26 +100,29 @@ func (file *SourceFile) Hash(forSerialization bool) SourceFileHash {
 }
 
 func (file *SourceFile) read(path string) error {
-	// TODO: Streaming or lazy loads?
-	bytes, err := os.ReadFile(path)
+	if err := dynamic.CheckSandbox("reading " + path); err != nil {
+		return err
+	}
+	var err error
+	file.serializedContents, err = readWorldFile(path)
+	return err
+}
 
+// readWorldFile reads a file and upgrades it to the current component
+// versions. Like ReadSnapshotFile, it's safe to call from any goroutine.
+func readWorldFile(path string) (Snapshot, error) {
+	contents, err := ReadSnapshotFile(path)
 	if err != nil {
-		return fmt.Errorf("SourceFile.read: reading file: %w", err)
+		return nil, err
 	}
-
-	contents := string(bytes)
-
-	var yamlTree any
-	if err := yaml.Unmarshal([]byte(contents), &yamlTree); err != nil {
-		return fmt.Errorf("SourceFile.read: yaml parsing: %w", err)
+	contents, report, err := MigrateSnapshot(contents)
+	if err != nil {
+		return nil, fmt.Errorf("SourceFile.read: %v: %w", path, err)
 	}
-
-	var ok bool
-	if file.serializedContents, ok = yamlTree.([]any); !ok || file.serializedContents == nil {
-		return fmt.Errorf("SourceFile.read: YAML root must be an array")
+	for _, am := range report.Applied {
+		log.Printf("SourceFile.read: %v: migrated %v", path, am)
 	}
-
-	return nil
+	return contents, nil
 }
 
 var sourceFileTypeName = reflect.TypeFor[SourceFile]().String()
@@ -122,23 +152,25 @@ func (file *SourceFile) readAndMapNestedFiles(parent *SourceFile) error {
 
 	log.Printf("SourceFile.readAndMapNestedFiles - reading %v(%v): %v", file.Source, file.ID, file.workingDir)
 
+	path := file.Source
+	if parent != nil {
+		path = filepath.Join(parent.workingDir, path)
+	}
+	if file.Streamed && StreamIncludes && parent != nil && file.serializedContents == nil {
+		// Streamed files get mapped now, so that their IDs are reserved, but
+		// they're read later. See StreamIn.
+		file.streamPath = path
+		file.mapID()
+		return nil
+	}
+
 	// Read in the file if we need to
 	if file.serializedContents == nil {
-		path := file.Source
-		if parent != nil {
-			path = filepath.Join(parent.workingDir, path)
-		}
 		if err := file.read(path); err != nil {
 			return err
 		}
 	}
-
-	if file.Entity != 1 {
-		file.ID = NextFreeEntitySourceID()
-	}
-	file.References = 1
-	SourceFileNames[file.Source] = file
-	SourceFileIDs[file.ID] = file
+	file.mapID()
 
 	err := rangeSnapshot(file.serializedContents, func(entity Entity, data map[string]any) error {
 		// Sanity check:
@@ -179,6 +211,12 @@ func (file *SourceFile) readAndMapNestedFiles(parent *SourceFile) error {
 		attach(entity, &a, SourceFileCID)
 		// the attach method will change where *a is.
 		nestedFile = a.(*SourceFile)
+		ModifyComponentRelationEntities(nestedFile, func(r *Relation, e Entity) Entity {
+			if e != 0 && !e.IsExternal() {
+				return e.WithFileID(file.ID)
+			}
+			return e
+		})
 		nestedFile.setWorkingDir(file.workingDir)
 		nestedFile.readAndMapNestedFiles(file)
 		file.children.Set(nestedFile.Entity)
@@ -187,6 +225,17 @@ func (file *SourceFile) readAndMapNestedFiles(parent *SourceFile) error {
 	return err
 }
 
+// mapID assigns an ID to this file, unless it already has one (e.g. a
+// streamed file being loaded again).
+func (file *SourceFile) mapID() {
+	if file.Entity != 1 && SourceFileIDs[file.ID] != file {
+		file.ID = NextFreeEntitySourceID()
+	}
+	file.References = 1
+	SourceFileNames[file.Source] = file
+	SourceFileIDs[file.ID] = file
+}
+
 func (file *SourceFile) setWorkingDir(parent string) {
 	src := path.Dir(file.Source)
 	if filepath.IsAbs(src) {
@@ -234,7 +283,8 @@ func (file *SourceFile) loadEntities() error {
 		if nestedFile == nil {
 			continue
 		}
-		if nestedFile.Loaded {
+		if nestedFile.Loaded || nestedFile.serializedContents == nil {
+			// Already loaded, or streamed
 			continue
 		}
 		nestedFile.loadEntities()
@@ -292,8 +342,20 @@ func (file *SourceFile) loadEntities() error {
 }
 
 func (file *SourceFile) Unload() {
+	file.unloadEntities()
+	file.streaming = nil
+	// Streamed files are mapped even if they're not loaded.
+	if file.ID != 0 && SourceFileIDs[file.ID] == file {
+		delete(SourceFileNames, file.Source)
+		delete(SourceFileIDs, file.ID)
+	}
+}
+
+// unloadEntities deletes all the entities from this file (and any nested
+// files nobody else refers to), but leaves the ID mapped.
+func (file *SourceFile) unloadEntities() bool {
 	if file.ID == 0 || !file.Loaded {
-		return
+		return false
 	}
 
 	// First unload any children
@@ -322,10 +384,88 @@ func (file *SourceFile) Unload() {
 		Delete(e)
 	}
 
-	delete(SourceFileNames, file.Source)
-	delete(SourceFileIDs, file.ID)
 	file.Loaded = false
 	file.serializedContents = nil
+	return true
+}
+
+// StreamIn starts reading a streamed file, unless it's already loaded or being
+// read. Call FinishStreaming to create the entities once the read is done.
+func (file *SourceFile) StreamIn() {
+	if !file.Streamed || file.Loaded || file.streaming != nil || file.streamPath == "" {
+		return
+	}
+	read := &streamedRead{}
+	file.streaming = read
+	// The sandbox is checked here rather than in readWorldFile, since the read
+	// can happen on another goroutine.
+	if err := dynamic.CheckSandbox("streaming " + file.streamPath); err != nil {
+		read.err = err
+		read.done.Store(true)
+		return
+	}
+	load := func() {
+		read.contents, read.err = readWorldFile(file.streamPath)
+		read.done.Store(true)
+	}
+	if StreamInBackground {
+		go load()
+	} else {
+		load()
+	}
+}
+
+// IsStreaming checks whether a streamed file is being read.
+func (file *SourceFile) IsStreaming() bool {
+	return file.streaming != nil
+}
+
+// FinishStreaming loads the entities of a streamed file once the read started
+// by StreamIn is done. It returns true if the file was loaded by this call.
+// This has to be called from the simulation goroutine.
+func (file *SourceFile) FinishStreaming() (bool, error) {
+	read := file.streaming
+	if read == nil || !read.done.Load() {
+		return false, nil
+	}
+	file.streaming = nil
+	if read.err != nil {
+		return false, fmt.Errorf("SourceFile.FinishStreaming: %v: %w", file.Source, read.err)
+	}
+	if file.Loaded {
+		return false, nil
+	}
+	file.serializedContents = read.contents
+	if err := file.readAndMapNestedFiles(nil); err != nil {
+		file.serializedContents = nil
+		return false, fmt.Errorf("SourceFile.FinishStreaming: %w", err)
+	}
+	err := file.loadEntities()
+	file.serializedContents = nil
+	// Portals into this file need to be recalculated as well.
+	for _, e := range file.Anchors {
+		if e != 0 {
+			ActAllControllersOneEntity(e, ControllerPrecompute)
+		}
+	}
+	PrecomputeChanged()
+	if err != nil {
+		return true, fmt.Errorf("SourceFile.FinishStreaming: %w", err)
+	}
+	return true, nil
+}
+
+// StreamOut deletes the entities of a streamed file. The file keeps its ID, so
+// that entities have the same IDs if it's streamed in again.
+func (file *SourceFile) StreamOut() {
+	if !file.Streamed || !file.unloadEntities() {
+		return
+	}
+	for _, e := range file.Anchors {
+		if e != 0 {
+			ActAllControllersOneEntity(e, ControllerPrecompute)
+		}
+	}
 }
 
 func (file *SourceFile) String() string {
@@ -345,6 +485,11 @@ func (file *SourceFile) Construct(data map[string]any) {
 	file.References = 0
 	file.serializedContents = nil
 	file.children = EntityTable{}
+	file.Streamed = false
+	file.StreamDistance = 2
+	file.Anchors = nil
+	file.streamPath = ""
+	file.streaming = nil
 
 	if data == nil {
 		return
@@ -359,6 +504,15 @@ func (file *SourceFile) Construct(data map[string]any) {
 	if v, ok := data["Hash"]; ok {
 		file.loadedHash = SourceFileHash(cast.ToUint32(v))
 	}
+	if v, ok := data["Streamed"]; ok {
+		file.Streamed = cast.ToBool(v)
+	}
+	if v, ok := data["StreamDistance"]; ok {
+		file.StreamDistance = cast.ToInt(v)
+	}
+	if v, ok := data["Anchors"]; ok {
+		file.Anchors = ParseEntityTable(v, true)
+	}
 }
 
 func (file *SourceFile) Serialize() map[string]any {
@@ -367,6 +521,13 @@ func (file *SourceFile) Serialize() map[string]any {
 	result["Source"] = file.Source
 	result["ID"] = file.ID
 	result["Hash"] = "0x" + strconv.FormatUint(uint64(file.Hash(true)), 16) // To handle file moves and human readers
+	if file.Streamed {
+		result["Streamed"] = true
+		result["StreamDistance"] = file.StreamDistance
+		if len(file.Anchors) != 0 {
+			result["Anchors"] = file.Anchors.Serialize()
+		}
+	}
 
 	return result
 }
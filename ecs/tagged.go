@@ -0,0 +1,92 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// Tagged labels an entity with any number of tags, for finding groups of
+// entities (e.g. "reactor-room"). Unlike names, tags don't need to be unique.
+// See EntitiesByTag and FindEntities.
+type Tagged struct {
+	Attached `editable:"^"`
+	Tags     []string `editable:"Tags"`
+}
+
+var TaggedCID ComponentID
+
+func init() {
+	TaggedCID = RegisterComponent(&Arena[Tagged, *Tagged]{})
+	Types().Subscribe(TaggedCID, ChangeAttached|ChangeDetached|ChangeModified, entityIndexChanged)
+}
+
+func (*Tagged) ComponentID() ComponentID {
+	return TaggedCID
+}
+
+func GetTagged(e Entity) *Tagged {
+	if asserted, ok := GetComponent(e, TaggedCID).(*Tagged); ok {
+		return asserted
+	}
+	return nil
+}
+
+func (t *Tagged) String() string {
+	return "Tags: " + strings.Join(t.Tags, ", ")
+}
+
+// Has checks whether the entity is tagged with tag.
+func (t *Tagged) Has(tag string) bool {
+	return slices.Contains(t.Tags, tag)
+}
+
+// Add tags the entity, if it isn't already, and updates the index.
+func (t *Tagged) Add(tag string) {
+	if t.add(tag) {
+		markEntitiesModified(t)
+	}
+}
+
+func (t *Tagged) add(tag string) bool {
+	if tag == "" || t.Has(tag) {
+		return false
+	}
+	t.Tags = append(t.Tags, tag)
+	return true
+}
+
+// Remove untags the entity and updates the index.
+func (t *Tagged) Remove(tag string) {
+	if t.Has(tag) {
+		t.Tags = slices.DeleteFunc(t.Tags, func(s string) bool { return s == tag })
+		markEntitiesModified(t)
+	}
+}
+
+func (t *Tagged) Construct(data map[string]any) {
+	t.Attached.Construct(data)
+
+	t.Tags = nil
+
+	if data == nil {
+		return
+	}
+	if v, ok := data["Tags"]; ok {
+		for _, tag := range cast.ToStringSlice(v) {
+			t.add(strings.TrimSpace(tag))
+		}
+	}
+}
+
+func (t *Tagged) Serialize() map[string]any {
+	result := t.Attached.Serialize()
+	if len(t.Tags) > 0 {
+		result["Tags"] = t.Tags
+	}
+	return result
+}
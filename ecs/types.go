@@ -18,6 +18,8 @@ type controllerMetadata struct {
 	Constructor func() Controller
 	Type        reflect.Type
 	Priority    int
+	ComponentID ComponentID
+	Access      *ControllerAccess
 }
 type typeMetadata struct {
 	ArenaIndexes         map[string]int
@@ -26,6 +28,9 @@ type typeMetadata struct {
 	ArenaPlaceholders    []ComponentArena
 	nextFreeComponent    uint32
 	Controllers          []controllerMetadata
+	ControllerGroups     []controllerGroup
+	Subscriptions        [][]changeSubscription
+	Migrations           map[string][]Migration
 	ExprEnv              map[string]any
 	InterpSymbols        interp.Exports
 	lock                 sync.RWMutex
@@ -0,0 +1,220 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ProblemKind identifies the type of a Problem in machine-readable output.
+type ProblemKind string
+
+const (
+	// A relation refers to an entity that doesn't exist.
+	ProblemBrokenReference ProblemKind = "broken-reference"
+	// An entity is hidden in the editor and nothing refers to it, so there's
+	// no way to get to it.
+	ProblemUnreachableEntity ProblemKind = "unreachable-entity"
+)
+
+// Problem is an issue with a world found by Validate.
+type Problem struct {
+	Kind      ProblemKind `json:"kind"`
+	Entity    string      `json:"entity"`
+	Component string      `json:"component,omitempty"`
+	Field     string      `json:"field,omitempty"`
+	Message   string      `json:"message"`
+	// Fixed is set if the problem was repaired.
+	Fixed bool `json:"fixed"`
+}
+
+func (p *Problem) String() string {
+	result := fmt.Sprintf("%v: %v", p.Kind, p.Entity)
+	if p.Component != "" {
+		result += " " + p.Component
+	}
+	if p.Field != "" {
+		result += "." + p.Field
+	}
+	result += ": " + p.Message
+	if p.Fixed {
+		result += " (fixed)"
+	}
+	return result
+}
+
+// NewProblem creates a problem for a component. c can be nil for problems
+// with the entity as a whole.
+func NewProblem(kind ProblemKind, e Entity, c Component, field string, fixed bool, format string, args ...any) Problem {
+	p := Problem{
+		Kind:    kind,
+		Entity:  e.Serialize(),
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+		Fixed:   fixed,
+	}
+	if c != nil {
+		p.Component = Types().ArenaPlaceholders[c.ComponentID()].Type().String()
+	}
+	return p
+}
+
+// isDangling checks whether a reference points to an entity that doesn't
+// exist. References into included files that aren't loaded (e.g. streamed
+// out) are assumed to be fine.
+func isDangling(e Entity) bool {
+	if e == 0 || Entities.Contains(uint32(e)) {
+		return false
+	}
+	if e.IsExternal() {
+		if file, ok := SourceFileIDs[e.SourceID()]; ok && !file.Loaded {
+			return false
+		}
+	}
+	return true
+}
+
+// validateRelation reports (and optionally removes) any dangling entities in
+// a relation. Returns the entities the relation refers to.
+func validateRelation(r *Relation, e Entity, c Component, fix bool, problems *[]Problem) []Entity {
+	referenced := make([]Entity, 0)
+	check := func(target Entity) bool {
+		if !isDangling(target) {
+			if target != 0 {
+				referenced = append(referenced, target)
+			}
+			return false
+		}
+		*problems = append(*problems, NewProblem(ProblemBrokenReference, e, c, r.Name, fix,
+			"refers to %v, which doesn't exist", target))
+		return true
+	}
+
+	switch r.Type {
+	case RelationOne:
+		if check(r.One) && fix {
+			r.One = 0
+			r.Update()
+		}
+	case RelationSet:
+		for target := range r.Set {
+			if check(target) && fix {
+				r.Set.Delete(target)
+			}
+		}
+	case RelationSlice:
+		valid := make([]Entity, 0, len(r.Slice))
+		for _, target := range r.Slice {
+			if !check(target) {
+				valid = append(valid, target)
+			}
+		}
+		if fix && len(valid) != len(r.Slice) {
+			r.Slice = valid
+			r.Update()
+		}
+	case RelationTable:
+		// Deleting from the table moves things around, so collect these first.
+		dangling := make([]Entity, 0)
+		for _, target := range r.Table {
+			if check(target) {
+				dangling = append(dangling, target)
+			}
+		}
+		if fix && len(dangling) > 0 {
+			for _, target := range dangling {
+				r.Table.Delete(target)
+			}
+			r.Update()
+		}
+	case RelationMap:
+		for _, key := range r.Value.MapKeys() {
+			if check(key.Interface().(Entity)) && fix {
+				r.Value.SetMapIndex(key, reflect.Value{})
+			}
+		}
+	}
+	return referenced
+}
+
+// isHidden checks whether none of an entity's saved components are visible in
+// the editor. Returns false if the entity has nothing to save.
+func isHidden(e Entity) bool {
+	hidden := false
+	for _, c := range AllComponents(e) {
+		if c == nil || c.Base().Flags&ComponentNoSave != 0 {
+			continue
+		}
+		if c.Base().Flags&ComponentHideEntityInEditor == 0 {
+			return false
+		}
+		hidden = true
+	}
+	return hidden
+}
+
+// Validate checks the entities in the world (not in included files) for
+// broken references and unreachable entities. If fix is set, broken
+// references are removed and unreachable entities are deleted. Other packages
+// check for problems specific to their components, see
+// controllers.ValidateWorld.
+func Validate(fix bool) []Problem {
+	problems := make([]Problem, 0)
+	referenced := make(map[Entity]struct{})
+	visited := make(map[Component]struct{})
+
+	Entities.Range(func(entity uint32) {
+		e := Entity(entity)
+		if e == 0 || e.IsExternal() {
+			return
+		}
+		for _, c := range AllComponents(e) {
+			if c == nil || c.Base().Flags&ComponentNoSave != 0 {
+				continue
+			}
+			// Components attached to more than one entity are only
+			// checked once.
+			if _, ok := visited[c]; ok {
+				continue
+			}
+			visited[c] = struct{}{}
+			if c.Base().Attachments > 1 {
+				for _, shared := range c.Base().Entities {
+					if shared != 0 {
+						referenced[shared] = struct{}{}
+					}
+				}
+			}
+			RangeComponentRelations(c, func(r *Relation) bool {
+				for _, target := range validateRelation(r, e, c, fix, &problems) {
+					if target != e {
+						referenced[target] = struct{}{}
+					}
+				}
+				return true
+			})
+		}
+	})
+
+	unreachable := make([]Entity, 0)
+	Entities.Range(func(entity uint32) {
+		e := Entity(entity)
+		if e <= 1 || e.IsExternal() {
+			return
+		}
+		if _, ok := referenced[e]; ok || !isHidden(e) {
+			return
+		}
+		problems = append(problems, NewProblem(ProblemUnreachableEntity, e, nil, "", fix,
+			"is hidden in the editor and nothing refers to it"))
+		unreachable = append(unreachable, e)
+	})
+	if fix {
+		for _, e := range unreachable {
+			Delete(e)
+		}
+	}
+	return problems
+}
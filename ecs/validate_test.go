@@ -0,0 +1,54 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package ecs
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	Initialize()
+	source := NewEntity()
+	// Hidden, but referenced, so it's reachable.
+	NewAttachedComponent(source, NamedCID).Base().Flags |= ComponentHideEntityInEditor
+	linker := NewEntity()
+	deleted := NewEntity()
+	linked := NewAttachedComponent(linker, LinkedCID).(*Linked)
+	linked.Sources = []Entity{source, deleted}
+	hidden := NewEntity()
+	NewAttachedComponent(hidden, NamedCID).Base().Flags |= ComponentHideEntityInEditor
+	Delete(deleted)
+
+	problems := Validate(false)
+	if len(problems) != 2 {
+		t.Fatalf("Expected 2 problems, got %v", problems)
+	}
+	kinds := map[ProblemKind]Problem{}
+	for _, p := range problems {
+		kinds[p.Kind] = p
+	}
+	if p := kinds[ProblemBrokenReference]; p.Entity != linker.Serialize() || p.Field != "Sources" || p.Fixed {
+		t.Errorf("Expected broken reference in %v Sources, got %v", linker, p)
+	}
+	if p := kinds[ProblemUnreachableEntity]; p.Entity != hidden.Serialize() {
+		t.Errorf("Expected %v to be unreachable, got %v", hidden, p)
+	}
+	if len(linked.Sources) != 2 || !Entities.Contains(uint32(hidden)) {
+		t.Errorf("Nothing should change without fix")
+	}
+
+	problems = Validate(true)
+	for _, p := range problems {
+		if !p.Fixed {
+			t.Errorf("Expected problem to be fixed: %v", p.String())
+		}
+	}
+	if len(linked.Sources) != 1 || linked.Sources[0] != source {
+		t.Errorf("Expected broken reference to be removed, got %v", linked.Sources)
+	}
+	if Entities.Contains(uint32(hidden)) {
+		t.Errorf("Expected unreachable entity to be deleted")
+	}
+	if problems = Validate(false); len(problems) != 0 {
+		t.Errorf("Expected no problems after fixing, got %v", problems)
+	}
+}
@@ -21,6 +21,12 @@ func (a *AddComponent) Activate() {
 		ecs.NewAttachedComponent(entity, a.ID)
 		a.FlushEntityImage(entity)
 	}
-	ecs.ActAllControllers(ecs.ControllerPrecompute)
+	if a.ID == core.SectorCID || a.ID == ecs.LinkedCID {
+		// These affect other entities too (e.g. portals between adjacent
+		// sectors, linked components), which PrecomputeChanged misses.
+		ecs.ActAllControllers(ecs.ControllerPrecompute)
+	} else {
+		ecs.PrecomputeChanged()
+	}
 	a.ActionFinished(false, true, a.ID == core.SectorCID)
 }
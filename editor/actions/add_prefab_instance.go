@@ -0,0 +1,37 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package actions
+
+import (
+	"fmt"
+	"tlyakhov/gofoom/ecs"
+)
+
+// AddPrefabInstance places a new instance of a prefab file, see
+// ecs.PrefabInstance. If the prefab's root has a body, it's placed like
+// AddEntity.
+type AddPrefabInstance struct {
+	AddEntity
+
+	Source string
+}
+
+func (a *AddPrefabInstance) Activate() {
+	pi := &ecs.PrefabInstance{}
+	pi.Construct(nil)
+	pi.Source = a.Source
+	a.Components = ecs.ComponentTable{pi}
+	a.AddEntity.Activate()
+
+	if err := ecs.GetPrefabInstance(a.Entity).Instantiate(); err != nil {
+		a.Alert(fmt.Sprintf("Error instancing prefab %v: %v", a.Source, err))
+		a.Cancel()
+		return
+	}
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+}
+
+func (a *AddPrefabInstance) Status() string {
+	return "Click to place prefab"
+}
@@ -0,0 +1,34 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package actions
+
+import (
+	"fmt"
+	"tlyakhov/gofoom/ecs"
+	"tlyakhov/gofoom/editor/state"
+)
+
+// ApplyPrefab writes an instance's overrides back to its prefab file. This
+// can't be undone, since the prefab is saved right away.
+type ApplyPrefab struct {
+	state.Action
+
+	Entity ecs.Entity
+}
+
+func (a *ApplyPrefab) Activate() {
+	pi := ecs.GetPrefabInstance(a.Entity)
+	if pi == nil {
+		a.ActionFinished(true, false, false)
+		return
+	}
+	if err := pi.ApplyOverrides(); err != nil {
+		a.Alert(fmt.Sprintf("Error applying overrides to prefab %v: %v", pi.Source, err))
+		a.ActionFinished(true, false, false)
+		return
+	}
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+	a.State().Modified = true
+	a.ActionFinished(false, true, false)
+}
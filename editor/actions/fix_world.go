@@ -0,0 +1,23 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package actions
+
+import (
+	"log"
+	"tlyakhov/gofoom/controllers"
+	"tlyakhov/gofoom/editor/state"
+)
+
+// FixWorld repairs whatever problems controllers.ValidateWorld can.
+type FixWorld struct {
+	state.Action
+}
+
+func (a *FixWorld) Activate() {
+	for _, p := range controllers.ValidateWorld(true) {
+		log.Printf("FixWorld: %v", p.String())
+	}
+	a.State().Modified = true
+	a.ActionFinished(false, true, false)
+}
@@ -0,0 +1,40 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package actions
+
+import (
+	"fmt"
+	"tlyakhov/gofoom/components/selection"
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/controllers"
+	"tlyakhov/gofoom/editor/state"
+)
+
+// ImportWorld merges the entities from another world file into this one,
+// with new entity IDs. Like other actions, it's undone by loading the
+// snapshot Editor.Act takes beforehand. A failed import is rolled back, so
+// it's canceled rather than leaving anything to undo.
+type ImportWorld struct {
+	state.Action
+
+	Filename string
+	Offset   concepts.Vector3
+}
+
+func (a *ImportWorld) Activate() {
+	imported, err := controllers.ImportWorld(a.Filename, &a.Offset)
+	if err != nil {
+		a.Alert(fmt.Sprintf("Error importing world: %v", err))
+		a.ActionFinished(true, false, false)
+		return
+	}
+
+	selected := selection.NewSelection()
+	for _, e := range imported {
+		selected.Add(selection.SelectableFromEntity(e))
+	}
+	a.SetSelection(true, selected)
+	a.State().Modified = true
+	a.ActionFinished(false, true, true)
+}
@@ -7,7 +7,6 @@ import (
 	"log"
 	"reflect"
 
-	"tlyakhov/gofoom/components/audio"
 	"tlyakhov/gofoom/components/core"
 	"tlyakhov/gofoom/components/materials"
 	"tlyakhov/gofoom/dynamic"
@@ -30,27 +29,25 @@ func (a *SetProperty) AssignAll(v reflect.Value) {
 }
 
 func (a *SetProperty) FireHooks() {
-	// TODO: Optimize this by remembering visited parents to avoid firing these
-	//       multiple times for the same selection.
-	// TODO: Instead of this giant switch, we should use the "mark dirty" and
-	//       precompute pattern.
 	for _, v := range a.Values {
 		if v.Entity.IsExternal() {
 			continue
 		}
 		a.FlushEntityImage(v.Entity)
+		// Controllers and subscribers take care of most changes, see
+		// ecs.PrecomputeChanged below.
+		ecs.MarkModified(v.Component, v.Entity)
 		switch target := v.Parent().(type) {
-		case *materials.Image:
-			target.MarkDirty()
-			ecs.ActAllControllersOneEntity(v.Entity, ecs.ControllerPrecompute)
-		case *ecs.Linked, *audio.Sound, *core.Script, *core.SectorPlane, *core.Sector:
-			ecs.ActAllControllersOneEntity(v.Entity, ecs.ControllerPrecompute)
-			// TODO: use a nicer source code editor for script properties.
 		case *ecs.SourceFile:
 			if target.Loaded {
 				target.Unload()
 			}
 			target.Load()
+		case *ecs.PrefabInstance:
+			target.Uninstantiate()
+			if err := target.Instantiate(); err != nil {
+				log.Printf("SetProperty.FireHooks: %v", err)
+			}
 		case dynamic.Dynamic:
 			target.ResetToSpawn()
 			target.Precompute()
@@ -75,6 +72,7 @@ func (a *SetProperty) FireHooks() {
 			log.Printf("SetProperty.FireHooks for *core.SectorSegment: %v", a.Name)
 		}
 	}
+	ecs.PrecomputeChanged()
 }
 
 func (a *SetProperty) Activate() {
@@ -20,6 +20,7 @@ import (
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/storage"
@@ -296,6 +297,44 @@ func (e *Editor) Load(filename string) {
 	}
 }
 
+// Save checks the world for problems first, and gives the user a chance to
+// fix them before saving.
+func (e *Editor) Save(filename string) {
+	save := func() {
+		ecs.Save(filename)
+		e.OpenFile = filename
+		e.Modified = false
+		e.UpdateTitle()
+	}
+
+	e.Lock.Lock()
+	problems := controllers.ValidateWorld(false)
+	e.Lock.Unlock()
+	if len(problems) == 0 {
+		save()
+		return
+	}
+
+	text := ""
+	for i, p := range problems {
+		log.Printf("Editor.Save: %v", p.String())
+		if i < 10 {
+			text += p.String() + "\n"
+		}
+	}
+	if len(problems) > 10 {
+		text += fmt.Sprintf("...and %v more, see the log.", len(problems)-10)
+	}
+	dlg := dialog.NewCustomConfirm("World has problems", "Fix and save", "Save anyway",
+		widget.NewLabel(text), func(fix bool) {
+			if fix {
+				e.Act(&actions.FixWorld{Action: state.Action{IEditor: e}})
+			}
+			save()
+		}, e.Window)
+	dlg.Show()
+}
+
 func (e *Editor) Test() {
 	e.Lock.Lock()
 	defer e.SelectObjects(true)
@@ -463,6 +502,91 @@ func (e *Editor) NewShader() {
 	dlg.Show()
 }
 
+// NewPrefabInstance asks for a prefab file and places an instance of it.
+func (e *Editor) NewPrefabInstance() {
+	dlg := dialog.NewFileOpen(func(uc fyne.URIReadCloser, err error) {
+		if err != nil {
+			e.Alert(fmt.Sprintf("Error loading prefab: %v", err))
+			return
+		}
+		if uc == nil {
+			return
+		}
+
+		// Keep the path relative to the world, so that it can be moved.
+		source := uc.URI().Path()
+		if rel, err := filepath.Rel(ecs.WorkingDirForEntity(0), source); err == nil {
+			source = rel
+		}
+		e.Act(&actions.AddPrefabInstance{
+			AddEntity: actions.AddEntity{Place: actions.Place{Action: state.Action{IEditor: e}}},
+			Source:    source,
+		})
+	}, e.Window)
+
+	e.SetDialogLocation(dlg, e.OpenFile)
+	dlg.Resize(fyne.NewSize(1000, 700))
+	dlg.SetConfirmText("Instance prefab")
+	dlg.SetDismissText("Cancel")
+	dlg.Show()
+}
+
+// MemoryStats shows how much memory each component type is using, with an
+// option to release empty arena chunks.
+func (e *Editor) MemoryStats() {
+	e.Lock.Lock()
+	arenas, total := ecs.MemoryStats()
+	e.Lock.Unlock()
+
+	text := total.String() + "\n\n"
+	for _, s := range arenas {
+		if s.Chunks == 0 {
+			continue
+		}
+		text += s.String() + "\n"
+	}
+	label := widget.NewLabel(text)
+	label.TextStyle.Monospace = true
+	scroll := container.NewVScroll(label)
+	scroll.SetMinSize(fyne.NewSize(700, 500))
+	dlg := dialog.NewCustomConfirm("Component memory", "Compact", "Close", scroll, func(compact bool) {
+		if !compact {
+			return
+		}
+		e.Lock.Lock()
+		freed := ecs.CompactArenas()
+		e.Lock.Unlock()
+		e.Alert(fmt.Sprintf("Released %v", ecs.HumanBytes(freed)))
+	}, e.Window)
+	dlg.Show()
+}
+
+// EventHistory shows the most recent simulation events, how many subscribers
+// they were delivered to, and per-class totals.
+func (e *Editor) EventHistory() {
+	e.Lock.Lock()
+	records := ecs.Simulation.Events.RecentEvents()
+	overflows := ecs.Simulation.Events.Overflows
+	text := fmt.Sprintf("Queue overflows: %v\n\n", overflows)
+	for _, ec := range dynamic.EventClasses() {
+		if ec == nil || ec.Published.Load() == 0 {
+			continue
+		}
+		text += fmt.Sprintf("%v: %v published, %v dropped\n", ec.Name, ec.Published.Load(), ec.Dropped.Load())
+	}
+	e.Lock.Unlock()
+
+	text += "\n"
+	for i := len(records) - 1; i >= 0; i-- {
+		text += records[i].String() + "\n"
+	}
+	label := widget.NewLabel(text)
+	label.TextStyle.Monospace = true
+	scroll := container.NewVScroll(label)
+	scroll.SetMinSize(fyne.NewSize(700, 500))
+	dialog.ShowCustom("Event history", "Close", scroll, e.Window)
+}
+
 func (e *Editor) SwitchTool(tool state.EditorTool) {
 	e.Tool = tool
 	if m, ok := e.CurrentAction.(state.Cancelable); ok {
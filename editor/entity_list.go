@@ -303,6 +303,16 @@ func (list *EntityList) Update() {
 			searchEntities = append(searchEntities, e)
 		}
 	}
+	// Names, tags, and component types (e.g. "tag:reactor component:Light")
+	if searchValid {
+		if found, err := ecs.FindEntities(list.State().SearchQuery); err == nil {
+			for _, e := range found {
+				if e != 0 {
+					searchEntities = append(searchEntities, e)
+				}
+			}
+		}
+	}
 	query := bleve.NewQueryStringQuery(list.State().SearchQuery)
 	searchRequest := bleve.NewSearchRequest(query)
 	searchRequest.Size = 100
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"tlyakhov/gofoom/components/audio"
+	"tlyakhov/gofoom/components/core"
 	"tlyakhov/gofoom/constants"
 	"tlyakhov/gofoom/ecs"
 	"tlyakhov/gofoom/editor/resources"
@@ -55,6 +56,8 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
+	// Every file needs to be loaded to edit it.
+	ecs.StreamIncludes = false
 	ecs.Initialize()
 	audio.Mixer.Initialize()
 	defer audio.Mixer.Close()
@@ -133,8 +136,13 @@ func main() {
 		t := time.NewTicker(time.Second / 30)
 		for range t.C {
 			editor.Lock.Lock()
+			reloaded := core.ReloadChangedScripts()
 			ecs.Simulation.Step()
 			editor.Lock.Unlock()
+			if reloaded > 0 {
+				// Show the new compile status
+				editor.refreshProperties()
+			}
 			fyne.DoAndWait(editor.MapWidget.Raster.Refresh)
 		}
 	}()
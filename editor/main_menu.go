@@ -27,6 +27,7 @@ type MenuAction struct {
 
 type EditorMenu struct {
 	FileOpen   MenuAction
+	FileImport MenuAction
 	FileSaveAs MenuAction
 	FileSave   MenuAction
 	FileQuit   MenuAction
@@ -69,7 +70,11 @@ type EditorMenu struct {
 	ToolsSplitSector        MenuAction
 	ToolsAlignGrid          MenuAction
 	ToolsNewShader          MenuAction
+	ToolsInstancePrefab     MenuAction
 	ToolsPathDebug          MenuAction
+	ToolsMemoryStats        MenuAction
+	ToolsEventHistory       MenuAction
+	ToolsScriptConsole      MenuAction
 
 	ViewSectorEntities     MenuAction
 	ViewSnapToGrid         MenuAction
@@ -103,6 +108,41 @@ func CreateMainMenu() {
 		dlg.Show()
 	})
 
+	editor.FileImport.Menu = fyne.NewMenuItem("Import world...", func() {
+		dlg := dialog.NewFileOpen(func(uc fyne.URIReadCloser, err error) {
+			if err != nil {
+				editor.Alert(fmt.Sprintf("Error importing world: %v", err))
+				return
+			}
+			if uc == nil {
+				return
+			}
+			offsetEntry := widget.NewEntry()
+			offsetEntry.Text = "0, 0, 0"
+			dialog.ShowForm("Import world", "Import", "Cancel", []*widget.FormItem{
+				{Text: "Offset (x, y, z)", Widget: offsetEntry},
+			}, func(ok bool) {
+				if !ok {
+					return
+				}
+				offset, err := concepts.ParseVector3(offsetEntry.Text)
+				if err != nil {
+					editor.Alert(fmt.Sprintf("Error parsing offset: %v", err))
+					return
+				}
+				editor.Act(&actions.ImportWorld{
+					Action:   state.Action{IEditor: editor},
+					Filename: uc.URI().Path(),
+					Offset:   *offset})
+			}, editor.Window)
+		}, editor.Window)
+		editor.SetDialogLocation(dlg, editor.OpenFile)
+		dlg.Resize(fyne.NewSize(1000, 700))
+		dlg.SetConfirmText("Import world")
+		dlg.SetDismissText("Cancel")
+		dlg.Show()
+	})
+
 	editor.FileSaveAs.Shortcut = &desktop.CustomShortcut{KeyName: fyne.KeyS, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift}
 	editor.FileSaveAs.Menu = fyne.NewMenuItem("Save As", func() {
 		dlg := dialog.NewFileSave(func(uc fyne.URIWriteCloser, err error) {
@@ -113,10 +153,7 @@ func CreateMainMenu() {
 			if uc == nil {
 				return
 			}
-			ecs.Save(uc.URI().Path())
-			editor.OpenFile = uc.URI().Path()
-			editor.Modified = false
-			editor.UpdateTitle()
+			editor.Save(uc.URI().Path())
 		}, editor.Window)
 		target := editor.OpenFile
 		if target == "" {
@@ -136,9 +173,7 @@ func CreateMainMenu() {
 			editor.FileSaveAs.Menu.Action()
 			return
 		}
-		ecs.Save(editor.OpenFile)
-		editor.Modified = false
-		editor.UpdateTitle()
+		editor.Save(editor.OpenFile)
 	})
 	editor.FileQuit.Menu = fyne.NewMenuItem("Quit", func() {})
 
@@ -330,7 +365,11 @@ func CreateMainMenu() {
 	editor.ToolsAlignGrid.Menu = fyne.NewMenuItem("Align Grid", func() { editor.SwitchTool(state.ToolAlignGrid) })
 
 	editor.ToolsNewShader.Menu = fyne.NewMenuItem("New Shader...", editor.NewShader)
+	editor.ToolsInstancePrefab.Menu = fyne.NewMenuItem("Instance Prefab...", editor.NewPrefabInstance)
 	editor.ToolsPathDebug.Menu = fyne.NewMenuItem("Path Debug", func() { editor.SwitchTool(state.ToolPathDebug) })
+	editor.ToolsMemoryStats.Menu = fyne.NewMenuItem("Component Memory...", editor.MemoryStats)
+	editor.ToolsEventHistory.Menu = fyne.NewMenuItem("Event History...", editor.EventHistory)
+	editor.ToolsScriptConsole.Menu = fyne.NewMenuItem("Script Console...", editor.ScriptConsole)
 
 	editor.ViewSectorEntities.Menu = fyne.NewMenuItem("Toggle Sector Labels", func() {
 		editor.SectorTypesVisible = !editor.SectorTypesVisible
@@ -370,7 +409,7 @@ func CreateMainMenu() {
 		controllers.RespawnAll()
 	})
 
-	menuFile := fyne.NewMenu("File", editor.FileOpen.Menu, editor.FileSave.Menu, editor.FileSaveAs.Menu, editor.FileQuit.Menu)
+	menuFile := fyne.NewMenu("File", editor.FileOpen.Menu, editor.FileImport.Menu, editor.FileSave.Menu, editor.FileSaveAs.Menu, editor.FileQuit.Menu)
 	menuEdit := fyne.NewMenu("Edit", editor.EditUndo.Menu, editor.EditRedo.Menu, fyne.NewMenuItemSeparator(),
 		editor.EditCut.Menu, editor.EditCopy.Menu, editor.EditPaste.Menu, editor.EditDelete.Menu, editor.EditFindReplace.Menu, fyne.NewMenuItemSeparator(),
 		editor.EditSelectSegment.Menu,
@@ -382,7 +421,8 @@ func CreateMainMenu() {
 	menuTools := fyne.NewMenu("Tools", editor.ToolsSelect.Menu,
 		editor.ToolsAddBody.Menu, editor.ToolsAddSector.Menu, editor.ToolsAddInternalSegment.Menu, editor.ToolsSplitSegment.Menu,
 		editor.ToolsSplitSector.Menu, editor.ToolsAlignGrid.Menu, fyne.NewMenuItemSeparator(),
-		editor.ToolsNewShader.Menu, editor.ToolsPathDebug.Menu)
+		editor.ToolsNewShader.Menu, editor.ToolsInstancePrefab.Menu, editor.ToolsPathDebug.Menu, editor.ToolsMemoryStats.Menu,
+		editor.ToolsEventHistory.Menu, editor.ToolsScriptConsole.Menu)
 
 	menuView := fyne.NewMenu("View", editor.ViewSectorEntities.Menu, editor.ViewSnapToGrid.Menu)
 
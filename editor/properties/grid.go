@@ -328,6 +328,20 @@ func entityLocked(e ecs.Entity) bool {
 	return false
 }
 
+func (g *Grid) applyPrefabUI(entity ecs.Entity) *widget.Button {
+	title := "Apply overrides to prefab"
+	return widget.NewButtonWithIcon(title, theme.DocumentSaveIcon(), func() {
+		dialog.ShowConfirm(title, "This will change the prefab file and every instance of it. Continue?", func(ok bool) {
+			if !ok {
+				return
+			}
+			g.Act(&actions.ApplyPrefab{
+				Action: state.Action{IEditor: g},
+				Entity: entity})
+		}, g.GridWindow)
+	})
+}
+
 func (g *Grid) addEntityControls(sel *selection.Selection) {
 	entities := make([]ecs.Entity, 0)
 	entityList := ""
@@ -398,7 +412,7 @@ func (g *Grid) addEntityControls(sel *selection.Selection) {
 			Entities: entities})
 	})
 
-	var sw *widget.Button
+	var sw, ap *widget.Button
 	if len(sel.Exact) == 1 {
 		sw = g.switchEntityUI(sel.First().Entity)
 		if disabled {
@@ -406,6 +420,9 @@ func (g *Grid) addEntityControls(sel *selection.Selection) {
 		} else {
 			sw.Enable()
 		}
+		if ecs.GetPrefabInstance(sel.First().Entity) != nil {
+			ap = g.applyPrefabUI(sel.First().Entity)
+		}
 	}
 
 	if disabled {
@@ -424,6 +441,9 @@ func (g *Grid) addEntityControls(sel *selection.Selection) {
 	if sw != nil {
 		c.Add(sw)
 	}
+	if ap != nil {
+		c.Add(ap)
+	}
 	fyne.Do(c.Refresh)
 }
 
@@ -0,0 +1,87 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"tlyakhov/gofoom/components/core"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Only the slowest scripts are shown in the profile.
+const scriptConsoleProfileLength = 20
+
+func monospaceLabel(text string) *widget.Label {
+	label := widget.NewLabel(text)
+	label.TextStyle.Monospace = true
+	label.Wrapping = fyne.TextWrapWord
+	return label
+}
+
+// ScriptConsole lists failing scripts with buttons to recompile them, along
+// with recent errors and the slowest scripts.
+func (e *Editor) ScriptConsole() {
+	content := container.NewVBox()
+	var refresh func()
+	refresh = func() {
+		e.Lock.Lock()
+		failing := core.FailingScripts()
+		all := core.Scripts()
+		errors := core.RecentScriptErrors()
+		e.Lock.Unlock()
+
+		content.RemoveAll()
+		content.Add(widget.NewLabelWithStyle(fmt.Sprintf("Failing scripts (%v)", len(failing)),
+			fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		for _, s := range failing {
+			button := widget.NewButton("Recompile", func() {
+				e.Lock.Lock()
+				s.Recompile()
+				e.Lock.Unlock()
+				refresh()
+			})
+			content.Add(container.NewBorder(nil, nil, nil, button, monospaceLabel(s.ErrorMessage)))
+		}
+		if len(failing) > 0 {
+			content.Add(widget.NewButton("Recompile All", func() {
+				e.Lock.Lock()
+				for _, s := range failing {
+					s.Recompile()
+				}
+				e.Lock.Unlock()
+				refresh()
+			}))
+		}
+
+		var sb strings.Builder
+		for i, s := range all {
+			if i == scriptConsoleProfileLength {
+				break
+			}
+			stats := s.Stats()
+			fmt.Fprintf(&sb, "%v: %v\n", s.Describe(), stats.String())
+		}
+		content.Add(widget.NewLabelWithStyle("Slowest scripts", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		content.Add(monospaceLabel(sb.String()))
+
+		sb.Reset()
+		for i := len(errors) - 1; i >= 0; i-- {
+			fmt.Fprintf(&sb, "%v %v\n", errors[i].Time.Format("15:04:05"), errors[i].String())
+		}
+		content.Add(widget.NewLabelWithStyle("Recent errors", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		content.Add(monospaceLabel(sb.String()))
+	}
+	refresh()
+
+	scroll := container.NewVScroll(content)
+	scroll.SetMinSize(fyne.NewSize(800, 600))
+	dialog.ShowCustom("Script console", "Close",
+		container.NewBorder(widget.NewButton("Refresh", refresh), nil, nil, nil, scroll), e.Window)
+}
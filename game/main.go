@@ -13,6 +13,7 @@ import (
 	"runtime/pprof"
 
 	"tlyakhov/gofoom/components/audio"
+	"tlyakhov/gofoom/components/core"
 	"tlyakhov/gofoom/controllers"
 	"tlyakhov/gofoom/ecs"
 	"tlyakhov/gofoom/ui"
@@ -28,6 +29,15 @@ import (
 
 var cpuProfile = flag.String("cpuprofile", "", "Write CPU profile to file")
 var memProfile = flag.String("memprofile", "", "Write Memory profile to file")
+var recordDemo = flag.String("record", "", "Record a demo to file")
+var playDemo = flag.String("demo", "", "Play back a demo from file")
+var scriptRetries = flag.Int("script-retries", 0, "Number of runtime errors in a row before a script is disabled")
+
+// Off by default, since the sandbox limits scripts our own worlds can use
+// (e.g. ecs.Save or file-based scripts). Turn it on when playing a world you
+// didn't make.
+var scriptSandbox = flag.Bool("sandbox", false, "Restrict what world scripts can do (files, goroutines, runaway loops). Use for untrusted worlds")
+var scriptMaxSteps = flag.Int("script-max-steps", core.ScriptMaxSteps, "Maximum loop iterations and calls per script run in the sandbox")
 var win *opengl.Window
 var renderer *render.Renderer
 var canvas *opengl.Canvas
@@ -42,6 +52,10 @@ func integrateGame() {
 			inMenu = !inMenu
 			if !inMenu {
 				gameUI.SetPage(nil)
+			} else {
+				// A good time to release memory from components that came and
+				// went (projectiles, particles, etc.)
+				ecs.CompactArenas()
 			}
 		}
 	}
@@ -139,6 +153,20 @@ func run() {
 	controllers.RespawnAll()
 	controllers.CreateFont(constants.DefaultFontPath, "Default Font")
 
+	if *playDemo != "" {
+		if err = ecs.Simulation.Play(*playDemo); err != nil {
+			log.Printf("Error playing demo %v", err)
+			return
+		}
+		inMenu = false
+	} else if *recordDemo != "" {
+		if err = ecs.Simulation.Record(*recordDemo, constants.TestWorldPath); err != nil {
+			log.Printf("Error recording demo %v", err)
+			return
+		}
+	}
+	defer ecs.Simulation.StopLedger()
+
 	renderer = render.NewRenderer()
 	gameUI = &ui.UI{Renderer: renderer}
 	gameUI.OnChanged = onWidgetChanged
@@ -147,6 +175,7 @@ func run() {
 	ui.LoadSettings(constants.UserSettings, uiPageMain, uiPageSettings, uiPageKeyBindings)
 
 	for !win.Closed() {
+		core.ReloadChangedScripts()
 		ecs.Simulation.Step()
 	}
 
@@ -168,6 +197,9 @@ func run() {
 
 func main() {
 	flag.Parse()
+	core.ScriptRetries = *scriptRetries
+	core.ScriptMaxSteps = *scriptMaxSteps
+	core.SetScriptSandbox(*scriptSandbox)
 
 	opengl.Run(run)
 }
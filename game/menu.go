@@ -7,7 +7,6 @@ import (
 	"io/fs"
 	"log"
 	"path/filepath"
-	"strings"
 
 	"github.com/gopxl/pixel/v2"
 
@@ -36,12 +35,21 @@ func initializeMenus() {
 		Title:    "Menu",
 		Widgets: []ui.IWidget{
 			&ui.Button{Widget: ui.Widget{Label: "Reset"}},
+			&ui.Button{Widget: ui.Widget{Label: "Quick Save"}, Clicked: func(b *ui.Button) {
+				quickSave()
+			}},
+			&ui.Button{Widget: ui.Widget{Label: "Quick Load"}, Clicked: func(b *ui.Button) {
+				quickLoad()
+			}},
 			&ui.Button{Widget: ui.Widget{Label: "Load World " + string(rune(16))}, Clicked: func(b *ui.Button) {
 				gameUI.SetPage(uiPageLoadWorld)
 			}},
 			&ui.Button{Widget: ui.Widget{Label: "Settings " + string(rune(16))}, Clicked: func(b *ui.Button) {
 				gameUI.SetPage(uiPageSettings)
 			}},
+			&ui.Button{Widget: ui.Widget{Label: "Scripts " + string(rune(16))}, Clicked: func(b *ui.Button) {
+				showScriptConsole()
+			}},
 			&ui.Button{Widget: ui.Widget{Label: "Quit"},
 				Clicked: func(b *ui.Button) {
 					win.SetClosed(true)
@@ -62,7 +70,7 @@ func initializeMenus() {
 
 	path := filepath.Dir(constants.TestWorldPath)
 	filepath.Walk(path, func(path string, info fs.FileInfo, err error) error {
-		if !strings.HasSuffix(path, ".yaml") {
+		if !ecs.IsWorldFile(path) {
 			return nil
 		}
 		name := filepath.Base(path)
@@ -93,6 +101,28 @@ func initializeMenus() {
 	initMenuOptions()
 }
 
+func quickSave() {
+	if err := ecs.SaveGame(constants.QuickSavePath); err != nil {
+		log.Printf("Error saving game %v: %v", constants.QuickSavePath, err)
+		return
+	}
+	inMenu = false
+	gameUI.SetPage(nil)
+}
+
+func quickLoad() {
+	if err := ecs.LoadGame(constants.QuickSavePath); err != nil {
+		log.Printf("Error loading game %v: %v", constants.QuickSavePath, err)
+		return
+	}
+	// Unlike loading a world, we don't respawn anything here.
+	controllers.CreateFont(constants.DefaultFontPath, "Default Font")
+	renderer.Initialize()
+	gameUI.Config.TextStyle = renderer.NewTextStyle()
+	inMenu = false
+	gameUI.SetPage(nil)
+}
+
 func applyBinding(name string) {
 	for _, w := range gameUI.Page.Widgets {
 		if binding, ok := w.(*ui.InputBinding); ok {
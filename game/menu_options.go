@@ -244,6 +244,28 @@ func initMenuOptions() {
 				Input1:  "MouseButton2",
 				Input2:  "F",
 			},
+			&ui.InputBinding{
+				Widget: ui.Widget{
+					ID:      "inputAltFire",
+					Label:   "Alt Fire",
+					Tooltip: "Input for the weapon's alternate fire",
+					Justify: -1,
+				},
+				EventID: controllers.EventIdAltFire,
+				Input1:  "MouseButton3",
+				Input2:  "",
+			},
+			&ui.InputBinding{
+				Widget: ui.Widget{
+					ID:      "inputReload",
+					Label:   "Reload",
+					Tooltip: "Input for reloading the weapon",
+					Justify: -1,
+				},
+				EventID: controllers.EventIdReload,
+				Input1:  "R",
+				Input2:  "",
+			},
 			&ui.InputBinding{
 				Widget: ui.Widget{
 					ID:      "inputYaw",
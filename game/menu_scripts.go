@@ -0,0 +1,52 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+
+	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/ui"
+)
+
+// Labels longer than this are cut off, the full error is in the tooltip.
+const scriptConsoleLabelLength = 40
+
+var uiPageScripts *ui.Page
+
+// showScriptConsole lists failing scripts. Selecting one recompiles it.
+func showScriptConsole() {
+	failing := core.FailingScripts()
+	uiPageScripts = &ui.Page{
+		Parent:   uiPageMain,
+		IsDialog: true,
+		Title:    fmt.Sprintf("Scripts (%v failing)", len(failing)),
+		Widgets: []ui.IWidget{
+			&ui.Button{Widget: ui.Widget{Label: string(rune(17)) + " Main Menu"}, Clicked: func(b *ui.Button) {
+				gameUI.SetPage(uiPageMain)
+			}},
+			&ui.Button{Widget: ui.Widget{Label: "Recompile All"}, Clicked: func(b *ui.Button) {
+				for _, s := range failing {
+					s.Recompile()
+				}
+				showScriptConsole()
+			}},
+		},
+	}
+	for _, s := range failing {
+		label := s.Describe()
+		if len(label) > scriptConsoleLabelLength {
+			label = label[:scriptConsoleLabelLength-3] + "..."
+		}
+		uiPageScripts.Widgets = append(uiPageScripts.Widgets, &ui.Button{
+			Widget: ui.Widget{Label: label, Tooltip: s.ErrorMessage},
+			Clicked: func(b *ui.Button) {
+				s.Recompile()
+				showScriptConsole()
+			},
+		})
+	}
+	uiPageScripts.Initialize()
+	gameUI.SetPage(uiPageScripts)
+}
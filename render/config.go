@@ -53,18 +53,19 @@ func (c *Config) Initialize() {
 	c.RefreshPlayer()
 }
 
+// Players that aren't spawn points.
+var spawnedPlayers = ecs.NewView1[character.Player](behaviors.SpawnerCID)
+
 func (c *Config) RefreshPlayer() {
-	arena := ecs.ArenaFor[character.Player](character.PlayerCID)
-	for i := range arena.Cap() {
-		player := arena.Value(i)
-		if player == nil || !player.IsActive() || behaviors.GetSpawner(player.Entity) != nil {
-			continue
+	spawnedPlayers.Each(func(e ecs.Entity, player *character.Player) bool {
+		if !player.IsActive() {
+			return true
 		}
 		c.Player = player
-		c.PlayerBody = core.GetBody(player.Entity)
-		c.Carrier = inventory.GetCarrier(player.Entity)
-		return
-	}
+		c.PlayerBody = core.GetBody(e)
+		c.Carrier = inventory.GetCarrier(e)
+		return false
+	})
 }
 
 const lightmapVMask uint64 = (1 << 16) - 1
@@ -274,13 +274,20 @@ func (r *Renderer) DebugInfo() {
 		r.Print(ts, 4, 44, fmt.Sprintf("f: %v, v: %v, p: %v\n", playerMobile.Force.StringHuman(2), playerMobile.Vel.Render.StringHuman(2), r.PlayerBody.Pos.Render.StringHuman(2)))
 	}
 
+	arenas, total := ecs.MemoryStats()
+	text := fmt.Sprintf("Components: %v in %v chunks (%v empty), %v", total.Count, total.Chunks, total.EmptyChunks, ecs.HumanBytes(total.Bytes))
+	for i := 0; i < 3 && i < len(arenas); i++ {
+		text += fmt.Sprintf(", %v: %v", arenas[i].Name, ecs.HumanBytes(arenas[i].Bytes))
+	}
+	r.Print(ts, 4, 54, text)
+
 	for i := range 20 {
 		if i >= r.Player.Notices.Length() {
 			break
 		}
 		msg := r.Player.Notices.Items[i]
 		if t, ok := r.Player.Notices.SetWithTimes.Load(msg); ok {
-			r.Print(ts, 4, 54+i*10, msg)
+			r.Print(ts, 4, 64+i*10, msg)
 			age := time.Now().UnixMilli() - t.(int64)
 			if age > 10000 {
 				r.Player.Notices.PopAtIndex(i)
@@ -4,11 +4,21 @@
 package scripting_symbols
 
 import (
+	"errors"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"testing"
+	"tlyakhov/gofoom/components/behaviors"
 	"tlyakhov/gofoom/components/core"
+	"tlyakhov/gofoom/components/inventory"
+	"tlyakhov/gofoom/concepts"
 	"tlyakhov/gofoom/controllers"
+	"tlyakhov/gofoom/dynamic"
 	"tlyakhov/gofoom/ecs"
 )
 
@@ -36,3 +46,440 @@ func BenchmarkScriptedCode(b *testing.B) {
 		}
 	})
 }
+
+func TestScriptDiagnostics(t *testing.T) {
+	setup()
+	e := ecs.GetEntityByName("sector1")
+	s := core.Script{}
+	s.Construct(map[string]any{"Code": "x := 1\nundefinedThing(x)"})
+	s.SetOwner(e, nil, "Test.Compile")
+	s.Compile()
+	if s.IsCompiled() {
+		t.Fatalf("Script with an undefined function shouldn't compile")
+	}
+	errors := core.RecentScriptErrors()
+	last := errors[len(errors)-1]
+	if last.Runtime || last.Line != 2 || last.Column != 1 || last.Entity != e || last.Name != "Test.Compile" {
+		t.Errorf("Expected compile error on line 2:1, got %v", last.String())
+	}
+	if !slices.Contains(core.FailingScripts(), &s) {
+		t.Errorf("Script should be in the list of failing scripts")
+	}
+
+	// Fixing the code and recompiling should clear the error.
+	s.Code = "x := 1\n_ = x"
+	if !s.Recompile() || s.ErrorMessage != "" || slices.Contains(core.FailingScripts(), &s) {
+		t.Errorf("Script should have recompiled, got %v", s.ErrorMessage)
+	}
+	s.Act()
+	if stats := s.Stats(); stats.Runs != 1 || stats.Errors != 1 {
+		t.Errorf("Expected 1 run and 1 error, got %v", stats.String())
+	}
+
+	// Scripts are dropped when their component is detached, even if the
+	// entity is still around.
+	e2 := ecs.NewEntity()
+	ecs.NewAttachedComponent(e2, core.BodyCID)
+	scripted := ecs.NewAttachedComponent(e2, core.ScriptedCID).(*core.Scripted)
+	scripted.OnFrame.Construct(map[string]any{"Code": "_ = 1"})
+	scripted.OnFrame.SetOwner(e2, scripted, "Scripted.OnFrame")
+	scripted.OnFrame.Compile()
+	if !slices.Contains(core.Scripts(), &scripted.OnFrame) {
+		t.Errorf("Expected attached script to be listed")
+	}
+	ecs.DetachComponent(core.ScriptedCID, e2)
+	if slices.Contains(core.Scripts(), &scripted.OnFrame) {
+		t.Errorf("Expected detached script not to be listed")
+	}
+
+	// Resetting the world forgets everything.
+	ecs.Initialize()
+	if len(core.Scripts()) != 0 || len(core.RecentScriptErrors()) != 0 {
+		t.Errorf("Expected no scripts or errors after Initialize, got %v, %v", len(core.Scripts()), len(core.RecentScriptErrors()))
+	}
+}
+
+func TestScriptRuntimeErrors(t *testing.T) {
+	setup()
+	defer func(retries int) { core.ScriptRetries = retries }(core.ScriptRetries)
+
+	s := core.Script{}
+	s.Construct(map[string]any{"Code": "fail := func() {\n\tpanic(\"boom\")\n}\nif s.Vars[\"fail\"] == true {\n\tfail()\n}"})
+	s.SetOwner(0, nil, "Test.Runtime")
+	s.Compile()
+	if !s.IsCompiled() {
+		t.Fatalf("Script should compile, got %v", s.ErrorMessage)
+	}
+
+	core.ScriptRetries = 1
+	s.Vars["fail"] = true
+	s.Act()
+	errors := core.RecentScriptErrors()
+	last := errors[len(errors)-1]
+	if !last.Runtime || last.Line != 2 || last.Message != "boom" {
+		t.Errorf("Expected runtime error on line 2, got %v", last.String())
+	}
+	if !s.IsCompiled() {
+		t.Fatalf("Script should be retried after the first error")
+	}
+	// A successful run resets the retries.
+	s.Vars["fail"] = false
+	s.Act()
+	if s.ErrorMessage != "" || s.Stats().Failures != 0 {
+		t.Errorf("Successful run should clear the error, got %v", s.ErrorMessage)
+	}
+	s.Vars["fail"] = true
+	s.Act()
+	s.Act()
+	if s.IsCompiled() {
+		t.Errorf("Script should be disabled after too many errors")
+	}
+}
+
+func TestScriptHotReload(t *testing.T) {
+	setup()
+	path := filepath.Join(t.TempDir(), "script.go")
+	if err := os.WriteFile(path, []byte("s.Vars[\"result\"] = 1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := core.Script{}
+	s.Construct(map[string]any{"File": path})
+	if s.IsEmpty() {
+		t.Fatalf("Script with a file shouldn't be empty")
+	}
+	s.Compile()
+	s.Vars["kept"] = "yes"
+	if !s.Act() || s.Vars["result"] != 1 {
+		t.Fatalf("Expected script from file to run, got %v", s.ErrorMessage)
+	}
+	if data := s.Serialize(); data["Code"] != nil || data["File"] != path {
+		t.Errorf("Expected only the file to be serialized, got %v", data)
+	}
+
+	// Writing a file can generate several events, wait until the script
+	// matches what we wrote.
+	reload := func(code string, done func() bool) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+			t.Fatal(err)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			core.ReloadChangedScripts()
+			if done() {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Script wasn't reloaded")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	reload("s.Vars[\"result\"] = 2", func() bool { return s.Code == "s.Vars[\"result\"] = 2" })
+	if !s.Act() || s.Vars["result"] != 2 || s.Vars["kept"] != "yes" {
+		t.Errorf("Expected reloaded script to run and keep vars, got %v, %v", s.Vars["result"], s.Vars["kept"])
+	}
+
+	// Broken code shouldn't replace working code.
+	reload("s.Vars[\"result\"] = undefinedThing", func() bool { return s.ErrorMessage != "" })
+	if !s.Act() || s.Vars["result"] != 2 {
+		t.Errorf("Expected previous version to keep running, got %v", s.Vars["result"])
+	}
+
+	// Scripts from a previous world aren't watched anymore.
+	setup()
+	if err := os.WriteFile(path, []byte("s.Vars[\"result\"] = 3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for range 20 {
+		if count := core.ReloadChangedScripts(); count != 0 {
+			t.Fatalf("Expected no scripts to be reloaded after a reset, got %v", count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestScriptLibrary(t *testing.T) {
+	setup()
+	e := ecs.NewEntity()
+	sl := ecs.NewAttachedComponent(e, core.ScriptLibraryCID).(*core.ScriptLibrary)
+	sl.Library.Code = "func Twice(x float64) float64 {\n\treturn x * 2\n}"
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+
+	sector := ecs.GetEntityByName("sector1")
+	scripts := make([]core.Script, 2)
+	for i := range scripts {
+		scripts[i].Construct(map[string]any{"Code": "core.GetSector(s.Entity(\"sector\")).Bottom.Z.Spawn = Twice(3)"})
+		scripts[i].Vars["sector"] = sector
+		scripts[i].Compile()
+		if !scripts[i].IsCompiled() {
+			t.Fatalf("Script %v using the library didn't compile: %v", i, scripts[i].ErrorMessage)
+		}
+	}
+	scripts[1].Act()
+	if z := core.GetSector(sector).Bottom.Z.Spawn; z != 6 {
+		t.Errorf("Expected library function to return 6, got %v", z)
+	}
+
+	// Changing the library should recompile scripts the next time they run.
+	sl.Library.Code = "func Twice(x float64) float64 {\n\treturn x + x + 1\n}"
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+	scripts[0].Act()
+	if z := core.GetSector(sector).Bottom.Z.Spawn; z != 7 {
+		t.Errorf("Expected updated library function to return 7, got %v", z)
+	}
+
+	// Once the library is gone, so are its functions.
+	ecs.Delete(e)
+	if scripts[0].Act() || !strings.Contains(scripts[0].ErrorMessage, "Twice") {
+		t.Errorf("Expected script to fail without the library, got %q", scripts[0].ErrorMessage)
+	}
+
+	// Same for libraries from a previous world.
+	e = ecs.NewEntity()
+	sl = ecs.NewAttachedComponent(e, core.ScriptLibraryCID).(*core.ScriptLibrary)
+	sl.Library.Code = "func Twice(x float64) float64 {\n\treturn x * 2\n}"
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+	setup()
+	scripts[1].Compile()
+	if scripts[1].IsCompiled() || !strings.Contains(scripts[1].ErrorMessage, "Twice") {
+		t.Errorf("Expected script not to see libraries from the previous world, got %q", scripts[1].ErrorMessage)
+	}
+}
+
+func TestScriptState(t *testing.T) {
+	setup()
+	e := ecs.GetEntityByName("sector1")
+	s := core.Script{}
+	s.Construct(map[string]any{"Code": "s.State().Set(\"count\", s.State().Int(\"count\")+1)"})
+	s.SetOwner(e, nil, "Test.State")
+	s.Compile()
+	s.Act()
+	s.Act()
+	state := core.GetScriptState(e)
+	if state == nil || state.Int("count") != 2 {
+		t.Fatalf("Expected count to be 2, got %v", state)
+	}
+
+	loaded := &core.ScriptState{}
+	loaded.Construct(state.Serialize())
+	if loaded.Int("count") != 2 {
+		t.Errorf("Expected count to survive serialization, got %v", loaded.Values)
+	}
+}
+
+func TestScriptSandbox(t *testing.T) {
+	setup()
+	core.SetScriptSandbox(true)
+	defer core.SetScriptSandbox(false)
+	prevSteps := core.ScriptMaxSteps
+	core.ScriptMaxSteps = 1000
+	defer func() { core.ScriptMaxSteps = prevSteps }()
+
+	compile := func(code string) *core.Script {
+		s := &core.Script{}
+		s.Construct(map[string]any{"Code": code})
+		s.SetOwner(ecs.GetEntityByName("sector1"), nil, "Test.Sandbox")
+		s.Compile()
+		return s
+	}
+
+	s := compile("total := 0\nfor i := 0; i < 10; i++ {\n\ttotal += i\n}\ns.Vars[\"total\"] = total")
+	if !s.Act() || s.Vars["total"] != 45 {
+		t.Fatalf("Expected a short loop to run in the sandbox, got %v: %v", s.Vars["total"], s.ErrorMessage)
+	}
+
+	s = compile("x := 0\nfor {\n\tx++\n}")
+	if !s.IsCompiled() {
+		t.Fatalf("Infinite loop should compile: %v", s.ErrorMessage)
+	}
+	if s.Act() || !strings.Contains(s.ErrorMessage, "exceeded its budget") || s.IsCompiled() {
+		t.Errorf("Expected infinite loop to be aborted and disabled, got %v", s.ErrorMessage)
+	}
+
+	for code, expected := range map[string]string{
+		"ecs.Save(\"world.yaml\")":            "ecs.Save isn't allowed",
+		"ecs.Simulation.Record(\"a\", \"b\")": "Record isn't allowed",
+		"go func() {}()":                      "go statements aren't allowed",
+		"c := make(chan int)\n<-c":            "channels aren't allowed",
+		"core.ScriptMaxSteps = 0":             "core.ScriptMaxSteps isn't allowed",
+		"f := func() {\n\tdefer func() { recover() }()\n\tfor {\n\t}\n}\nfor {\n\tf()\n}": "recover isn't allowed",
+		"p := &ecs.Prefab{Path: \"/tmp/x.yaml\"}\np.Save()":                               "ecs.Prefab isn't allowed",
+		"ecs.GetPrefabInstance(onEntity).ApplyOverrides()":                                "ApplyOverrides isn't allowed",
+		"pi := ecs.GetPrefabInstance(onEntity)\n_ = pi":                                   "ecs.GetPrefabInstance isn't allowed",
+		"s.File = \"/etc/passwd\"":                                                        "File isn't allowed",
+		"f := ecs.Simulation.Record\nf(\"a\", \"b\")":                                     "Record isn't allowed",
+		"sim := ecs.Simulation\nf := sim.Play\nf(\"a\")":                                  "Play isn't allowed",
+		"f := ecs.Types().InterpSymbols[\"tlyakhov/gofoom/components/core/core\"][\"SetScriptSandbox\"]\nf.Interface().(func(bool))(false)": "InterpSymbols isn't allowed",
+		"types := ecs.Types()\n_ = types":                                    "ecs.Types isn't allowed",
+		"gofoom_sandbox.ScriptMaxSteps = 0":                                  "gofoom_sandbox is reserved",
+		"var gofoom_sandbox struct{ ScriptStep func() }\n_ = gofoom_sandbox": "gofoom_sandbox is reserved",
+	} {
+		s = compile(code)
+		if s.IsCompiled() || !strings.Contains(s.ErrorMessage, expected) {
+			t.Errorf("Expected %q to fail with %q, got %q", code, expected, s.ErrorMessage)
+		}
+	}
+
+	// Shadowing core doesn't get around the step budget.
+	s = compile("var core struct{ ScriptStep func(...any) (int, error) }\ncore.ScriptStep = fmt.Print\nx := 0\nfor i := 0; i < 100000; i++ {\n\tx++\n}\ns.Vars[\"x\"] = x")
+	if s.Act() || s.Vars["x"] != nil || !strings.Contains(s.ErrorMessage, "exceeded its budget") {
+		t.Errorf("Expected shadowing core not to escape the budget, got %v: %v", s.Vars["x"], s.ErrorMessage)
+	}
+
+	// Go code called by a script can't catch the budget panic and keep going.
+	s = compile("guard := s.Vars[\"guard\"].(func(func()))\nguard(func() {\n\tfor {\n\t}\n})\nx := 0\nfor i := 0; i < 10; i++ {\n\tx++\n}\ns.Vars[\"x\"] = x")
+	s.Vars["guard"] = func(f func()) {
+		defer func() { recover() }()
+		f()
+	}
+	if s.Act() || s.Vars["x"] != nil || !strings.Contains(s.ErrorMessage, "exceeded its budget") {
+		t.Errorf("Expected budget to stay exceeded after recovering, got %v: %v", s.Vars["x"], s.ErrorMessage)
+	}
+
+	// The engine refuses file access while a sandboxed script is running,
+	// however the script gets to it.
+	prefabPath := filepath.Join(t.TempDir(), "x.yaml")
+	s = compile("save := s.Vars[\"save\"].(func() error)\ns.Vars[\"err\"] = save()")
+	s.Vars["save"] = func() error {
+		p := &ecs.Prefab{Path: prefabPath}
+		return p.Save()
+	}
+	if !s.Act() || !errors.Is(s.Vars["err"].(error), dynamic.ErrSandboxed) {
+		t.Errorf("Expected saving a prefab to fail in the sandbox, got %v", s.Vars["err"])
+	}
+	if _, err := os.Stat(prefabPath); err == nil {
+		t.Errorf("Expected %v not to be written", prefabPath)
+	}
+	if dynamic.Sandboxed() {
+		t.Errorf("Expected sandbox to end with the script")
+	}
+
+	// Same for reading worlds and prefabs.
+	worldPath := filepath.Join(t.TempDir(), "world.yaml")
+	ecs.Save(worldPath)
+	s = compile("load := s.Vars[\"load\"].(func() []error)\ns.Vars[\"errs\"] = load()")
+	s.Vars["load"] = func() []error {
+		_, importErr := ecs.Import(worldPath)
+		_, prefabErr := ecs.LoadPrefab(worldPath)
+		return []error{importErr, prefabErr}
+	}
+	if !s.Act() {
+		t.Fatalf("Expected load script to run: %v", s.ErrorMessage)
+	}
+	for _, err := range s.Vars["errs"].([]error) {
+		if !errors.Is(err, dynamic.ErrSandboxed) {
+			t.Errorf("Expected reading a world to fail in the sandbox, got %v", err)
+		}
+	}
+
+	e := ecs.NewEntity()
+	sl := ecs.NewAttachedComponent(e, core.ScriptLibraryCID).(*core.ScriptLibrary)
+	sl.Library.Code = "import \"os\"\n\nfunc Read() { os.ReadFile(\"/etc/passwd\") }"
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+	if !strings.Contains(sl.Library.ErrorMessage, "package os isn't allowed") {
+		t.Errorf("Expected library importing os to fail, got %q", sl.Library.ErrorMessage)
+	}
+}
+
+func TestWeaponClassScripts(t *testing.T) {
+	setup()
+	eFirer := ecs.NewEntity()
+	body := ecs.NewAttachedComponent(eFirer, core.BodyCID).(*core.Body)
+	body.Pos.Spawn = concepts.Vector3{0, 0, 50}
+	body.Pos.ResetToSpawn()
+	carrier := ecs.NewAttachedComponent(eFirer, inventory.CarrierCID).(*inventory.Carrier)
+
+	eWeapon := ecs.NewEntity()
+	weapon := ecs.NewAttachedComponent(eWeapon, inventory.WeaponCID).(*inventory.Weapon)
+	slot := ecs.NewAttachedComponent(eWeapon, inventory.SlotCID).(*inventory.Slot)
+	slot.Carrier = carrier
+	ecs.NewAttachedComponent(eWeapon, inventory.WeaponClassInstantCID)
+	class := ecs.NewAttachedComponent(eWeapon, inventory.WeaponClassCID).(*inventory.WeaponClass)
+	for i := range class.Params {
+		class.Params[i].Time = 0
+	}
+	// Long enough to fire
+	class.Params[inventory.WeaponFiring].Time = 1000
+
+	// A shotgun with an alt-fire that shoots more pellets
+	class.Fire.Code = `pellets := 3
+if alt {
+	pellets = 5
+}
+for i := 0; i < pellets; i++ {
+	shoot(float64(i), 0)
+}
+s.State().Set("shots", s.State().Int("shots")+pellets)`
+	class.Damage.Code = `*damage = 0
+s.State().Set("hits", s.State().Int("hits")+1)`
+	class.Reload.Code = `s.State().Set("reloads", s.State().Int("reloads")+1)`
+	class.StateChange.Code = `if to == inventory.WeaponReloading {
+	s.State().Set("reloading", true)
+}`
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+	for name, script := range class.Scripts() {
+		if !script.IsEmpty() && !script.IsCompiled() {
+			t.Fatalf("WeaponClass.%v didn't compile: %v", name, script.ErrorMessage)
+		}
+	}
+
+	weapon.Intent = inventory.WeaponFireAlt
+	ecs.ActAllControllers(ecs.ControllerFrame)
+	ecs.ActAllControllers(ecs.ControllerFrame)
+	state := core.GetScriptState(eWeapon)
+	if state == nil || state.Int("shots") != 5 || !weapon.Alt {
+		t.Fatalf("Expected 5 alt-fire shots, got %v", state)
+	}
+	if state.Int("hits") != 5 {
+		t.Errorf("Expected the damage script to run for 5 hits, got %v", state.Int("hits"))
+	}
+
+	weapon.State = inventory.WeaponIdle
+	weapon.Intent = inventory.WeaponReload
+	ecs.ActAllControllers(ecs.ControllerFrame)
+	ecs.ActAllControllers(ecs.ControllerFrame)
+	if state.Int("reloads") != 1 || !state.Bool("reloading") || weapon.State != inventory.WeaponIdle {
+		t.Errorf("Expected one reload and to be idle again, got %v in state %v", state.Values, weapon.State)
+	}
+
+	// Projectiles run the same scripts when they hit something.
+	class.Damage.Code = `*damage *= 2`
+	class.Hit.Code = `s.State().Set("hit", hit.Entity)`
+	eTarget := ecs.NewEntity()
+	targetBody := ecs.NewAttachedComponent(eTarget, core.BodyCID).(*core.Body)
+	targetBody.Pos.Spawn = concepts.Vector3{40, 0, 50}
+	targetBody.Pos.ResetToSpawn()
+	ecs.NewAttachedComponent(eTarget, core.MobileCID)
+	alive := ecs.NewAttachedComponent(eTarget, behaviors.AliveCID).(*behaviors.Alive)
+
+	eProjectile := ecs.NewEntity()
+	projectileBody := ecs.NewAttachedComponent(eProjectile, core.BodyCID).(*core.Body)
+	projectileBody.Pos.Spawn = concepts.Vector3{20, 0, 50}
+	projectileBody.Pos.ResetToSpawn()
+	projectileBody.Size.SetAll(concepts.Vector2{2, 2})
+	mobile := ecs.NewAttachedComponent(eProjectile, core.MobileCID).(*core.Mobile)
+	mobile.Mass = 0.25
+	mobile.CrBody = core.CollideRemove
+	mobile.CrPlayer = core.CollideRemove
+	mobile.CrWall = core.CollideRemove
+	mobile.Vel.Spawn = concepts.Vector3{20, 0, 0}
+	mobile.Vel.ResetToSpawn()
+	projectile := ecs.NewAttachedComponent(eProjectile, inventory.ProjectileCID).(*inventory.Projectile)
+	projectile.Weapon = eWeapon
+	projectile.Damage = 10
+	ecs.ActAllControllers(ecs.ControllerPrecompute)
+	for i := 0; i < 100 && ecs.Entities.Contains(uint32(eProjectile)); i++ {
+		ecs.ActAllControllers(ecs.ControllerFrame)
+	}
+	if ecs.Entities.Contains(uint32(eProjectile)) {
+		t.Fatalf("Expected projectile to hit the target")
+	}
+	if alive.Health.Now != 80 {
+		t.Errorf("Expected the damage script to double projectile damage, got health %v", alive.Health.Now)
+	}
+	if e, _ := state.Get("hit").(ecs.Entity); e != eTarget {
+		t.Errorf("Expected the hit script to see the target, got %v", state.Get("hit"))
+	}
+}
@@ -6,6 +6,8 @@
 package scripting_symbols
 
 import (
+	"go/constant"
+	"go/token"
 	"reflect"
 	"tlyakhov/gofoom/components/core"
 )
@@ -13,39 +15,68 @@ import (
 func init() {
 	Symbols["tlyakhov/gofoom/components/core/core"] = map[string]reflect.Value{
 		// function, constant and variable definitions
-		"BodyCID":                  reflect.ValueOf(&core.BodyCID).Elem(),
-		"CollideBounce":            reflect.ValueOf(core.CollideBounce),
-		"CollideDeactivate":        reflect.ValueOf(core.CollideDeactivate),
-		"CollideNone":              reflect.ValueOf(core.CollideNone),
-		"CollideRemove":            reflect.ValueOf(core.CollideRemove),
-		"CollideSeparate":          reflect.ValueOf(core.CollideSeparate),
-		"CollideStop":              reflect.ValueOf(core.CollideStop),
-		"CollisionResponseString":  reflect.ValueOf(core.CollisionResponseString),
-		"CollisionResponseStrings": reflect.ValueOf(core.CollisionResponseStrings),
-		"CollisionResponseValues":  reflect.ValueOf(core.CollisionResponseValues),
-		"GetBody":                  reflect.ValueOf(core.GetBody),
-		"GetInternalSegment":       reflect.ValueOf(core.GetInternalSegment),
-		"GetLight":                 reflect.ValueOf(core.GetLight),
-		"GetMobile":                reflect.ValueOf(core.GetMobile),
-		"GetScripted":              reflect.ValueOf(core.GetScripted),
-		"GetSector":                reflect.ValueOf(core.GetSector),
-		"InternalSegmentCID":       reflect.ValueOf(&core.InternalSegmentCID).Elem(),
-		"LightCID":                 reflect.ValueOf(&core.LightCID).Elem(),
-		"MobileCID":                reflect.ValueOf(&core.MobileCID).Elem(),
-		"QuadTree":                 reflect.ValueOf(&core.QuadTree).Elem(),
-		"ScriptedCID":              reflect.ValueOf(&core.ScriptedCID).Elem(),
-		"SectorCID":                reflect.ValueOf(&core.SectorCID).Elem(),
+		"BodyCID":                   reflect.ValueOf(&core.BodyCID).Elem(),
+		"CollideBounce":             reflect.ValueOf(core.CollideBounce),
+		"CollideDeactivate":         reflect.ValueOf(core.CollideDeactivate),
+		"CollideNone":               reflect.ValueOf(core.CollideNone),
+		"CollideRemove":             reflect.ValueOf(core.CollideRemove),
+		"CollideSeparate":           reflect.ValueOf(core.CollideSeparate),
+		"CollideStop":               reflect.ValueOf(core.CollideStop),
+		"CollisionResponseString":   reflect.ValueOf(core.CollisionResponseString),
+		"CollisionResponseStrings":  reflect.ValueOf(core.CollisionResponseStrings),
+		"CollisionResponseValues":   reflect.ValueOf(core.CollisionResponseValues),
+		"FailingScripts":            reflect.ValueOf(core.FailingScripts),
+		"GetBody":                   reflect.ValueOf(core.GetBody),
+		"GetInternalSegment":        reflect.ValueOf(core.GetInternalSegment),
+		"GetLight":                  reflect.ValueOf(core.GetLight),
+		"GetMobile":                 reflect.ValueOf(core.GetMobile),
+		"GetParented":               reflect.ValueOf(core.GetParented),
+		"GetScriptLibrary":          reflect.ValueOf(core.GetScriptLibrary),
+		"GetScriptState":            reflect.ValueOf(core.GetScriptState),
+		"GetScripted":               reflect.ValueOf(core.GetScripted),
+		"GetSector":                 reflect.ValueOf(core.GetSector),
+		"InternalSegmentCID":        reflect.ValueOf(&core.InternalSegmentCID).Elem(),
+		"InvalidateScriptLibraries": reflect.ValueOf(core.InvalidateScriptLibraries),
+		"LightCID":                  reflect.ValueOf(&core.LightCID).Elem(),
+		"LogDebug":                  reflect.ValueOf(core.LogDebug),
+		"MobileCID":                 reflect.ValueOf(&core.MobileCID).Elem(),
+		"ParentedCID":               reflect.ValueOf(&core.ParentedCID).Elem(),
+		"QuadTree":                  reflect.ValueOf(&core.QuadTree).Elem(),
+		"RecentScriptErrors":        reflect.ValueOf(core.RecentScriptErrors),
+		"ReloadChangedScripts":      reflect.ValueOf(core.ReloadChangedScripts),
+		"ResetScriptDiagnostics":    reflect.ValueOf(core.ResetScriptDiagnostics),
+		"ScriptErrorHistoryLength":  reflect.ValueOf(constant.MakeFromLiteral("64", token.INT, 0)),
+		"ScriptLibraryCID":          reflect.ValueOf(&core.ScriptLibraryCID).Elem(),
+		"ScriptMaxSteps":            reflect.ValueOf(&core.ScriptMaxSteps).Elem(),
+		"ScriptMaxTime":             reflect.ValueOf(&core.ScriptMaxTime).Elem(),
+		"ScriptRetries":             reflect.ValueOf(&core.ScriptRetries).Elem(),
+		"ScriptSandbox":             reflect.ValueOf(&core.ScriptSandbox).Elem(),
+		"ScriptStateCID":            reflect.ValueOf(&core.ScriptStateCID).Elem(),
+		"ScriptStateFor":            reflect.ValueOf(core.ScriptStateFor),
+		"ScriptStep":                reflect.ValueOf(core.ScriptStep),
+		"ScriptedCID":               reflect.ValueOf(&core.ScriptedCID).Elem(),
+		"Scripts":                   reflect.ValueOf(core.Scripts),
+		"SectorCID":                 reflect.ValueOf(&core.SectorCID).Elem(),
+		"SetScriptSandbox":          reflect.ValueOf(core.SetScriptSandbox),
 
 		// type definitions
 		"Body":              reflect.ValueOf((*core.Body)(nil)),
+		"CastRequest":       reflect.ValueOf((*core.CastRequest)(nil)),
+		"CastResponse":      reflect.ValueOf((*core.CastResponse)(nil)),
 		"CollisionResponse": reflect.ValueOf((*core.CollisionResponse)(nil)),
 		"InternalSegment":   reflect.ValueOf((*core.InternalSegment)(nil)),
 		"Light":             reflect.ValueOf((*core.Light)(nil)),
 		"LightmapCell":      reflect.ValueOf((*core.LightmapCell)(nil)),
 		"Mobile":            reflect.ValueOf((*core.Mobile)(nil)),
+		"Parented":          reflect.ValueOf((*core.Parented)(nil)),
 		"QuadNode":          reflect.ValueOf((*core.QuadNode)(nil)),
 		"Script":            reflect.ValueOf((*core.Script)(nil)),
+		"ScriptBudgetError": reflect.ValueOf((*core.ScriptBudgetError)(nil)),
+		"ScriptError":       reflect.ValueOf((*core.ScriptError)(nil)),
+		"ScriptLibrary":     reflect.ValueOf((*core.ScriptLibrary)(nil)),
 		"ScriptParam":       reflect.ValueOf((*core.ScriptParam)(nil)),
+		"ScriptState":       reflect.ValueOf((*core.ScriptState)(nil)),
+		"ScriptStats":       reflect.ValueOf((*core.ScriptStats)(nil)),
 		"Scripted":          reflect.ValueOf((*core.Scripted)(nil)),
 		"Sector":            reflect.ValueOf((*core.Sector)(nil)),
 		"SectorPlane":       reflect.ValueOf((*core.SectorPlane)(nil)),
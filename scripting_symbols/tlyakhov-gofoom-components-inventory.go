@@ -16,6 +16,7 @@ func init() {
 		"CarrierCID":               reflect.ValueOf(&inventory.CarrierCID).Elem(),
 		"GetCarrier":               reflect.ValueOf(inventory.GetCarrier),
 		"GetItem":                  reflect.ValueOf(inventory.GetItem),
+		"GetProjectile":            reflect.ValueOf(inventory.GetProjectile),
 		"GetSlot":                  reflect.ValueOf(inventory.GetSlot),
 		"GetWeapon":                reflect.ValueOf(inventory.GetWeapon),
 		"GetWeaponClass":           reflect.ValueOf(inventory.GetWeaponClass),
@@ -28,6 +29,7 @@ func init() {
 		"ItemFlagsString":          reflect.ValueOf(inventory.ItemFlagsString),
 		"ItemFlagsStrings":         reflect.ValueOf(inventory.ItemFlagsStrings),
 		"ItemFlagsValues":          reflect.ValueOf(inventory.ItemFlagsValues),
+		"ProjectileCID":            reflect.ValueOf(&inventory.ProjectileCID).Elem(),
 		"SlotCID":                  reflect.ValueOf(&inventory.SlotCID).Elem(),
 		"WeaponCID":                reflect.ValueOf(&inventory.WeaponCID).Elem(),
 		"WeaponClassCID":           reflect.ValueOf(&inventory.WeaponClassCID).Elem(),
@@ -35,6 +37,7 @@ func init() {
 		"WeaponClassProjectileCID": reflect.ValueOf(&inventory.WeaponClassProjectileCID).Elem(),
 		"WeaponCooling":            reflect.ValueOf(inventory.WeaponCooling),
 		"WeaponFire":               reflect.ValueOf(inventory.WeaponFire),
+		"WeaponFireAlt":            reflect.ValueOf(inventory.WeaponFireAlt),
 		"WeaponFiring":             reflect.ValueOf(inventory.WeaponFiring),
 		"WeaponHeld":               reflect.ValueOf(inventory.WeaponHeld),
 		"WeaponHolstered":          reflect.ValueOf(inventory.WeaponHolstered),
@@ -43,6 +46,7 @@ func init() {
 		"WeaponIntentString":       reflect.ValueOf(inventory.WeaponIntentString),
 		"WeaponIntentStrings":      reflect.ValueOf(inventory.WeaponIntentStrings),
 		"WeaponIntentValues":       reflect.ValueOf(inventory.WeaponIntentValues),
+		"WeaponReload":             reflect.ValueOf(inventory.WeaponReload),
 		"WeaponReloading":          reflect.ValueOf(inventory.WeaponReloading),
 		"WeaponStateCount":         reflect.ValueOf(inventory.WeaponStateCount),
 		"WeaponStateString":        reflect.ValueOf(inventory.WeaponStateString),
@@ -54,6 +58,7 @@ func init() {
 		"Carrier":               reflect.ValueOf((*inventory.Carrier)(nil)),
 		"Item":                  reflect.ValueOf((*inventory.Item)(nil)),
 		"ItemFlags":             reflect.ValueOf((*inventory.ItemFlags)(nil)),
+		"Projectile":            reflect.ValueOf((*inventory.Projectile)(nil)),
 		"Slot":                  reflect.ValueOf((*inventory.Slot)(nil)),
 		"Weapon":                reflect.ValueOf((*inventory.Weapon)(nil)),
 		"WeaponClass":           reflect.ValueOf((*inventory.WeaponClass)(nil)),
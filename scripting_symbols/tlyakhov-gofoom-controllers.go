@@ -15,6 +15,7 @@ func init() {
 		// function, constant and variable definitions
 		"AutoPortal":                reflect.ValueOf(controllers.AutoPortal),
 		"BodySectorScript":          reflect.ValueOf(controllers.BodySectorScript),
+		"CaptureParentOffsets":      reflect.ValueOf(controllers.CaptureParentOffsets),
 		"Cast":                      reflect.ValueOf(controllers.Cast),
 		"CloneEntity":               reflect.ValueOf(controllers.CloneEntity),
 		"CreateFont":                reflect.ValueOf(controllers.CreateFont),
@@ -31,33 +32,51 @@ func init() {
 		"CreateTestWorld3":          reflect.ValueOf(controllers.CreateTestWorld3),
 		"DefaultMaterial":           reflect.ValueOf(controllers.DefaultMaterial),
 		"DeleteSpawned":             reflect.ValueOf(controllers.DeleteSpawned),
+		"DumpState":                 reflect.ValueOf(controllers.DumpState),
 		"EntitiesByClass":           reflect.ValueOf(controllers.EntitiesByClass),
+		"EventDamaged":              reflect.ValueOf(&controllers.EventDamaged).Elem(),
+		"EventDied":                 reflect.ValueOf(&controllers.EventDied).Elem(),
+		"EventDoorState":            reflect.ValueOf(&controllers.EventDoorState).Elem(),
+		"EventIdAltFire":            reflect.ValueOf(&controllers.EventIdAltFire).Elem(),
 		"EventIdBack":               reflect.ValueOf(&controllers.EventIdBack).Elem(),
 		"EventIdDown":               reflect.ValueOf(&controllers.EventIdDown).Elem(),
 		"EventIdForward":            reflect.ValueOf(&controllers.EventIdForward).Elem(),
 		"EventIdLeft":               reflect.ValueOf(&controllers.EventIdLeft).Elem(),
 		"EventIdPitch":              reflect.ValueOf(&controllers.EventIdPitch).Elem(),
 		"EventIdPrimaryAction":      reflect.ValueOf(&controllers.EventIdPrimaryAction).Elem(),
+		"EventIdReload":             reflect.ValueOf(&controllers.EventIdReload).Elem(),
 		"EventIdRight":              reflect.ValueOf(&controllers.EventIdRight).Elem(),
 		"EventIdSecondaryAction":    reflect.ValueOf(&controllers.EventIdSecondaryAction).Elem(),
 		"EventIdTurnLeft":           reflect.ValueOf(&controllers.EventIdTurnLeft).Elem(),
 		"EventIdTurnRight":          reflect.ValueOf(&controllers.EventIdTurnRight).Elem(),
 		"EventIdUp":                 reflect.ValueOf(&controllers.EventIdUp).Elem(),
 		"EventIdYaw":                reflect.ValueOf(&controllers.EventIdYaw).Elem(),
+		"EventProximityEnter":       reflect.ValueOf(&controllers.EventProximityEnter).Elem(),
+		"EventProximityExit":        reflect.ValueOf(&controllers.EventProximityExit).Elem(),
+		"EventRevived":              reflect.ValueOf(&controllers.EventRevived).Elem(),
+		"ImportWorld":               reflect.ValueOf(controllers.ImportWorld),
+		"LogDebug":                  reflect.ValueOf(controllers.LogDebug),
 		"MovePlayer":                reflect.ValueOf(controllers.MovePlayer),
 		"MovePlayerForce":           reflect.ValueOf(controllers.MovePlayerForce),
 		"MovePlayerNoClip":          reflect.ValueOf(controllers.MovePlayerNoClip),
 		"NpcMove":                   reflect.ValueOf(controllers.NpcMove),
+		"OffsetEntity":              reflect.ValueOf(controllers.OffsetEntity),
 		"PickUpInventoryItem":       reflect.ValueOf(controllers.PickUpInventoryItem),
+		"ProblemMismatchedPortal":   reflect.ValueOf(controllers.ProblemMismatchedPortal),
+		"ProblemOpenSector":         reflect.ValueOf(controllers.ProblemOpenSector),
 		"ResetAllSpawnables":        reflect.ValueOf(controllers.ResetAllSpawnables),
 		"RespawnAll":                reflect.ValueOf(controllers.RespawnAll),
+		"RunHeadless":               reflect.ValueOf(controllers.RunHeadless),
 		"Spawn":                     reflect.ValueOf(controllers.Spawn),
+		"ValidateWorld":             reflect.ValueOf(controllers.ValidateWorld),
 
 		// type definitions
 		"ActionController":           reflect.ValueOf((*controllers.ActionController)(nil)),
 		"AliveController":            reflect.ValueOf((*controllers.AliveController)(nil)),
 		"BodyController":             reflect.ValueOf((*controllers.BodyController)(nil)),
+		"DamageEventParams":          reflect.ValueOf((*controllers.DamageEventParams)(nil)),
 		"DoorController":             reflect.ValueOf((*controllers.DoorController)(nil)),
+		"DoorEventParams":            reflect.ValueOf((*controllers.DoorEventParams)(nil)),
 		"EntityAxisEventParams":      reflect.ValueOf((*controllers.EntityAxisEventParams)(nil)),
 		"EntityEventParams":          reflect.ValueOf((*controllers.EntityEventParams)(nil)),
 		"EphemeralController":        reflect.ValueOf((*controllers.EphemeralController)(nil)),
@@ -69,18 +88,23 @@ func init() {
 		"MarkMakerController":        reflect.ValueOf((*controllers.MarkMakerController)(nil)),
 		"MobileController":           reflect.ValueOf((*controllers.MobileController)(nil)),
 		"NpcController":              reflect.ValueOf((*controllers.NpcController)(nil)),
+		"ParentedController":         reflect.ValueOf((*controllers.ParentedController)(nil)),
 		"ParticleController":         reflect.ValueOf((*controllers.ParticleController)(nil)),
 		"PlayerController":           reflect.ValueOf((*controllers.PlayerController)(nil)),
 		"PlayerTargetableController": reflect.ValueOf((*controllers.PlayerTargetableController)(nil)),
 		"ProximityController":        reflect.ValueOf((*controllers.ProximityController)(nil)),
+		"ProximityEventParams":       reflect.ValueOf((*controllers.ProximityEventParams)(nil)),
 		"PursuerController":          reflect.ValueOf((*controllers.PursuerController)(nil)),
+		"ScriptLibraryController":    reflect.ValueOf((*controllers.ScriptLibraryController)(nil)),
 		"ScriptedController":         reflect.ValueOf((*controllers.ScriptedController)(nil)),
 		"SectorController":           reflect.ValueOf((*controllers.SectorController)(nil)),
 		"SectorSplitter":             reflect.ValueOf((*controllers.SectorSplitter)(nil)),
 		"SoundController":            reflect.ValueOf((*controllers.SoundController)(nil)),
 		"SoundEventController":       reflect.ValueOf((*controllers.SoundEventController)(nil)),
+		"SourceFileStreamController": reflect.ValueOf((*controllers.SourceFileStreamController)(nil)),
 		"UnderwaterController":       reflect.ValueOf((*controllers.UnderwaterController)(nil)),
 		"WanderController":           reflect.ValueOf((*controllers.WanderController)(nil)),
+		"WeaponClassController":      reflect.ValueOf((*controllers.WeaponClassController)(nil)),
 		"WeaponController":           reflect.ValueOf((*controllers.WeaponController)(nil)),
 	}
 }
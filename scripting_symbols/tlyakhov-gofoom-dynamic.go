@@ -27,6 +27,8 @@ func init() {
 		"AnimationLifetimeString":      reflect.ValueOf(dynamic.AnimationLifetimeString),
 		"AnimationLifetimeStrings":     reflect.ValueOf(dynamic.AnimationLifetimeStrings),
 		"AnimationLifetimeValues":      reflect.ValueOf(dynamic.AnimationLifetimeValues),
+		"CheckSandbox":                 reflect.ValueOf(dynamic.CheckSandbox),
+		"DefaultEventPriority":         reflect.ValueOf(constant.MakeFromLiteral("100", token.INT, 0)),
 		"DynamicStateString":           reflect.ValueOf(dynamic.DynamicStateString),
 		"DynamicStateStrings":          reflect.ValueOf(dynamic.DynamicStateStrings),
 		"DynamicStateValues":           reflect.ValueOf(dynamic.DynamicStateValues),
@@ -43,24 +45,40 @@ func init() {
 		"ElasticIn":                    reflect.ValueOf(dynamic.ElasticIn),
 		"ElasticInOut":                 reflect.ValueOf(dynamic.ElasticInOut),
 		"ElasticOut":                   reflect.ValueOf(dynamic.ElasticOut),
+		"EnterSandbox":                 reflect.ValueOf(dynamic.EnterSandbox),
+		"ErrSandboxed":                 reflect.ValueOf(&dynamic.ErrSandboxed).Elem(),
 		"EventClasses":                 reflect.ValueOf(dynamic.EventClasses),
+		"EventHistoryLength":           reflect.ValueOf(constant.MakeFromLiteral("256", token.INT, 0)),
+		"ExitSandbox":                  reflect.ValueOf(dynamic.ExitSandbox),
+		"LedgerVersion":                reflect.ValueOf(constant.MakeFromLiteral("1", token.INT, 0)),
 		"Lerp":                         reflect.ValueOf(dynamic.Lerp),
 		"MaxEvents":                    reflect.ValueOf(constant.MakeFromLiteral("1024", token.INT, 0)),
+		"NewLedgerPlayer":              reflect.ValueOf(dynamic.NewLedgerPlayer),
+		"NewLedgerRecorder":            reflect.ValueOf(dynamic.NewLedgerRecorder),
 		"NewSimulation":                reflect.ValueOf(dynamic.NewSimulation),
 		"Now":                          reflect.ValueOf(dynamic.Now),
+		"PlayFromFile":                 reflect.ValueOf(dynamic.PlayFromFile),
 		"Prev":                         reflect.ValueOf(dynamic.Prev),
 		"Random":                       reflect.ValueOf(dynamic.Random),
+		"RecordToFile":                 reflect.ValueOf(dynamic.RecordToFile),
 		"RegisterEventClass":           reflect.ValueOf(dynamic.RegisterEventClass),
+		"RegisterEventData":            reflect.ValueOf(dynamic.RegisterEventData),
+		"RegisterTimerHandler":         reflect.ValueOf(dynamic.RegisterTimerHandler),
 		"Render":                       reflect.ValueOf(dynamic.Render),
+		"Sandboxed":                    reflect.ValueOf(dynamic.Sandboxed),
 		"Spawn":                        reflect.ValueOf(dynamic.Spawn),
 		"Spike":                        reflect.ValueOf(dynamic.Spike),
 		"Spike2":                       reflect.ValueOf(dynamic.Spike2),
 		"Spike3":                       reflect.ValueOf(dynamic.Spike3),
 		"Spike4":                       reflect.ValueOf(dynamic.Spike4),
 		"SubscribeToEvent":             reflect.ValueOf(dynamic.SubscribeToEvent),
+		"SubscribeToEventWithPriority": reflect.ValueOf(dynamic.SubscribeToEventWithPriority),
+		"SubscribeToTarget":            reflect.ValueOf(dynamic.SubscribeToTarget),
 		"TweenAngles":                  reflect.ValueOf(dynamic.TweenAngles),
 		"TweeningFuncNames":            reflect.ValueOf(&dynamic.TweeningFuncNames).Elem(),
 		"TweeningFuncs":                reflect.ValueOf(&dynamic.TweeningFuncs).Elem(),
+		"Unsubscribe":                  reflect.ValueOf(dynamic.Unsubscribe),
+		"UnsubscribeTarget":            reflect.ValueOf(dynamic.UnsubscribeTarget),
 
 		// type definitions
 		"Animated":             reflect.ValueOf((*dynamic.Animated)(nil)),
@@ -73,9 +91,17 @@ func init() {
 		"EventConsumer":        reflect.ValueOf((*dynamic.EventConsumer)(nil)),
 		"EventID":              reflect.ValueOf((*dynamic.EventID)(nil)),
 		"EventQueue":           reflect.ValueOf((*dynamic.EventQueue)(nil)),
+		"EventRecord":          reflect.ValueOf((*dynamic.EventRecord)(nil)),
+		"EventSubscription":    reflect.ValueOf((*dynamic.EventSubscription)(nil)),
+		"Ledger":               reflect.ValueOf((*dynamic.Ledger)(nil)),
+		"LedgerEvent":          reflect.ValueOf((*dynamic.LedgerEvent)(nil)),
+		"LedgerFrame":          reflect.ValueOf((*dynamic.LedgerFrame)(nil)),
+		"LedgerHeader":         reflect.ValueOf((*dynamic.LedgerHeader)(nil)),
+		"LedgerStep":           reflect.ValueOf((*dynamic.LedgerStep)(nil)),
 		"Simulation":           reflect.ValueOf((*dynamic.Simulation)(nil)),
 		"Spawnable":            reflect.ValueOf((*dynamic.Spawnable)(nil)),
 		"Timer":                reflect.ValueOf((*dynamic.Timer)(nil)),
+		"TimerHandler":         reflect.ValueOf((*dynamic.TimerHandler)(nil)),
 		"TweeningFunc":         reflect.ValueOf((*dynamic.TweeningFunc)(nil)),
 
 		// interface wrapper definitions
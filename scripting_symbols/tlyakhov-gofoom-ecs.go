@@ -21,6 +21,12 @@ func init() {
 		"AllComponents":                   reflect.ValueOf(ecs.AllComponents),
 		"ArenaByID":                       reflect.ValueOf(ecs.ArenaByID),
 		"Attach":                          reflect.ValueOf(ecs.Attach),
+		"BinaryWorldExtension":            reflect.ValueOf(constant.MakeFromLiteral("\".foom\"", token.STRING, 0)),
+		"ChangeAttached":                  reflect.ValueOf(ecs.ChangeAttached),
+		"ChangeDetached":                  reflect.ValueOf(ecs.ChangeDetached),
+		"ChangeGeneration":                reflect.ValueOf(ecs.ChangeGeneration),
+		"ChangeModified":                  reflect.ValueOf(ecs.ChangeModified),
+		"CompactArenas":                   reflect.ValueOf(ecs.CompactArenas),
 		"ComponentActive":                 reflect.ValueOf(ecs.ComponentActive),
 		"ComponentFlagsString":            reflect.ValueOf(ecs.ComponentFlagsString),
 		"ComponentFlagsStrings":           reflect.ValueOf(ecs.ComponentFlagsStrings),
@@ -34,13 +40,19 @@ func init() {
 		"ComponentTableGrowthRate":        reflect.ValueOf(constant.MakeFromLiteral("8", token.INT, 0)),
 		"ComponentTableHit":               reflect.ValueOf(&ecs.ComponentTableHit).Elem(),
 		"ComponentTableMiss":              reflect.ValueOf(&ecs.ComponentTableMiss).Elem(),
+		"ComponentVersion":                reflect.ValueOf(ecs.ComponentVersion),
+		"ConstructComponent":              reflect.ValueOf(ecs.ConstructComponent),
 		"ControllerFrame":                 reflect.ValueOf(ecs.ControllerFrame),
 		"ControllerPrecompute":            reflect.ValueOf(ecs.ControllerPrecompute),
 		"CreateEntity":                    reflect.ValueOf(ecs.CreateEntity),
+		"DebugControllerAccess":           reflect.ValueOf(&ecs.DebugControllerAccess).Elem(),
 		"Delete":                          reflect.ValueOf(ecs.Delete),
 		"DeleteByType":                    reflect.ValueOf(ecs.DeleteByType),
 		"DetachComponent":                 reflect.ValueOf(ecs.DetachComponent),
 		"Entities":                        reflect.ValueOf(&ecs.Entities).Elem(),
+		"EntitiesByComponent":             reflect.ValueOf(ecs.EntitiesByComponent),
+		"EntitiesByName":                  reflect.ValueOf(ecs.EntitiesByName),
+		"EntitiesByTag":                   reflect.ValueOf(ecs.EntitiesByTag),
 		"EntityBits":                      reflect.ValueOf(constant.MakeFromLiteral("24", token.INT, 0)),
 		"EntityDelimiter":                 reflect.ValueOf(constant.MakeFromLiteral("\"∈⋮\"", token.STRING, 0)),
 		"EntityHumanRegexp":               reflect.ValueOf(&ecs.EntityHumanRegexp).Elem(),
@@ -62,6 +74,7 @@ func init() {
 		"FieldNonCacheable":               reflect.ValueOf(ecs.FieldNonCacheable),
 		"FieldNonTraversable":             reflect.ValueOf(ecs.FieldNonTraversable),
 		"FieldShallowCacheable":           reflect.ValueOf(ecs.FieldShallowCacheable),
+		"FindEntities":                    reflect.ValueOf(ecs.FindEntities),
 		"FindReplaceRelations":            reflect.ValueOf(ecs.FindReplaceRelations),
 		"First":                           reflect.ValueOf(ecs.First),
 		"FuncMap":                         reflect.ValueOf(&ecs.FuncMap).Elem(),
@@ -69,15 +82,29 @@ func init() {
 		"GetEntityByName":                 reflect.ValueOf(ecs.GetEntityByName),
 		"GetLinked":                       reflect.ValueOf(ecs.GetLinked),
 		"GetNamed":                        reflect.ValueOf(ecs.GetNamed),
+		"GetPrefabInstance":               reflect.ValueOf(ecs.GetPrefabInstance),
 		"GetSourceFile":                   reflect.ValueOf(ecs.GetSourceFile),
+		"GetTagged":                       reflect.ValueOf(ecs.GetTagged),
+		"HumanBytes":                      reflect.ValueOf(ecs.HumanBytes),
+		"Import":                          reflect.ValueOf(ecs.Import),
+		"ImportSnapshot":                  reflect.ValueOf(ecs.ImportSnapshot),
 		"Initialize":                      reflect.ValueOf(ecs.Initialize),
+		"IsBinaryWorld":                   reflect.ValueOf(ecs.IsBinaryWorld),
+		"IsChanged":                       reflect.ValueOf(ecs.IsChanged),
+		"IsWorldFile":                     reflect.ValueOf(ecs.IsWorldFile),
 		"Link":                            reflect.ValueOf(ecs.Link),
 		"LinkedCID":                       reflect.ValueOf(&ecs.LinkedCID).Elem(),
 		"Load":                            reflect.ValueOf(ecs.Load),
 		"LoadComponentWithoutAttaching":   reflect.ValueOf(ecs.LoadComponentWithoutAttaching),
+		"LoadGame":                        reflect.ValueOf(ecs.LoadGame),
+		"LoadPrefab":                      reflect.ValueOf(ecs.LoadPrefab),
 		"LoadSnapshot":                    reflect.ValueOf(ecs.LoadSnapshot),
 		"Lock":                            reflect.ValueOf(&ecs.Lock).Elem(),
+		"MarkModified":                    reflect.ValueOf(ecs.MarkModified),
+		"MarshalBinarySnapshot":           reflect.ValueOf(ecs.MarshalBinarySnapshot),
 		"MaxEntities":                     reflect.ValueOf(constant.MakeFromLiteral("16777215", token.INT, 0)),
+		"MemoryStats":                     reflect.ValueOf(ecs.MemoryStats),
+		"MigrateSnapshot":                 reflect.ValueOf(ecs.MigrateSnapshot),
 		"ModifyComponentRelationEntities": reflect.ValueOf(ecs.ModifyComponentRelationEntities),
 		"ModifyEntityRelationEntities":    reflect.ValueOf(ecs.ModifyEntityRelationEntities),
 		"MoveEntityComponents":            reflect.ValueOf(ecs.MoveEntityComponents),
@@ -85,15 +112,24 @@ func init() {
 		"NewAttachedComponent":            reflect.ValueOf(ecs.NewAttachedComponent),
 		"NewAttachedComponentTyped":       reflect.ValueOf(ecs.NewAttachedComponentTyped),
 		"NewEntity":                       reflect.ValueOf(ecs.NewEntity),
+		"NewProblem":                      reflect.ValueOf(ecs.NewProblem),
+		"NewQuery":                        reflect.ValueOf(ecs.NewQuery),
 		"NextFreeEntitySourceID":          reflect.ValueOf(ecs.NextFreeEntitySourceID),
+		"OnInitialize":                    reflect.ValueOf(ecs.OnInitialize),
+		"ParallelControllers":             reflect.ValueOf(&ecs.ParallelControllers).Elem(),
 		"ParseComponentIDs":               reflect.ValueOf(ecs.ParseComponentIDs),
 		"ParseEntitiesFromMap":            reflect.ValueOf(ecs.ParseEntitiesFromMap),
 		"ParseEntity":                     reflect.ValueOf(ecs.ParseEntity),
 		"ParseEntityHumanOrCanonical":     reflect.ValueOf(ecs.ParseEntityHumanOrCanonical),
 		"ParseEntitySlice":                reflect.ValueOf(ecs.ParseEntitySlice),
 		"ParseEntityTable":                reflect.ValueOf(ecs.ParseEntityTable),
+		"PrecomputeChanged":               reflect.ValueOf(ecs.PrecomputeChanged),
+		"PrefabInstanceCID":               reflect.ValueOf(&ecs.PrefabInstanceCID).Elem(),
+		"ProblemBrokenReference":          reflect.ValueOf(ecs.ProblemBrokenReference),
+		"ProblemUnreachableEntity":        reflect.ValueOf(ecs.ProblemUnreachableEntity),
 		"RangeComponentRelations":         reflect.ValueOf(ecs.RangeComponentRelations),
 		"RangeRelations":                  reflect.ValueOf(ecs.RangeRelations),
+		"ReadSnapshotFile":                reflect.ValueOf(ecs.ReadSnapshotFile),
 		"RelationMap":                     reflect.ValueOf(ecs.RelationMap),
 		"RelationOne":                     reflect.ValueOf(ecs.RelationOne),
 		"RelationSet":                     reflect.ValueOf(ecs.RelationSet),
@@ -101,7 +137,10 @@ func init() {
 		"RelationTable":                   reflect.ValueOf(ecs.RelationTable),
 		"RelationUnknown":                 reflect.ValueOf(ecs.RelationUnknown),
 		"Save":                            reflect.ValueOf(ecs.Save),
+		"SaveGame":                        reflect.ValueOf(ecs.SaveGame),
+		"SaveGameVersion":                 reflect.ValueOf(constant.MakeFromLiteral("1", token.INT, 0)),
 		"SaveSnapshot":                    reflect.ValueOf(ecs.SaveSnapshot),
+		"SerializeComponent":              reflect.ValueOf(ecs.SerializeComponent),
 		"SerializeComponentIDs":           reflect.ValueOf(ecs.SerializeComponentIDs),
 		"SerializeEntity":                 reflect.ValueOf(ecs.SerializeEntity),
 		"Simulation":                      reflect.ValueOf(&ecs.Simulation).Elem(),
@@ -111,35 +150,61 @@ func init() {
 		"SourceFileFromHash":              reflect.ValueOf(ecs.SourceFileFromHash),
 		"SourceFileIDs":                   reflect.ValueOf(&ecs.SourceFileIDs).Elem(),
 		"SourceFileNames":                 reflect.ValueOf(&ecs.SourceFileNames).Elem(),
+		"StateHash":                       reflect.ValueOf(ecs.StateHash),
+		"StreamInBackground":              reflect.ValueOf(&ecs.StreamInBackground).Elem(),
+		"StreamIncludes":                  reflect.ValueOf(&ecs.StreamIncludes).Elem(),
+		"TaggedCID":                       reflect.ValueOf(&ecs.TaggedCID).Elem(),
 		"Types":                           reflect.ValueOf(ecs.Types),
+		"UndeclaredControllerAccesses":    reflect.ValueOf(ecs.UndeclaredControllerAccesses),
+		"UnmarshalBinarySnapshot":         reflect.ValueOf(ecs.UnmarshalBinarySnapshot),
+		"UpdatePrefabOverrides":           reflect.ValueOf(ecs.UpdatePrefabOverrides),
+		"Validate":                        reflect.ValueOf(ecs.Validate),
 		"WorkingDirForEntity":             reflect.ValueOf(ecs.WorkingDirForEntity),
+		"WorldFormatVersion":              reflect.ValueOf(constant.MakeFromLiteral("1", token.INT, 0)),
+		"WorldHeader":                     reflect.ValueOf(ecs.WorldHeader),
+		"WriteSnapshotFile":               reflect.ValueOf(ecs.WriteSnapshotFile),
 
 		// type definitions
-		"Attachable":             reflect.ValueOf((*ecs.Attachable)(nil)),
-		"Attached":               reflect.ValueOf((*ecs.Attached)(nil)),
-		"AttachedWithIndirects":  reflect.ValueOf((*ecs.AttachedWithIndirects)(nil)),
-		"BaseController":         reflect.ValueOf((*ecs.BaseController)(nil)),
-		"Component":              reflect.ValueOf((*ecs.Component)(nil)),
-		"ComponentArena":         reflect.ValueOf((*ecs.ComponentArena)(nil)),
-		"ComponentFlags":         reflect.ValueOf((*ecs.ComponentFlags)(nil)),
-		"ComponentID":            reflect.ValueOf((*ecs.ComponentID)(nil)),
-		"ComponentTable":         reflect.ValueOf((*ecs.ComponentTable)(nil)),
-		"ComponentWithIndirects": reflect.ValueOf((*ecs.ComponentWithIndirects)(nil)),
-		"Controller":             reflect.ValueOf((*ecs.Controller)(nil)),
-		"ControllerMethod":       reflect.ValueOf((*ecs.ControllerMethod)(nil)),
-		"Entity":                 reflect.ValueOf((*ecs.Entity)(nil)),
-		"EntitySourceID":         reflect.ValueOf((*ecs.EntitySourceID)(nil)),
-		"EntityTable":            reflect.ValueOf((*ecs.EntityTable)(nil)),
-		"FieldFlags":             reflect.ValueOf((*ecs.FieldFlags)(nil)),
-		"Linked":                 reflect.ValueOf((*ecs.Linked)(nil)),
-		"LinkedController":       reflect.ValueOf((*ecs.LinkedController)(nil)),
-		"Named":                  reflect.ValueOf((*ecs.Named)(nil)),
-		"Relation":               reflect.ValueOf((*ecs.Relation)(nil)),
-		"RelationType":           reflect.ValueOf((*ecs.RelationType)(nil)),
-		"Serializable":           reflect.ValueOf((*ecs.Serializable)(nil)),
-		"Snapshot":               reflect.ValueOf((*ecs.Snapshot)(nil)),
-		"SourceFile":             reflect.ValueOf((*ecs.SourceFile)(nil)),
-		"SourceFileHash":         reflect.ValueOf((*ecs.SourceFileHash)(nil)),
+		"AppliedMigration":         reflect.ValueOf((*ecs.AppliedMigration)(nil)),
+		"ArenaStats":               reflect.ValueOf((*ecs.ArenaStats)(nil)),
+		"Attachable":               reflect.ValueOf((*ecs.Attachable)(nil)),
+		"Attached":                 reflect.ValueOf((*ecs.Attached)(nil)),
+		"AttachedWithIndirects":    reflect.ValueOf((*ecs.AttachedWithIndirects)(nil)),
+		"BaseController":           reflect.ValueOf((*ecs.BaseController)(nil)),
+		"ChangeHandler":            reflect.ValueOf((*ecs.ChangeHandler)(nil)),
+		"ChangeKind":               reflect.ValueOf((*ecs.ChangeKind)(nil)),
+		"Component":                reflect.ValueOf((*ecs.Component)(nil)),
+		"ComponentArena":           reflect.ValueOf((*ecs.ComponentArena)(nil)),
+		"ComponentFlags":           reflect.ValueOf((*ecs.ComponentFlags)(nil)),
+		"ComponentID":              reflect.ValueOf((*ecs.ComponentID)(nil)),
+		"ComponentTable":           reflect.ValueOf((*ecs.ComponentTable)(nil)),
+		"ComponentWithIndirects":   reflect.ValueOf((*ecs.ComponentWithIndirects)(nil)),
+		"Controller":               reflect.ValueOf((*ecs.Controller)(nil)),
+		"ControllerAccess":         reflect.ValueOf((*ecs.ControllerAccess)(nil)),
+		"ControllerMethod":         reflect.ValueOf((*ecs.ControllerMethod)(nil)),
+		"Entity":                   reflect.ValueOf((*ecs.Entity)(nil)),
+		"EntitySourceID":           reflect.ValueOf((*ecs.EntitySourceID)(nil)),
+		"EntityTable":              reflect.ValueOf((*ecs.EntityTable)(nil)),
+		"FieldFlags":               reflect.ValueOf((*ecs.FieldFlags)(nil)),
+		"Linked":                   reflect.ValueOf((*ecs.Linked)(nil)),
+		"LinkedController":         reflect.ValueOf((*ecs.LinkedController)(nil)),
+		"Migration":                reflect.ValueOf((*ecs.Migration)(nil)),
+		"MigrationReport":          reflect.ValueOf((*ecs.MigrationReport)(nil)),
+		"Named":                    reflect.ValueOf((*ecs.Named)(nil)),
+		"Prefab":                   reflect.ValueOf((*ecs.Prefab)(nil)),
+		"PrefabInstance":           reflect.ValueOf((*ecs.PrefabInstance)(nil)),
+		"PrefabInstanceController": reflect.ValueOf((*ecs.PrefabInstanceController)(nil)),
+		"Problem":                  reflect.ValueOf((*ecs.Problem)(nil)),
+		"ProblemKind":              reflect.ValueOf((*ecs.ProblemKind)(nil)),
+		"Query":                    reflect.ValueOf((*ecs.Query)(nil)),
+		"Relation":                 reflect.ValueOf((*ecs.Relation)(nil)),
+		"RelationType":             reflect.ValueOf((*ecs.RelationType)(nil)),
+		"RuntimeSerializable":      reflect.ValueOf((*ecs.RuntimeSerializable)(nil)),
+		"Serializable":             reflect.ValueOf((*ecs.Serializable)(nil)),
+		"Snapshot":                 reflect.ValueOf((*ecs.Snapshot)(nil)),
+		"SourceFile":               reflect.ValueOf((*ecs.SourceFile)(nil)),
+		"SourceFileHash":           reflect.ValueOf((*ecs.SourceFileHash)(nil)),
+		"Tagged":                   reflect.ValueOf((*ecs.Tagged)(nil)),
 
 		// interface wrapper definitions
 		"_Attachable":             reflect.ValueOf((*_tlyakhov_gofoom_ecs_Attachable)(nil)),
@@ -147,6 +212,7 @@ func init() {
 		"_ComponentArena":         reflect.ValueOf((*_tlyakhov_gofoom_ecs_ComponentArena)(nil)),
 		"_ComponentWithIndirects": reflect.ValueOf((*_tlyakhov_gofoom_ecs_ComponentWithIndirects)(nil)),
 		"_Controller":             reflect.ValueOf((*_tlyakhov_gofoom_ecs_Controller)(nil)),
+		"_RuntimeSerializable":    reflect.ValueOf((*_tlyakhov_gofoom_ecs_RuntimeSerializable)(nil)),
 		"_Serializable":           reflect.ValueOf((*_tlyakhov_gofoom_ecs_Serializable)(nil)),
 	}
 }
@@ -219,6 +285,7 @@ type _tlyakhov_gofoom_ecs_ComponentArena struct {
 	IValue     interface{}
 	WAdd       func(c *ecs.Component)
 	WCap       func() int
+	WCompact   func() int
 	WComponent func(index int) ecs.Component
 	WDetach    func(index int)
 	WFrom      func(source ecs.ComponentArena)
@@ -227,6 +294,7 @@ type _tlyakhov_gofoom_ecs_ComponentArena struct {
 	WNew       func() ecs.Component
 	WReplace   func(c *ecs.Component, index int)
 	WSingleton func() bool
+	WStats     func() ecs.ArenaStats
 	WString    func() string
 	WType      func() reflect.Type
 }
@@ -237,6 +305,9 @@ func (W _tlyakhov_gofoom_ecs_ComponentArena) Add(c *ecs.Component) {
 func (W _tlyakhov_gofoom_ecs_ComponentArena) Cap() int {
 	return W.WCap()
 }
+func (W _tlyakhov_gofoom_ecs_ComponentArena) Compact() int {
+	return W.WCompact()
+}
 func (W _tlyakhov_gofoom_ecs_ComponentArena) Component(index int) ecs.Component {
 	return W.WComponent(index)
 }
@@ -261,6 +332,9 @@ func (W _tlyakhov_gofoom_ecs_ComponentArena) Replace(c *ecs.Component, index int
 func (W _tlyakhov_gofoom_ecs_ComponentArena) Singleton() bool {
 	return W.WSingleton()
 }
+func (W _tlyakhov_gofoom_ecs_ComponentArena) Stats() ecs.ArenaStats {
+	return W.WStats()
+}
 func (W _tlyakhov_gofoom_ecs_ComponentArena) String() string {
 	if W.WString == nil {
 		return ""
@@ -331,6 +405,7 @@ func (W _tlyakhov_gofoom_ecs_ComponentWithIndirects) String() string {
 // _tlyakhov_gofoom_ecs_Controller is an interface wrapper for Controller type
 type _tlyakhov_gofoom_ecs_Controller struct {
 	IValue               interface{}
+	WAccess              func() *ecs.ControllerAccess
 	WComponentID         func() ecs.ComponentID
 	WEditorPausedMethods func() ecs.ControllerMethod
 	WFrame               func()
@@ -339,6 +414,9 @@ type _tlyakhov_gofoom_ecs_Controller struct {
 	WTarget              func(a0 ecs.Component, a1 ecs.Entity) bool
 }
 
+func (W _tlyakhov_gofoom_ecs_Controller) Access() *ecs.ControllerAccess {
+	return W.WAccess()
+}
 func (W _tlyakhov_gofoom_ecs_Controller) ComponentID() ecs.ComponentID {
 	return W.WComponentID()
 }
@@ -358,6 +436,20 @@ func (W _tlyakhov_gofoom_ecs_Controller) Target(a0 ecs.Component, a1 ecs.Entity)
 	return W.WTarget(a0, a1)
 }
 
+// _tlyakhov_gofoom_ecs_RuntimeSerializable is an interface wrapper for RuntimeSerializable type
+type _tlyakhov_gofoom_ecs_RuntimeSerializable struct {
+	IValue            interface{}
+	WConstructRuntime func(data map[string]any)
+	WSerializeRuntime func() map[string]any
+}
+
+func (W _tlyakhov_gofoom_ecs_RuntimeSerializable) ConstructRuntime(data map[string]any) {
+	W.WConstructRuntime(data)
+}
+func (W _tlyakhov_gofoom_ecs_RuntimeSerializable) SerializeRuntime() map[string]any {
+	return W.WSerializeRuntime()
+}
+
 // _tlyakhov_gofoom_ecs_Serializable is an interface wrapper for Serializable type
 type _tlyakhov_gofoom_ecs_Serializable struct {
 	IValue     interface{}
@@ -0,0 +1,71 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	_ "tlyakhov/gofoom/components/behaviors"
+	"tlyakhov/gofoom/controllers"
+	"tlyakhov/gofoom/dynamic"
+	"tlyakhov/gofoom/ecs"
+	_ "tlyakhov/gofoom/scripting_symbols"
+)
+
+// Plays back a demo recorded with `game -record` without a renderer or audio,
+// and prints a hash of the final world state. Two runs of the same demo
+// against the same world should always produce the same hash.
+
+var worldPath = flag.String("world", "", "World to load (defaults to the world stored in the demo)")
+var demoPath = flag.String("demo", "", "Demo file to play back")
+var maxFrames = flag.Int("max-frames", 0, "Stop after this many frames (0 = play to the end)")
+
+func main() {
+	flag.Parse()
+	if *demoPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	world := *worldPath
+	if world == "" {
+		l, err := dynamic.PlayFromFile(*demoPath)
+		if err != nil {
+			log.Fatalf("Error reading demo: %v", err)
+		}
+		world = l.Header.World
+		l.Close()
+	}
+
+	ecs.Initialize()
+	if err := ecs.Load(world); err != nil {
+		log.Fatalf("Error loading world %v: %v", world, err)
+	}
+	controllers.RespawnAll()
+
+	ecs.Simulation.Integrate = func() {
+		ecs.ActAllControllers(ecs.ControllerFrame)
+	}
+	if err := ecs.Simulation.Play(*demoPath); err != nil {
+		log.Fatalf("Error playing demo: %v", err)
+	}
+	defer ecs.Simulation.StopLedger()
+
+	frames := 0
+	for !ecs.Simulation.Ledger.Finished {
+		ecs.Simulation.Step()
+		frames++
+		if *maxFrames > 0 && frames >= *maxFrames {
+			break
+		}
+	}
+
+	hash, err := ecs.StateHash()
+	if err != nil {
+		log.Fatalf("Error hashing world state: %v", err)
+	}
+	fmt.Printf("frames: %v\nsteps: %v\nhash: %016x\n", frames, ecs.Simulation.Counter, hash)
+}
@@ -0,0 +1,52 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"tlyakhov/gofoom/controllers"
+	"tlyakhov/gofoom/ecs"
+)
+
+// check validates a world, printing any problems. If fix is set, whatever can
+// be repaired is and the world is saved in place. Returns the number of
+// problems that are left.
+func check(path string, fix, asJSON bool) (int, error) {
+	ecs.Initialize()
+	if err := ecs.Load(path); err != nil {
+		return 0, fmt.Errorf("check: %w", err)
+	}
+	problems := controllers.ValidateWorld(fix)
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(problems); err != nil {
+			return 0, fmt.Errorf("check: %w", err)
+		}
+	} else {
+		for _, p := range problems {
+			fmt.Println(p.String())
+		}
+	}
+
+	remaining := 0
+	fixed := 0
+	for _, p := range problems {
+		if p.Fixed {
+			fixed++
+		} else {
+			remaining++
+		}
+	}
+	if fixed > 0 {
+		ecs.Save(path)
+	}
+	log.Printf("%v: %v problems, %v fixed", path, len(problems), fixed)
+	return remaining, nil
+}
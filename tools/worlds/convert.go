@@ -0,0 +1,42 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+
+	"tlyakhov/gofoom/ecs"
+)
+
+// convert rewrites a world file in the format implied by the destination's
+// extension. This only works with the raw data, so nothing gets loaded and
+// the output can be converted back to exactly the same input.
+func convert(from, to string) error {
+	snapshot, err := ecs.ReadSnapshotFile(from)
+	if err != nil {
+		return fmt.Errorf("convert: %w", err)
+	}
+	if err = ecs.WriteSnapshotFile(to, snapshot); err != nil {
+		return fmt.Errorf("convert: %w", err)
+	}
+
+	// Make sure we can read back what we wrote.
+	written, err := ecs.ReadSnapshotFile(to)
+	if err != nil {
+		return fmt.Errorf("convert: reading back %v: %w", to, err)
+	}
+	if !reflect.DeepEqual(snapshot, written) {
+		return fmt.Errorf("convert: %v doesn't match %v after conversion", to, from)
+	}
+
+	fromInfo, _ := os.Stat(from)
+	toInfo, _ := os.Stat(to)
+	if fromInfo != nil && toInfo != nil {
+		log.Printf("Converted %v (%v bytes) to %v (%v bytes)", from, fromInfo.Size(), to, toInfo.Size())
+	}
+	return nil
+}
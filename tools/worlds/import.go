@@ -0,0 +1,36 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"tlyakhov/gofoom/concepts"
+	"tlyakhov/gofoom/controllers"
+	"tlyakhov/gofoom/ecs"
+)
+
+// importWorld merges the entities from one world into another with new IDs,
+// and saves the result.
+func importWorld(into, from, out, offset string) error {
+	delta := &concepts.Vector3{}
+	if offset != "" {
+		var err error
+		if delta, err = concepts.ParseVector3(offset); err != nil {
+			return fmt.Errorf("import: offset: %w", err)
+		}
+	}
+	ecs.Initialize()
+	if err := ecs.Load(into); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	imported, err := controllers.ImportWorld(from, delta)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	log.Printf("Imported %v entities from %v into %v", len(imported), from, into)
+	ecs.Save(out)
+	return nil
+}
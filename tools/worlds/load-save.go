@@ -1,41 +1,78 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
 	_ "tlyakhov/gofoom/components/behaviors"
 	_ "tlyakhov/gofoom/controllers"
 	"tlyakhov/gofoom/ecs"
 	_ "tlyakhov/gofoom/scripting_symbols"
 )
 
+var allowLossy = flag.Bool("lossy", false, "allow upgrades that lose data")
+var importOffset = flag.String("offset", "", "move imported geometry by \"x, y, z\"")
+var checkFix = flag.Bool("fix", false, "repair problems found by check and save the world")
+var checkJSON = flag.Bool("json", false, "print problems found by check as JSON")
+
 func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  worlds [-lossy]        upgrade and re-save every world in gofoom-data\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  worlds <from> <to>     convert a world between YAML and binary (%v)\n", ecs.BinaryWorldExtension)
+		fmt.Fprintf(flag.CommandLine.Output(), "  worlds [-offset x,y,z] import <world> <other> <out>\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "                         merge <other> into <world> with new entity IDs, saving to <out>\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  worlds [-fix] [-json] check <world>\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "                         check a world for broken references and geometry\n")
+	}
+	flag.Parse()
+	if flag.NArg() == 2 && flag.Arg(0) == "check" {
+		remaining, err := check(flag.Arg(1), *checkFix, *checkJSON)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if remaining > 0 {
+			os.Exit(1)
+		}
+		return
+	} else if flag.NArg() == 4 && flag.Arg(0) == "import" {
+		if err := importWorld(flag.Arg(1), flag.Arg(2), flag.Arg(3), *importOffset); err != nil {
+			log.Fatal(err)
+		}
+		return
+	} else if flag.NArg() == 2 {
+		if err := convert(flag.Arg(0), flag.Arg(1)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	} else if flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
 	// Assumes we're running this from gofoom/tools/worlds
 	os.Chdir("../../../gofoom-data")
+	updated := make(map[string]string)
 	filepath.Walk("worlds/", func(path string, info fs.FileInfo, err error) error {
-		if !strings.HasSuffix(path, ".yaml") {
+		if !ecs.IsWorldFile(path) {
 			return nil
 		}
 		log.Printf("Processing %v", path)
-		ecs.Initialize()
-		if err = ecs.Load(path); err != nil {
-			log.Printf("Error loading world %v", err)
+		// Keep the extension so that the format doesn't change.
+		updatedPath := path + ".updated" + filepath.Ext(path)
+		if err = upgrade(path, updatedPath, *allowLossy); err != nil {
+			log.Printf("Error upgrading world %v", err)
 			return nil
 		}
-		ecs.Save(path + ".updated")
+		updated[updatedPath] = path
 		return nil
 	})
-	filepath.Walk("worlds/", func(path string, info fs.FileInfo, err error) error {
-		if !strings.HasSuffix(path, ".yaml.updated") {
-			return nil
-		}
-		err = os.Rename(path, strings.TrimSuffix(path, ".updated"))
-		if err != nil {
-			log.Printf("Error renaming %v: %v", path, err)
+	for from, to := range updated {
+		if err := os.Rename(from, to); err != nil {
+			log.Printf("Error renaming %v: %v", from, err)
 		}
-		return nil
-	})
+	}
 }
@@ -0,0 +1,109 @@
+// Copyright (c) Tim Lyakhovetskiy
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"reflect"
+	"slices"
+
+	"tlyakhov/gofoom/ecs"
+
+	"github.com/spf13/cast"
+)
+
+// upgrade loads a world, migrating any old component data, and saves it again.
+// Anything that would be lost (lossy migrations, or fields that don't get
+// written back) is reported, and the world isn't saved unless allowLossy is
+// set.
+func upgrade(path, out string, allowLossy bool) error {
+	raw, err := ecs.ReadSnapshotFile(path)
+	if err != nil {
+		return fmt.Errorf("upgrade: %w", err)
+	}
+	migrated, report, err := ecs.MigrateSnapshot(raw)
+	if err != nil {
+		return fmt.Errorf("upgrade: %w", err)
+	}
+	for _, am := range report.Applied {
+		log.Printf("  Migrated %v", am)
+	}
+
+	ecs.Initialize()
+	if err = ecs.Load(path); err != nil {
+		return fmt.Errorf("upgrade: %w", err)
+	}
+	dropped := droppedFields(migrated, ecs.SaveSnapshot(false))
+	for _, field := range dropped {
+		log.Printf("  Dropped %v", field)
+	}
+
+	if (report.Lossy() || len(dropped) > 0) && !allowLossy {
+		return fmt.Errorf("upgrade: %v would lose data, run with -lossy to save anyway", path)
+	}
+	ecs.Save(out)
+	return nil
+}
+
+func isEmptyValue(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	}
+	return rv.IsZero()
+}
+
+// droppedFields compares the components and fields of each entity in a world
+// file with what was saved after loading it. Empty values are ignored, since
+// components usually don't save defaults.
+func droppedFields(before, after ecs.Snapshot) []string {
+	saved := make(map[ecs.Entity]map[string]any)
+	for _, element := range after {
+		if m, ok := element.(map[string]any); ok {
+			e, _ := ecs.ParseEntity(cast.ToString(m["Entity"]))
+			saved[e] = m
+		}
+	}
+
+	result := make([]string, 0)
+	for _, element := range before {
+		m, ok := element.(map[string]any)
+		if !ok {
+			continue
+		}
+		e, _ := ecs.ParseEntity(cast.ToString(m["Entity"]))
+		savedEntity := saved[e]
+		if savedEntity == nil {
+			result = append(result, fmt.Sprintf("%v (entity)", e))
+			continue
+		}
+		for _, name := range slices.Sorted(maps.Keys(m)) {
+			if name == "Entity" || isEmptyValue(m[name]) {
+				continue
+			}
+			savedComponent, ok := savedEntity[name]
+			if !ok {
+				result = append(result, fmt.Sprintf("%v %v", e, name))
+				continue
+			}
+			component, ok := m[name].(map[string]any)
+			if !ok {
+				continue
+			}
+			savedFields, _ := savedComponent.(map[string]any)
+			for _, field := range slices.Sorted(maps.Keys(component)) {
+				if _, ok := savedFields[field]; !ok && !isEmptyValue(component[field]) {
+					result = append(result, fmt.Sprintf("%v %v.%v", e, name, field))
+				}
+			}
+		}
+	}
+	return result
+}